@@ -0,0 +1,66 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type auditLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// auditRecord is the JSON record that will be written for every operation
+type auditRecord struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Operation  string    `json:"operation"`
+	Path       string    `json:"path"`
+	Target     string    `json:"target,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// WithAuditLog writes a JSON line for every operation that modifies the drive (e.g. PutFile, Delete, Move) to writer.
+// Every line contains the timestamp, operation, path, target (for operations like Move), outcome ("success" or "error"),
+// error and the duration in milliseconds.
+// The writer is protected by a lock, so it does not need to be safe for concurrent use.
+// Errors of the writer are ignored.
+func WithAuditLog(writer io.Writer) Option {
+	return func(driver *GDriver) error {
+		driver.auditLog = &auditLog{
+			encoder: json.NewEncoder(writer),
+		}
+		return nil
+	}
+}
+
+// audit starts the record of an operation, call the returned function with a pointer to the result error when the operation finished
+//
+// Example:
+//     defer d.audit("Delete", path, "")(&err)
+func (d *GDriver) audit(operation, path, target string) func(*error) {
+	if d.auditLog == nil {
+		return func(*error) {}
+	}
+	start := time.Now()
+	return func(err *error) {
+		record := auditRecord{
+			Timestamp:  start.UTC(),
+			Operation:  operation,
+			Path:       path,
+			Target:     target,
+			Outcome:    "success",
+			DurationMs: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+		}
+		if err != nil && *err != nil {
+			record.Outcome = "error"
+			record.Error = (*err).Error()
+		}
+		d.auditLog.mu.Lock()
+		d.auditLog.encoder.Encode(record) // nolint: errcheck
+		d.auditLog.mu.Unlock()
+	}
+}
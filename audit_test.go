@@ -0,0 +1,72 @@
+package gdriver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditRecords(t *testing.T, buf *bytes.Buffer) []auditRecord {
+	var records []auditRecord
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record auditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	driver := &GDriver{}
+	require.NoError(t, WithAuditLog(&buf)(driver))
+
+	err := errors.New("Custom Error")
+	driver.audit("Move", "Folder1/File1", "Folder2/File1")(&err)
+	driver.audit("Delete", "File1", "")(new(error))
+
+	records := readAuditRecords(t, &buf)
+	require.Len(t, records, 2)
+
+	require.Equal(t, "Move", records[0].Operation)
+	require.Equal(t, "Folder1/File1", records[0].Path)
+	require.Equal(t, "Folder2/File1", records[0].Target)
+	require.Equal(t, "error", records[0].Outcome)
+	require.Equal(t, "Custom Error", records[0].Error)
+	require.False(t, records[0].Timestamp.IsZero())
+
+	require.Equal(t, "Delete", records[1].Operation)
+	require.Equal(t, "success", records[1].Outcome)
+	require.Empty(t, records[1].Error)
+}
+
+func TestWithAuditLog(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	var buf bytes.Buffer
+	require.NoError(t, WithAuditLog(&buf)(driver))
+
+	newFile(t, driver, "File1", "Hello World")
+	require.NoError(t, driver.Delete("File1"))
+
+	// reading operations are not recorded
+	require.Error(t, getError(driver.Stat("File1")))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	records := readAuditRecords(t, &buf)
+	require.Equal(t, "PutFile", records[0].Operation)
+	require.Equal(t, "Delete", records[1].Operation)
+	for _, record := range records {
+		require.Equal(t, "File1", record.Path)
+		require.Equal(t, "success", record.Outcome)
+		require.Empty(t, record.Error)
+	}
+}
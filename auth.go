@@ -0,0 +1,79 @@
+package gdriver
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// WithTokenSource sets the token source that is checked by ForceRefreshToken,
+// by default the token source of the client is used if it was created by the oauth2 package (e.g. oauth2.Config.Client).
+// Note that the client passed to New is still used for all requests.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(driver *GDriver) error {
+		driver.tokenSource = ts
+		return nil
+	}
+}
+
+// clientTokenSource returns the token source of the client if it uses an oauth2.Transport
+func clientTokenSource(client *http.Client) oauth2.TokenSource {
+	if client == nil {
+		return nil
+	}
+	if t, ok := client.Transport.(*oauth2.Transport); ok {
+		return t.Source
+	}
+	return nil
+}
+
+// ForceRefreshToken checks that the authorization is still valid: it requests a token from the token source (see WithTokenSource)
+// and makes a cheap authenticated request with the client. Token sources that cache tokens (like oauth2.ReuseTokenSource)
+// return the cached token, the request makes sure that the token was not revoked in the meantime.
+// An AuthError will be returned if no valid token could be retrieved or drive rejected the token (e.g. because the user revoked the access).
+// Use it before long operations to re-authenticate early.
+func (d *GDriver) ForceRefreshToken(ctx context.Context) error {
+	if d.tokenSource != nil {
+		if err := d.checkTokenSource(ctx); err != nil {
+			return err
+		}
+	}
+
+	_, err := d.srv.About.Get().Fields("user(permissionId)").Context(ctx).Do()
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusUnauthorized {
+		return AuthError{Reason: apiErr.Message}
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// checkTokenSource requests a token from the token source
+func (d *GDriver) checkTokenSource(ctx context.Context) error {
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+	// oauth2.TokenSource does not accept a context, so the refresh is abandoned if ctx is done
+	done := make(chan result, 1)
+	go func() {
+		token, err := d.tokenSource.Token()
+		done <- result{token: token, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r := <-done:
+		if r.err != nil {
+			return AuthError{Reason: r.err.Error()}
+		}
+		if !r.token.Valid() {
+			return AuthError{Reason: "token source returned an invalid token"}
+		}
+		return nil
+	}
+}
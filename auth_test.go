@@ -0,0 +1,114 @@
+package gdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// tokenSourceFunc is a mock oauth2.TokenSource
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+func TestForceRefreshToken(t *testing.T) {
+	var status int32 = http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/drive/v3/about", r.URL.Path)
+		code := int(atomic.LoadInt32(&status))
+		w.WriteHeader(code)
+		if code != http.StatusOK {
+			fmt.Fprintf(w, `{"error":{"code":%d,"message":"Invalid Credentials"}}`, code)
+			return
+		}
+		fmt.Fprint(w, `{"user":{"permissionId":"1"}}`)
+	}))
+	defer ts.Close()
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{srv: srv}
+
+	t.Run("without token source", func(t *testing.T) {
+		require.NoError(t, driver.ForceRefreshToken(context.Background()))
+		atomic.StoreInt32(&status, http.StatusUnauthorized)
+		defer atomic.StoreInt32(&status, http.StatusOK)
+		err := driver.ForceRefreshToken(context.Background())
+		require.Equal(t, AuthError{Reason: "Invalid Credentials"}, err)
+	})
+
+	t.Run("token source", func(t *testing.T) {
+		driver := *driver
+		require.NoError(t, WithTokenSource(tokenSourceFunc(func() (*oauth2.Token, error) {
+			return nil, errors.New("oauth2: cannot fetch token: 400 Bad Request\nResponse: {\"error\": \"invalid_grant\"}")
+		}))(&driver))
+		err := driver.ForceRefreshToken(context.Background())
+		require.IsType(t, AuthError{}, err)
+		require.Contains(t, err.(AuthError).Reason, "invalid_grant")
+
+		require.NoError(t, WithTokenSource(tokenSourceFunc(func() (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(-time.Hour)}, nil
+		}))(&driver))
+		require.IsType(t, AuthError{}, driver.ForceRefreshToken(context.Background()))
+
+		require.NoError(t, WithTokenSource(tokenSourceFunc(func() (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		}))(&driver))
+		require.NoError(t, driver.ForceRefreshToken(context.Background()))
+	})
+
+	t.Run("revoked cached token", func(t *testing.T) {
+		// a cached token is still valid locally, but drive rejects it
+		driver := *driver
+		require.NoError(t, WithTokenSource(oauth2.ReuseTokenSource(nil, tokenSourceFunc(func() (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}, nil
+		})))(&driver))
+		require.NoError(t, driver.ForceRefreshToken(context.Background()))
+		atomic.StoreInt32(&status, http.StatusUnauthorized)
+		defer atomic.StoreInt32(&status, http.StatusOK)
+		require.IsType(t, AuthError{}, driver.ForceRefreshToken(context.Background()))
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		atomic.StoreInt32(&status, http.StatusInternalServerError)
+		defer atomic.StoreInt32(&status, http.StatusOK)
+		err := driver.ForceRefreshToken(context.Background())
+		require.Error(t, err)
+		require.IsType(t, &googleapi.Error{}, err)
+	})
+
+	t.Run("client token source", func(t *testing.T) {
+		// the token source of oauth2 clients is used by default
+		source := tokenSourceFunc(func() (*oauth2.Token, error) {
+			return nil, errors.New("revoked")
+		})
+		client := &http.Client{Transport: &oauth2.Transport{Source: source}}
+		require.NotNil(t, clientTokenSource(client))
+		require.Nil(t, clientTokenSource(http.DefaultClient))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		driver := *driver
+		release := make(chan struct{})
+		defer close(release)
+		require.NoError(t, WithTokenSource(tokenSourceFunc(func() (*oauth2.Token, error) {
+			<-release
+			return nil, errors.New("too late")
+		}))(&driver))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Equal(t, context.Canceled, driver.ForceRefreshToken(ctx))
+	})
+}
@@ -0,0 +1,446 @@
+package gdriver
+
+import (
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	batchMaxAttempts    = 5
+	batchInitialBackoff = time.Second
+	batchMaxBackoff     = 32 * time.Second
+	// fileListConcurrency is the amount of paths GetFileList resolves at the same time
+	fileListConcurrency = 10
+)
+
+// ReaderProvider opens the contents of a file,
+// it will be called again if the upload has to be retried
+type ReaderProvider func() (io.Reader, error)
+
+// PutItem describes a file that should be uploaded by PutFiles
+type PutItem struct {
+	Path   string
+	Reader ReaderProvider
+}
+
+// PutResult holds the outcome of one item of a batch operation
+type PutResult struct {
+	Path     string
+	FileInfo *FileInfo
+	Err      error
+}
+
+// BatchResult holds the outcomes of a batch operation (e.g. PutFiles) in the same order as the items
+type BatchResult struct {
+	Results []PutResult
+}
+
+// Failed returns the results that failed
+func (r *BatchResult) Failed() []PutResult {
+	var failed []PutResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// PutFiles uploads multiple files using concurrency workers, progress will be called (if not nil) after every finished item.
+// Directories that are needed by the items are created once before the uploads start.
+// Uploads that fail because of rate limits or server errors will be retried, all workers pause when a rate limit was hit.
+// The returned error is only set if the batch could not be started, errors of the items are reported in the BatchResult.
+func (d *GDriver) PutFiles(items []PutItem, concurrency int, progress func(done, total int)) (*BatchResult, error) {
+	if concurrency <= 0 {
+		return nil, errors.New("concurrency must be greater than zero")
+	}
+	return d.PutFilesWithOptions(items, BatchOptions{
+		Concurrency: concurrency,
+		Progress:    progress,
+	}), nil
+}
+
+// PutFilesWithOptions uploads multiple files like PutFiles, use opts to configure the concurrency and the progress reporting
+func (d *GDriver) PutFilesWithOptions(items []PutItem, opts BatchOptions) *BatchResult {
+	result := &BatchResult{
+		Results: make([]PutResult, len(items)),
+	}
+	for i, item := range items {
+		result.Results[i].Path = item.Path
+		if item.Reader == nil {
+			result.Results[i].Err = errors.New("no reader provided")
+		}
+		if len(strings.FieldsFunc(item.Path, isPathSeperator)) == 0 {
+			result.Results[i].Err = errors.New("path cannot be empty")
+		}
+	}
+
+	// create the parent directories before uploading, so no directory gets created twice
+	dirErrors := make(map[string]error)
+	for _, dir := range batchParentDirectories(items) {
+		if parentErr := dirErrors[path.Dir(dir)]; parentErr != nil {
+			dirErrors[dir] = parentErr
+			continue
+		}
+		if _, err := d.MakeDirectory(dir); err != nil {
+			dirErrors[dir] = err
+		}
+	}
+
+	report := opts.report(len(items))
+	var backoff batchBackoff
+	runBatch(len(items), opts.concurrency(), func(index int) {
+		item := items[index]
+		res := &result.Results[index]
+		report.started(item.Path)
+		if res.Err == nil {
+			res.Err = dirErrors[batchParentDirectory(item.Path)]
+		}
+		if res.Err == nil {
+			res.FileInfo, res.Err = d.putFileWithRetry(item, &backoff)
+		}
+		report.finished(item.Path, fileSize(res.FileInfo), res.Err)
+	})
+
+	return result
+}
+
+// BatchOptions can be used to configure batch operations like BulkSetStar
+type BatchOptions struct {
+	// Concurrency is the amount of workers, defaults to 1
+	Concurrency int
+	// Progress will be called (if not nil) after every finished item
+	Progress func(done, total int)
+	// Reporter receives (if not nil) the progress of every item
+	Reporter ProgressReporter
+}
+
+func (o BatchOptions) concurrency() int {
+	if o.Concurrency <= 0 {
+		return 1
+	}
+	return o.Concurrency
+}
+
+func (o BatchOptions) report(total int) *batchReport {
+	return newBatchReport(total, o.Progress, o.Reporter)
+}
+
+// BulkSetStar sets the starred flag of multiple files and directories (see SetFileStar).
+// Requests that fail because of rate limits or server errors will be retried, errors of the items are reported in the BatchResult.
+func (d *GDriver) BulkSetStar(paths []string, starred bool, opts BatchOptions) BatchResult {
+	result := BatchResult{
+		Results: make([]PutResult, len(paths)),
+	}
+	report := opts.report(len(paths))
+	var backoff batchBackoff
+	runBatch(len(paths), opts.concurrency(), func(index int) {
+		res := &result.Results[index]
+		res.Path = paths[index]
+		report.started(res.Path)
+		res.Err = retry(&backoff, func() (err error) {
+			defer d.audit("SetFileStar", paths[index], "")(&err)
+			res.FileInfo, err = d.setFileStar(paths[index], starred)
+			return err
+		})
+		report.finished(res.Path, 0, res.Err)
+	})
+	return result
+}
+
+// GetFileList returns the FileInfo of multiple files and directories (see Stat), the paths are resolved in parallel.
+// files and errs have the same length as paths, for every path either the FileInfo or the error is set.
+// err is only set if the paths could not be resolved at all.
+func (d *GDriver) GetFileList(paths []string) (files []*FileInfo, errs []error, err error) {
+	if d.rootNode == nil {
+		return nil, nil, errors.New("no root directory set")
+	}
+	files = make([]*FileInfo, len(paths))
+	errs = make([]error, len(paths))
+	var backoff batchBackoff
+	runBatch(len(paths), fileListConcurrency, func(index int) {
+		errs[index] = retry(&backoff, func() (err error) {
+			files[index], err = d.Stat(paths[index])
+			return err
+		})
+	})
+	return files, errs, nil
+}
+
+// CopyOptions can be used to configure RecursiveCopy
+type CopyOptions struct {
+	// OverwriteExisting replaces files that already exist in the destination, otherwise they fail with FileExistError
+	OverwriteExisting bool
+	// CopyMetadata copies the description, the starred flag and the properties of the files
+	CopyMetadata bool
+	// CopyDescription copies the description and the starred flag of the files
+	CopyDescription bool
+	// CopyProperties copies the properties and appProperties of the files
+	CopyProperties bool
+	// CopyPermissions recreates the permissions of the files and directories, owner and inherited permissions are skipped
+	CopyPermissions bool
+	// Concurrency is the amount of workers, defaults to 1
+	Concurrency int
+	// Reporter receives (if not nil) the progress of every copied file
+	Reporter ProgressReporter
+}
+
+// RecursiveCopy copies the directory srcPath with all its descendants to dstPath, the directory structure is recreated in dstPath.
+// Existing directories in dstPath will be reused.
+// The returned error is only set if the copy could not be started, errors of the files are reported in the BatchResult.
+// Directories whose permissions could not be recreated (see CopyPermissions) are reported after the files.
+//
+// Examples:
+//     RecursiveCopy("Pictures", "Backup/Pictures", CopyOptions{}) // copies Pictures/Holidays/image1.jpeg to Backup/Pictures/Holidays/image1.jpeg
+func (d *GDriver) RecursiveCopy(srcPath, dstPath string, opts CopyOptions) (*BatchResult, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if len(strings.FieldsFunc(dstPath, isPathSeperator)) == 0 {
+		return nil, errors.New("destination path cannot be empty")
+	}
+
+	// collect the tree first, so a destination inside of srcPath will not be copied again
+	srcDirPath := strings.Join(strings.FieldsFunc(srcPath, isPathSeperator), "/")
+	var dirs, files []string
+	// srcDirIDs holds the ids of the source directories by their relative path
+	srcDirIDs := make(map[string]string)
+	err := d.Walk(srcPath, func(f *FileInfo) error {
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(f.Path(), srcDirPath), "/")
+		if f.IsDir() {
+			dirs = append(dirs, relativePath)
+			srcDirIDs[relativePath] = f.item.Id
+		} else {
+			files = append(files, relativePath)
+		}
+		return nil
+	}, WalkSorted())
+	if err != nil {
+		return nil, err
+	}
+
+	dstDir, err := d.MakeDirectory(dstPath)
+	if err != nil {
+		return nil, err
+	}
+	var dirResults []PutResult
+	copyDirPermissions := func(srcID string, dst *FileInfo) {
+		if !opts.CopyPermissions {
+			return
+		}
+		if err := d.copyPermissions(srcID, dst.item.Id, dst.Path()); err != nil {
+			dirResults = append(dirResults, PutResult{Path: dst.Path(), FileInfo: dst, Err: err})
+		}
+	}
+	if opts.CopyPermissions {
+		srcDir, err := d.getFile(d.rootNode, srcPath, "files(id)")
+		if err != nil {
+			return nil, err
+		}
+		copyDirPermissions(srcDir.item.Id, dstDir)
+	}
+
+	// parents are walked before their children
+	dirErrors := make(map[string]error)
+	for _, dir := range dirs {
+		if parentErr := dirErrors[path.Dir(dir)]; parentErr != nil {
+			dirErrors[dir] = parentErr
+			continue
+		}
+		fi, err := d.MakeDirectory(path.Join(dstPath, dir))
+		if err != nil {
+			dirErrors[dir] = err
+			continue
+		}
+		copyDirPermissions(srcDirIDs[dir], fi)
+	}
+
+	result := &BatchResult{
+		Results: make([]PutResult, len(files), len(files)+len(dirResults)),
+	}
+	report := newBatchReport(len(files), nil, opts.Reporter)
+	var backoff batchBackoff
+	runBatch(len(files), concurrency, func(index int) {
+		res := &result.Results[index]
+		res.Path = path.Join(dstPath, files[index])
+		report.started(res.Path)
+		if res.Err = dirErrors[path.Dir(files[index])]; res.Err == nil {
+			res.Err = retry(&backoff, func() (err error) {
+				res.FileInfo, err = d.copyFile(path.Join(srcDirPath, files[index]), res.Path, opts)
+				return err
+			})
+		}
+		report.finished(res.Path, fileSize(res.FileInfo), res.Err)
+	})
+	result.Results = append(result.Results, dirResults...)
+	return result, nil
+}
+
+// copyFile copies one file of RecursiveCopy
+func (d *GDriver) copyFile(srcPath, dstPath string, opts CopyOptions) (*FileInfo, error) {
+	if opts.OverwriteExisting {
+		existing, err := d.getFile(d.rootNode, dstPath, "files(id,mimeType)")
+		if err == nil {
+			if existing.IsDir() {
+				return nil, FileIsDirectoryError{Path: dstPath}
+			}
+			if err = d.Delete(dstPath); err != nil {
+				return nil, err
+			}
+		} else if !IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return d.DuplicateFileWithOptions(srcPath, dstPath, DuplicateFileOptions{
+		CopyMetadata:    opts.CopyMetadata || opts.CopyDescription,
+		CopyProperties:  opts.CopyMetadata || opts.CopyProperties,
+		CopyPermissions: opts.CopyPermissions,
+	})
+}
+
+// runBatch calls fn for the indices 0 to n-1 using concurrency workers
+func runBatch(n, concurrency int, fn func(index int)) {
+	var wg sync.WaitGroup
+	indices := make(chan int)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				fn(index)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+func (d *GDriver) putFileWithRetry(item PutItem, backoff *batchBackoff) (fi *FileInfo, err error) {
+	err = retry(backoff, func() error {
+		r, err := item.Reader()
+		if err != nil {
+			return err
+		}
+		fi, err = d.PutFile(item.Path, r)
+		if closer, ok := r.(io.Closer); ok {
+			closer.Close() // nolint: errcheck
+		}
+		return err
+	})
+	return fi, err
+}
+
+// retry calls fn until it succeeds, fails with an error that is not retryable or the maximum attempts are reached
+func retry(backoff *batchBackoff, fn func() error) error {
+	delay := batchInitialBackoff
+	for attempt := 1; ; attempt++ {
+		backoff.wait()
+
+		err := fn()
+		if err == nil || attempt >= batchMaxAttempts || !isRetryableError(err) {
+			return err
+		}
+
+		if isRateLimitError(err) {
+			backoff.pause(delay)
+		} else {
+			time.Sleep(delay)
+		}
+		if delay *= 2; delay > batchMaxBackoff {
+			delay = batchMaxBackoff
+		}
+	}
+}
+
+// batchBackoff is shared between all workers of a batch, if one worker hits a rate limit all workers will pause
+type batchBackoff struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (b *batchBackoff) pause(d time.Duration) {
+	b.mu.Lock()
+	if until := time.Now().Add(d); until.After(b.until) {
+		b.until = until
+	}
+	b.mu.Unlock()
+}
+
+func (b *batchBackoff) wait() {
+	b.mu.Lock()
+	until := b.until
+	b.mu.Unlock()
+	if d := time.Until(until); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// isRetryableError returns true if the request that caused the error can be retried
+func isRetryableError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	return apiErr.Code >= 500 || isRateLimitError(err)
+}
+
+// isRateLimitError returns true if the error was caused by a rate limit
+func isRateLimitError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	if apiErr.Code == 429 {
+		return true
+	}
+	if apiErr.Code == 403 {
+		for _, item := range apiErr.Errors {
+			if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// batchParentDirectory returns the cleaned parent directory of a path, or an empty string for the root
+func batchParentDirectory(filePath string) string {
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	if len(pathParts) <= 1 {
+		return ""
+	}
+	return path.Join(pathParts[:len(pathParts)-1]...)
+}
+
+// batchParentDirectories returns all directories that are needed by the items, parents come before their children
+func batchParentDirectories(items []PutItem) []string {
+	seen := make(map[string]struct{})
+	var dirs []string
+	for _, item := range items {
+		for dir := batchParentDirectory(item.Path); dir != "" && dir != "."; dir = path.Dir(dir) {
+			if _, ok := seen[dir]; ok {
+				break
+			}
+			seen[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], "/") < strings.Count(dirs[j], "/") ||
+			(strings.Count(dirs[i], "/") == strings.Count(dirs[j], "/") && dirs[i] < dirs[j])
+	})
+	return dirs
+}
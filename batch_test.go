@@ -0,0 +1,198 @@
+package gdriver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+func TestBatchParentDirectories(t *testing.T) {
+	require.Equal(t, []string{"Folder1", "Folder2", "Folder1/Folder3", "Folder1/Folder3/Folder4"}, batchParentDirectories([]PutItem{
+		{Path: "Folder1/Folder3/Folder4/File1"},
+		{Path: "Folder2/File2"},
+		{Path: "/Folder1/File3"},
+		{Path: "File4"},
+		{Path: "Folder1/Folder3/File5"},
+	}))
+}
+
+func TestIsRetryableError(t *testing.T) {
+	require.True(t, isRetryableError(&googleapi.Error{Code: http.StatusInternalServerError}))
+	require.True(t, isRetryableError(&googleapi.Error{Code: http.StatusTooManyRequests}))
+	require.True(t, isRateLimitError(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}},
+	}))
+	require.False(t, isRetryableError(&googleapi.Error{Code: http.StatusForbidden}))
+	require.False(t, isRetryableError(&googleapi.Error{Code: http.StatusNotFound}))
+	require.False(t, isRetryableError(errors.New("some error")))
+}
+
+func TestPutFiles(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder3", "Hello World")
+
+	var items []PutItem
+	for i := 0; i < 10; i++ {
+		contents := fmt.Sprintf("Hello World %d", i)
+		items = append(items, PutItem{
+			Path: fmt.Sprintf("Folder%d/File%d", i%2+1, i),
+			Reader: func() (io.Reader, error) {
+				return bytes.NewBufferString(contents), nil
+			},
+		})
+	}
+	// Folder3 is a file, this item must fail
+	items = append(items, PutItem{
+		Path: "Folder3/File10",
+		Reader: func() (io.Reader, error) {
+			return bytes.NewBufferString("Hello World"), nil
+		},
+	})
+
+	var lastDone, lastTotal int
+	result, err := driver.PutFiles(items, 4, func(done, total int) {
+		lastDone = done
+		lastTotal = total
+	})
+	require.NoError(t, err)
+	require.Equal(t, len(items), lastDone)
+	require.Equal(t, len(items), lastTotal)
+	require.Len(t, result.Results, len(items))
+
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	require.Equal(t, "Folder3/File10", failed[0].Path)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, result.Results[i].Err)
+		require.Equal(t, items[i].Path, result.Results[i].FileInfo.Path())
+
+		_, r, err := driver.GetFile(items[i].Path)
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("Hello World %d", i), string(received))
+	}
+
+	// every directory must exist only once
+	var dirs int
+	require.NoError(t, driver.ListDirectory("", func(f *FileInfo) error {
+		if f.IsDir() {
+			dirs++
+		}
+		return nil
+	}))
+	require.Equal(t, 2, dirs)
+}
+
+func TestBulkSetStar(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/File2", "Hello World")
+
+	paths := []string{"Folder1/File1", "Folder1/File2", "Folder1/File3"}
+	var lastDone int
+	result := driver.BulkSetStar(paths, true, BatchOptions{
+		Concurrency: 2,
+		Progress: func(done, total int) {
+			lastDone = done
+		},
+	})
+	require.Equal(t, len(paths), lastDone)
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	require.Equal(t, "Folder1/File3", failed[0].Path)
+	require.True(t, IsNotExist(failed[0].Err))
+
+	for _, res := range result.Results[:2] {
+		require.NoError(t, res.Err)
+		file, err := driver.srv.Files.Get(res.FileInfo.item.Id).Fields("starred").Do()
+		require.NoError(t, err)
+		require.True(t, file.Starred)
+	}
+
+	result = driver.BulkSetStar(paths[:2], false, BatchOptions{})
+	require.Empty(t, result.Failed())
+}
+
+func TestGetFileList(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	var paths []string
+	for i := 0; i < 5; i++ {
+		paths = append(paths, fmt.Sprintf("Folder1/File%d", i))
+		newFile(t, driver, paths[i], "Hello World")
+	}
+	paths = append(paths, "Folder1/File5", "Folder2/File1")
+
+	files, errs, err := driver.GetFileList(paths)
+	require.NoError(t, err)
+	require.Len(t, files, len(paths))
+	require.Len(t, errs, len(paths))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, errs[i])
+		require.Equal(t, paths[i], files[i].Path())
+	}
+	for i := 5; i < len(paths); i++ {
+		require.Nil(t, files[i])
+		require.True(t, IsNotExist(errs[i]))
+	}
+}
+
+func TestRecursiveCopy(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/File2", "Hello Universe")
+	newDirectory(t, driver, "Folder1/Folder3")
+
+	result, err := driver.RecursiveCopy("Folder1", "Copy/Folder1", CopyOptions{Concurrency: 2})
+	require.NoError(t, err)
+	require.Empty(t, result.Failed())
+	require.Len(t, result.Results, 2)
+
+	for _, file := range []struct {
+		path     string
+		contents string
+	}{
+		{"Folder1/File1", "Hello World"},
+		{"Folder1/Folder2/File2", "Hello Universe"},
+		{"Copy/Folder1/File1", "Hello World"},
+		{"Copy/Folder1/Folder2/File2", "Hello Universe"},
+	} {
+		_, r, err := driver.GetFile(file.path)
+		require.NoError(t, err)
+		contents, err := ioutil.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		require.Equal(t, file.contents, string(contents))
+	}
+	fi, err := driver.Stat("Copy/Folder1/Folder3")
+	require.NoError(t, err)
+	require.True(t, fi.IsDir())
+
+	t.Run("existing", func(t *testing.T) {
+		result, err := driver.RecursiveCopy("Folder1", "Copy/Folder1", CopyOptions{})
+		require.NoError(t, err)
+		require.Len(t, result.Failed(), 2)
+		require.IsType(t, FileExistError{}, result.Failed()[0].Err)
+
+		result, err = driver.RecursiveCopy("Folder1", "Copy/Folder1", CopyOptions{OverwriteExisting: true})
+		require.NoError(t, err)
+		require.Empty(t, result.Failed())
+	})
+}
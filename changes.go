@@ -0,0 +1,66 @@
+package gdriver
+
+import (
+	"fmt"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// GetChangesStartToken returns the token that can be used with GetChangedFilesSinceToken to get all changes that happen from now on
+func (d *GDriver) GetChangesStartToken() (string, error) {
+	token, err := d.srv.Changes.GetStartPageToken().Do()
+	if err != nil {
+		return "", err
+	}
+	return token.StartPageToken, nil
+}
+
+// GetChangedFilesSinceToken calls fn for every file that changed since startToken was issued (see GetChangesStartToken)
+// and returns the token for the next call.
+// removed is true if the file was deleted or trashed, in that case only the id of the FileInfo is known (see DriveFile()).
+// Files that are not in the root directory are skipped.
+// If fn returns SkipAll the token of the current page will be returned, so the next call will report the changes of this page again.
+func (d *GDriver) GetChangedFilesSinceToken(startToken string, fn func(info *FileInfo, removed bool) error) (string, error) {
+	fields := googleapi.Field(fmt.Sprintf("changes(fileId,removed,file(%s,md5Checksum,parents,trashed))", googleapi.CombineFields(fileInfoFields)))
+	pageToken := startToken
+	for {
+		changes, err := d.srv.Changes.List(pageToken).Fields(fields, "nextPageToken", "newStartPageToken").Do()
+		if err != nil {
+			return "", err
+		}
+
+		for _, change := range changes.Changes {
+			if change.Removed || change.File == nil || change.File.Trashed {
+				if err = fn(&FileInfo{item: &drive.File{Id: change.FileId}, pathEscaping: d.pathEscaping}, true); err != nil {
+					return callbackToken(pageToken, err)
+				}
+				continue
+			}
+
+			inRoot, parentPath, err := d.isInRoot(d.rootNode.item.Id, change.File, "")
+			if err != nil {
+				return "", err
+			}
+			if !inRoot {
+				continue
+			}
+			if err = fn(&FileInfo{item: change.File, parentPath: parentPath, pathEscaping: d.pathEscaping}, false); err != nil {
+				return callbackToken(pageToken, err)
+			}
+		}
+
+		if changes.NewStartPageToken != "" {
+			return changes.NewStartPageToken, nil
+		}
+		pageToken = changes.NextPageToken
+	}
+}
+
+// callbackToken returns the token and error for an error returned by the callback of GetChangedFilesSinceToken
+func callbackToken(pageToken string, err error) (string, error) {
+	if err = callbackError(err); err != nil {
+		return "", err
+	}
+	return pageToken, nil
+}
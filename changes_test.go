@@ -0,0 +1,40 @@
+package gdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChangedFilesSinceToken(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	token, err := driver.GetChangesStartToken()
+	require.NoError(t, err)
+
+	newFile(t, driver, "File1", "Hello World")
+
+	var changed []*FileInfo
+	nextToken, err := driver.GetChangedFilesSinceToken(token, func(f *FileInfo, removed bool) error {
+		if !removed && f.Path() == "File1" {
+			changed = append(changed, f)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, nextToken)
+	require.Len(t, changed, 1)
+
+	require.NoError(t, driver.Delete("File1"))
+
+	var removed int
+	_, err = driver.GetChangedFilesSinceToken(nextToken, func(f *FileInfo, isRemoved bool) error {
+		if isRemoved && f.DriveFile().Id == changed[0].DriveFile().Id {
+			removed++
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+}
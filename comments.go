@@ -0,0 +1,126 @@
+package gdriver
+
+import (
+	"fmt"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// CommentInfo represents a comment on a file
+type CommentInfo struct {
+	item *drive.Comment
+}
+
+// ID returns the id of the comment
+func (c *CommentInfo) ID() string {
+	return c.item.Id
+}
+
+// AuthorName returns the display name of the author
+func (c *CommentInfo) AuthorName() string {
+	if c.item.Author == nil {
+		return ""
+	}
+	return c.item.Author.DisplayName
+}
+
+// AuthorEmail returns the email address of the author, it might be empty if drive does not expose it
+func (c *CommentInfo) AuthorEmail() string {
+	if c.item.Author == nil {
+		return ""
+	}
+	return c.item.Author.EmailAddress
+}
+
+// Content returns the plain text content of the comment
+func (c *CommentInfo) Content() string {
+	return c.item.Content
+}
+
+// CreationTime returns the time when this comment was created
+func (c *CommentInfo) CreationTime() time.Time {
+	t, err := time.Parse(time.RFC3339, c.item.CreatedTime)
+	if err != nil {
+		panic(fmt.Errorf("unable to parse CreatedTime (`%s'): %v", c.item.CreatedTime, err))
+	}
+	return t
+}
+
+// IsResolved returns true if the comment has been resolved
+func (c *CommentInfo) IsResolved() bool {
+	return c.item.Resolved
+}
+
+// IsDeleted returns true if the comment has been deleted (see CommentsIncludeDeleted)
+func (c *CommentInfo) IsDeleted() bool {
+	return c.item.Deleted
+}
+
+// ReplyCount returns the amount of replies to the comment
+func (c *CommentInfo) ReplyCount() int {
+	return len(c.item.Replies)
+}
+
+// DriveComment returns the underlying *drive.Comment
+func (c *CommentInfo) DriveComment() *drive.Comment {
+	return c.item
+}
+
+type commentOptions struct {
+	includeDeleted bool
+}
+
+// CommentOption can be used to pass optional options to ListComments
+type CommentOption func(options *commentOptions)
+
+// CommentsIncludeDeleted also lists deleted comments
+func CommentsIncludeDeleted() CommentOption {
+	return func(options *commentOptions) {
+		options.includeDeleted = true
+	}
+}
+
+// ListComments calls fn for every comment on the file path, return SkipAll in fn to stop the listing
+func (d *GDriver) ListComments(path string, fn func(*CommentInfo) error, opts ...CommentOption) error {
+	var options commentOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return err
+	}
+	if file.IsDir() {
+		return FileIsDirectoryError{Path: path}
+	}
+
+	var pageToken string
+	for {
+		call := d.srv.Comments.List(file.item.Id).
+			IncludeDeleted(options.includeDeleted).
+			Fields("nextPageToken", "comments(id,author(displayName,emailAddress),content,createdTime,deleted,resolved,replies(id))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		comments, err := call.Do()
+		if err != nil {
+			return err
+		}
+		if comments == nil {
+			return fmt.Errorf("no comment information present (in `%s')", path)
+		}
+
+		for _, comment := range comments.Comments {
+			if err = fn(&CommentInfo{item: comment}); err != nil {
+				return callbackError(err)
+			}
+		}
+
+		if pageToken = comments.NextPageToken; pageToken == "" {
+			return nil
+		}
+	}
+}
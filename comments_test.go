@@ -0,0 +1,50 @@
+package gdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestListComments(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+
+	comment1, err := driver.srv.Comments.Create(fi.item.Id, &drive.Comment{Content: "Comment 1"}).Fields("id").Do()
+	require.NoError(t, err)
+	_, err = driver.srv.Replies.Create(fi.item.Id, comment1.Id, &drive.Reply{Content: "Reply 1"}).Fields("id").Do()
+	require.NoError(t, err)
+
+	comment2, err := driver.srv.Comments.Create(fi.item.Id, &drive.Comment{Content: "Comment 2"}).Fields("id").Do()
+	require.NoError(t, err)
+	require.NoError(t, driver.srv.Comments.Delete(fi.item.Id, comment2.Id).Do())
+
+	var comments []*CommentInfo
+	require.NoError(t, driver.ListComments("Folder1/File1", func(c *CommentInfo) error {
+		comments = append(comments, c)
+		return nil
+	}))
+	require.Len(t, comments, 1)
+	require.Equal(t, comment1.Id, comments[0].ID())
+	require.Equal(t, "Comment 1", comments[0].Content())
+	require.Equal(t, 1, comments[0].ReplyCount())
+	require.False(t, comments[0].IsResolved())
+	require.NotEmpty(t, comments[0].AuthorName())
+	require.False(t, comments[0].CreationTime().IsZero())
+
+	comments = nil
+	require.NoError(t, driver.ListComments("Folder1/File1", func(c *CommentInfo) error {
+		comments = append(comments, c)
+		return nil
+	}, CommentsIncludeDeleted()))
+	require.Len(t, comments, 2)
+
+	require.Equal(t, FileIsDirectoryError{Path: "Folder1"}, driver.ListComments("Folder1", func(c *CommentInfo) error {
+		return nil
+	}))
+}
@@ -0,0 +1,148 @@
+package gdriver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+)
+
+const (
+	encodingGzip = "gzip"
+
+	appPropertyEncoding     = "gdriver-encoding"
+	appPropertyOriginalSize = "gdriver-original-size"
+)
+
+type compressionOptions struct {
+	minSize   int64
+	mimeTypes map[string]struct{}
+}
+
+// WithCompression enables transparent gzip compression for uploads.
+// Files that are at least minSize bytes big and have a MIME type (determinated by their extension) that is in mimeAllowlist
+// will be compressed before uploading, if mimeAllowlist is empty all files will be compressed.
+// Compressed files will be decompressed when reading them, files that were uploaded without compression are read unchanged.
+// Note that up to minSize bytes of a file will be held in memory to decide if the file should be compressed.
+//
+// FileInfo.Size() and GetFileHash refer to the compressed contents, use FileInfo.OriginalSize() to get the uncompressed size.
+func WithCompression(minSize int64, mimeAllowlist []string) Option {
+	return func(driver *GDriver) error {
+		options := &compressionOptions{
+			minSize: minSize,
+		}
+		if len(mimeAllowlist) > 0 {
+			options.mimeTypes = make(map[string]struct{}, len(mimeAllowlist))
+			for _, mimeType := range mimeAllowlist {
+				options.mimeTypes[mimeType] = struct{}{}
+			}
+		}
+		driver.compression = options
+		return nil
+	}
+}
+
+// IsCompressed returns true if the contents of this file are compressed by WithCompression
+func (i *FileInfo) IsCompressed() bool {
+	return i.item.AppProperties[appPropertyEncoding] == encodingGzip
+}
+
+// OriginalSize returns the size of the contents before they were compressed,
+// for files that are not compressed this is the same as Size()
+func (i *FileInfo) OriginalSize() int64 {
+	if !i.IsCompressed() {
+		return i.Size()
+	}
+	size, err := strconv.ParseInt(i.item.AppProperties[appPropertyOriginalSize], 10, 64)
+	if err != nil {
+		return i.Size()
+	}
+	return size
+}
+
+// allowsMimeType returns true if files with the MIME type should be compressed
+func (o *compressionOptions) allowsMimeType(mimeType string) bool {
+	if o.mimeTypes == nil {
+		return true
+	}
+	_, ok := o.mimeTypes[mimeType]
+	return ok
+}
+
+// compress returns the reader that should be uploaded and true if the contents will be compressed
+func (o *compressionOptions) compress(r io.Reader) (io.Reader, bool, error) {
+	if o.minSize <= 0 {
+		return newCompressReader(r), true, nil
+	}
+	head := make([]byte, o.minSize)
+	n, err := io.ReadFull(r, head)
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		// too small for compression
+		return bytes.NewReader(head[:n]), false, nil
+	default:
+		return nil, false, err
+	}
+	return newCompressReader(io.MultiReader(bytes.NewReader(head), r)), true, nil
+}
+
+// compressReader compresses the contents of src while it is being read
+type compressReader struct {
+	src    io.Reader
+	buf    bytes.Buffer
+	writer *gzip.Writer
+	chunk  []byte
+	done   bool
+}
+
+func newCompressReader(src io.Reader) *compressReader {
+	r := &compressReader{
+		src:   src,
+		chunk: make([]byte, 32*1024),
+	}
+	r.writer = gzip.NewWriter(&r.buf)
+	return r
+}
+
+func (r *compressReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := r.src.Read(r.chunk)
+		if n > 0 {
+			// writing into a bytes.Buffer never fails
+			r.writer.Write(r.chunk[:n]) // nolint: errcheck
+		}
+		if err == io.EOF {
+			r.writer.Close() // nolint: errcheck
+			r.done = true
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// decompressReadCloser decompresses the contents of body while it is being read
+type decompressReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func newDecompressReadCloser(body io.ReadCloser) (*decompressReadCloser, error) {
+	reader, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressReadCloser{
+		Reader: reader,
+		body:   body,
+	}, nil
+}
+
+func (r *decompressReadCloser) Close() error {
+	r.Reader.Close() // nolint: errcheck
+	return r.body.Close()
+}
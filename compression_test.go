@@ -0,0 +1,110 @@
+package gdriver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestCompressReader(t *testing.T) {
+	for _, size := range []int{0, 1, 32 * 1024, 100*1024 + 7} {
+		plain := make([]byte, size)
+		_, err := rand.Read(plain)
+		require.NoError(t, err)
+
+		compressed, err := ioutil.ReadAll(newCompressReader(bytes.NewReader(plain)))
+		require.NoError(t, err)
+
+		r, err := newDecompressReadCloser(ioutil.NopCloser(bytes.NewReader(compressed)))
+		require.NoError(t, err)
+		decompressed, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, len(plain), len(decompressed))
+		require.EqualValues(t, plain, decompressed)
+	}
+}
+
+func TestEncodeContentsCompression(t *testing.T) {
+	driver := &GDriver{}
+	require.NoError(t, WithCompression(10, []string{"text/csv"})(driver))
+
+	t.Run("compressed", func(t *testing.T) {
+		plain := strings.Repeat("a,b,c\n", 100)
+		contents, err := driver.encodeContents("Table.csv", strings.NewReader(plain), false)
+		require.NoError(t, err)
+		require.Equal(t, "text/csv", contents.contentType)
+		require.Equal(t, encodingGzip, contents.appProperties[appPropertyEncoding])
+
+		compressed, err := ioutil.ReadAll(contents.reader)
+		require.NoError(t, err)
+		require.True(t, len(compressed) < len(plain))
+		require.EqualValues(t, len(plain), contents.original.n)
+
+		fi := &FileInfo{item: &drive.File{Name: "Table.csv", AppProperties: contents.appProperties}}
+		r, err := driver.decodeContents(fi, ioutil.NopCloser(bytes.NewReader(compressed)))
+		require.NoError(t, err)
+		decoded, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, plain, string(decoded))
+	})
+
+	t.Run("too small", func(t *testing.T) {
+		contents, err := driver.encodeContents("Table.csv", strings.NewReader("a,b,c"), false)
+		require.NoError(t, err)
+		require.Nil(t, contents.appProperties)
+		data, err := ioutil.ReadAll(contents.reader)
+		require.NoError(t, err)
+		require.Equal(t, "a,b,c", string(data))
+	})
+
+	t.Run("mime type not allowed", func(t *testing.T) {
+		plain := strings.Repeat("Hello World", 100)
+		contents, err := driver.encodeContents("File1", strings.NewReader(plain), false)
+		require.NoError(t, err)
+		require.Nil(t, contents.appProperties)
+		data, err := ioutil.ReadAll(contents.reader)
+		require.NoError(t, err)
+		require.Equal(t, plain, string(data))
+	})
+
+	t.Run("uncompressed file", func(t *testing.T) {
+		fi := &FileInfo{item: &drive.File{Name: "File1", Size: 11}}
+		r, err := driver.decodeContents(fi, ioutil.NopCloser(strings.NewReader("Hello World")))
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello World", string(data))
+		require.EqualValues(t, 11, fi.OriginalSize())
+	})
+}
+
+func TestWithCompression(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	// uploaded without compression
+	newFile(t, driver, "Folder1/Plain.csv", strings.Repeat("a,b,c\n", 100))
+
+	require.NoError(t, WithCompression(0, []string{"text/csv"})(driver))
+
+	plain := strings.Repeat("a,b,c\n", 100)
+	fi, err := driver.PutFile("Folder1/Compressed.csv", strings.NewReader(plain))
+	require.NoError(t, err)
+	require.True(t, fi.IsCompressed())
+	require.True(t, fi.Size() < int64(len(plain)))
+	require.EqualValues(t, len(plain), fi.OriginalSize())
+
+	for _, name := range []string{"Folder1/Plain.csv", "Folder1/Compressed.csv"} {
+		_, r, err := driver.GetFile(name)
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, plain, string(received))
+	}
+}
@@ -0,0 +1,185 @@
+package gdriver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// contentAppProperties are the appProperties that describe the encoding of the contents
+var contentAppProperties = []string{
+	appPropertyEncryption,
+	appPropertyEncryptionVersion,
+	appPropertyEncryptionChunkSize,
+	appPropertyEncoding,
+	appPropertyOriginalSize,
+}
+
+func isContentAppProperty(key string) bool {
+	for _, contentKey := range contentAppProperties {
+		if key == contentKey {
+			return true
+		}
+	}
+	return false
+}
+
+// encodedContents holds the contents that should be uploaded
+type encodedContents struct {
+	reader        io.Reader
+	contentType   string
+	appProperties map[string]string
+	// original counts the bytes of the unencoded contents, it is only set if the original size must be stored
+	original *countingReader
+	// size is the amount of bytes of reader, or -1 if it is unknown
+	size int64
+}
+
+// SizedReader can be implemented by readers passed to PutFile that know the amount of bytes they will provide,
+// Size must return the amount of bytes that are left to read
+type SizedReader interface {
+	Size() int64
+}
+
+// readerSize returns the amount of bytes that are left to read in r, or -1 if it is unknown
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }: // bytes.Buffer, bytes.Reader, strings.Reader
+		return int64(v.Len())
+	case SizedReader:
+		return v.Size()
+	case *os.File:
+		stat, err := v.Stat()
+		if err != nil || !stat.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return stat.Size() - offset
+	}
+	return -1
+}
+
+// needsSession returns true if the contents should be uploaded with a resumable session that announces their size,
+// contents with an unknown size are uploaded by the drive library
+func (c *encodedContents) needsSession() bool {
+	return c.size >= 0
+}
+
+func (c *encodedContents) mediaOptions() []googleapi.MediaOption {
+	if c.contentType == "" {
+		return nil
+	}
+	return []googleapi.MediaOption{googleapi.ContentType(c.contentType)}
+}
+
+func (c *encodedContents) setAppProperties(appProperties map[string]string) {
+	if c.appProperties == nil {
+		c.appProperties = make(map[string]string)
+	}
+	for key, value := range appProperties {
+		c.appProperties[key] = value
+	}
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// encodeContents prepares the contents of the file name for uploading,
+// converting must be true if the contents will be converted to a Google format
+func (d *GDriver) encodeContents(name string, r io.Reader, converting bool) (*encodedContents, error) {
+	contents := &encodedContents{
+		reader: r,
+		size:   readerSize(r),
+	}
+
+	if d.compression != nil && !converting {
+		mimeType := mimeTypeByExtension(name)
+		if d.compression.allowsMimeType(mimeType) {
+			original := &countingReader{Reader: r}
+			compressed, ok, err := d.compression.compress(original)
+			if err != nil {
+				return nil, err
+			}
+			contents.reader = compressed
+			if ok {
+				contents.size = -1
+				if mimeType == "" {
+					mimeType = mimeTypeFile
+				}
+				contents.contentType = mimeType
+				contents.original = original
+				contents.setAppProperties(map[string]string{
+					appPropertyEncoding: encodingGzip,
+				})
+			}
+		}
+	}
+
+	if d.encryptionKey != nil {
+		encrypted, err := newEncryptReader(contents.reader, d.encryptionKey, encryptionDefaultChunk)
+		if err != nil {
+			return nil, err
+		}
+		contents.reader = encrypted
+		contents.size = -1
+		contents.contentType = mimeTypeFile
+		contents.setAppProperties(encryptionAppProperties(encryptionDefaultChunk))
+	}
+
+	return contents, nil
+}
+
+// finishContents stores the information about the contents that is only known after the upload
+func (d *GDriver) finishContents(file *drive.File, contents *encodedContents) (*drive.File, error) {
+	if contents.original == nil {
+		return file, nil
+	}
+	return d.srv.Files.Update(file.Id, &drive.File{
+		AppProperties: map[string]string{
+			appPropertyOriginalSize: strconv.FormatInt(contents.original.n, 10),
+		},
+	}).Fields(fileInfoFields...).Do()
+}
+
+// downloadDecoded downloads the contents of the file and reverts the encoding that was done by encodeContents
+func (d *GDriver) downloadDecoded(file *FileInfo) (io.ReadCloser, error) {
+	response, err := d.srv.Files.Get(file.item.Id).Download()
+	if err != nil {
+		return nil, err
+	}
+	body, err := d.decodeContents(file, response.Body)
+	if err != nil {
+		response.Body.Close() // nolint: errcheck
+		return nil, err
+	}
+	return body, nil
+}
+
+// decodeContents reverts the encoding that was done by encodeContents
+func (d *GDriver) decodeContents(file *FileInfo, body io.ReadCloser) (io.ReadCloser, error) {
+	if file.IsEncrypted() {
+		if d.encryptionKey == nil {
+			return nil, fmt.Errorf("`%s' is encrypted, use WithEncryption to read it", file.Path())
+		}
+		body = newDecryptReader(body, d.encryptionKey, file.Path())
+	}
+	if file.IsCompressed() {
+		return newDecompressReadCloser(body)
+	}
+	return body, nil
+}
@@ -0,0 +1,213 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultMimeTypeMapping holds the default mapping of MIME types to their Google equivalent
+// that will be used by WithConvertToGoogleFormat
+var DefaultMimeTypeMapping = map[string]string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   mimeTypeGoogleDocument,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.template":   mimeTypeGoogleDocument,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         mimeTypeGoogleSpreadsheet,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.template":      mimeTypeGoogleSpreadsheet,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": mimeTypeGooglePresentation,
+	"application/vnd.openxmlformats-officedocument.presentationml.template":     mimeTypeGooglePresentation,
+	"application/msword":            mimeTypeGoogleDocument,
+	"application/vnd.ms-excel":      mimeTypeGoogleSpreadsheet,
+	"application/vnd.ms-powerpoint": mimeTypeGooglePresentation,
+}
+
+// extensionMimeTypes is used to determinate the MIME type of a file by its extension,
+// it is consulted before the systems MIME table because office types are often missing there
+var extensionMimeTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".dotx": "application/vnd.openxmlformats-officedocument.wordprocessingml.template",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xltx": "application/vnd.openxmlformats-officedocument.spreadsheetml.template",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".potx": "application/vnd.openxmlformats-officedocument.presentationml.template",
+	".doc":  "application/msword",
+	".xls":  "application/vnd.ms-excel",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".csv":  "text/csv",
+}
+
+// WithConvertToGoogleFormat enables the automatic conversion of uploaded files to their Google equivalent,
+// e.g. a .docx file will be converted to a Google Docs document.
+// The MIME type of a file is determinated by its extension, use WithMimeTypeMapping to change the conversions.
+// Conversion only happens when a file is created, updates of existing files are never converted.
+func WithConvertToGoogleFormat(enabled bool) Option {
+	return func(driver *GDriver) error {
+		driver.convertToGoogleFormat = enabled
+		return nil
+	}
+}
+
+// WithMimeTypeMapping overrides entries of DefaultMimeTypeMapping that will be used by WithConvertToGoogleFormat,
+// map a MIME type to an empty string to disable the conversion for it
+func WithMimeTypeMapping(mapping map[string]string) Option {
+	return func(driver *GDriver) error {
+		driver.mimeTypeMapping = make(map[string]string, len(DefaultMimeTypeMapping)+len(mapping))
+		for from, to := range DefaultMimeTypeMapping {
+			driver.mimeTypeMapping[from] = to
+		}
+		for from, to := range mapping {
+			if to == "" {
+				delete(driver.mimeTypeMapping, from)
+				continue
+			}
+			driver.mimeTypeMapping[from] = to
+		}
+		return nil
+	}
+}
+
+// mimeTypeByExtension returns the MIME type for the file name, or an empty string if unknown
+func mimeTypeByExtension(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+	if mimeType, ok := extensionMimeTypes[ext]; ok {
+		return mimeType
+	}
+	mimeType := mime.TypeByExtension(ext)
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return mimeType
+}
+
+// uploadMimeTypes returns the MIME type that should be used for a new file with the name
+// and the content type of the uploaded contents
+func (d *GDriver) uploadMimeTypes(name string) (mimeType string, contentType string) {
+	if !d.convertToGoogleFormat || d.encryptionKey != nil {
+		return mimeTypeFile, ""
+	}
+	contentType = mimeTypeByExtension(name)
+	if contentType == "" {
+		return mimeTypeFile, ""
+	}
+	mapping := d.mimeTypeMapping
+	if mapping == nil {
+		mapping = DefaultMimeTypeMapping
+	}
+	if googleType, ok := mapping[contentType]; ok {
+		return googleType, contentType
+	}
+	return mimeTypeFile, ""
+}
+
+// ImportFormat describes how Import converts the uploaded contents to a google native file
+type ImportFormat int
+
+const (
+	// ImportCSVToSheet converts a CSV file to a google spreadsheet
+	ImportCSVToSheet ImportFormat = iota
+	// ImportXLSXToSheet converts an excel workbook to a google spreadsheet
+	ImportXLSXToSheet
+	// ImportDOCXToDoc converts a word document to a google document
+	ImportDOCXToDoc
+	// ImportPDFToDoc converts a PDF file to a google document
+	ImportPDFToDoc
+	// ImportHTMLToDoc converts a HTML file to a google document
+	ImportHTMLToDoc
+	// ImportPPTXToSlide converts a powerpoint presentation to google slides
+	ImportPPTXToSlide
+)
+
+// importFormat holds the MIME type of the uploaded contents and the MIME type of the google native file
+type importFormat struct {
+	contentType string
+	mimeType    string
+}
+
+var importFormatTypes = map[ImportFormat]importFormat{
+	ImportCSVToSheet:  {"text/csv", mimeTypeGoogleSpreadsheet},
+	ImportXLSXToSheet: {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", mimeTypeGoogleSpreadsheet},
+	ImportDOCXToDoc:   {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", mimeTypeGoogleDocument},
+	ImportPDFToDoc:    {"application/pdf", mimeTypeGoogleDocument},
+	ImportHTMLToDoc:   {"text/html", mimeTypeGoogleDocument},
+	ImportPPTXToSlide: {"application/vnd.openxmlformats-officedocument.presentationml.presentation", mimeTypeGooglePresentation},
+}
+
+func (f ImportFormat) String() string {
+	switch f {
+	case ImportCSVToSheet:
+		return "ImportCSVToSheet"
+	case ImportXLSXToSheet:
+		return "ImportXLSXToSheet"
+	case ImportDOCXToDoc:
+		return "ImportDOCXToDoc"
+	case ImportPDFToDoc:
+		return "ImportPDFToDoc"
+	case ImportHTMLToDoc:
+		return "ImportHTMLToDoc"
+	case ImportPPTXToSlide:
+		return "ImportPPTXToSlide"
+	default:
+		return fmt.Sprintf("ImportFormat(%d)", int(f))
+	}
+}
+
+// Import uploads the contents of r to the specified path and converts them to a google native file using format,
+// it creates non existing directories.
+// The contents are never encrypted or compressed, because drive could not convert them anymore.
+//
+// Examples:
+//     Import(ImportCSVToSheet, "Reports/Sales", csvReader)
+func (d *GDriver) Import(format ImportFormat, filePath string, r io.Reader) (_ *FileInfo, err error) {
+	defer d.audit("Import", filePath, "")(&err)
+	types, ok := importFormatTypes[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %s", format)
+	}
+
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("path cannot be empty")
+	}
+
+	// the file must not exist
+	_, err = d.getFileByParts(d.rootNode, pathParts)
+	if err == nil {
+		return nil, FileExistError{Path: filePath}
+	}
+	if !IsNotExist(err) {
+		return nil, err
+	}
+
+	parentNode := d.rootNode
+	if amountOfParts > 1 {
+		parentNode, err = d.makeDirectoryByParts(pathParts[:amountOfParts-1])
+		if err != nil {
+			return nil, err
+		}
+		if !parentNode.IsDir() {
+			return nil, fmt.Errorf("unable to create file in `%s': `%s' is not a directory", path.Join(pathParts[:amountOfParts-1]...), parentNode.Name())
+		}
+	}
+
+	file, err := d.srv.Files.Create(&drive.File{
+		Name:     d.pathEscaping.unescape(pathParts[amountOfParts-1]),
+		MimeType: types.mimeType,
+		Parents: []string{
+			parentNode.item.Id,
+		},
+	}).Fields(fileInfoFields...).Media(r, googleapi.ContentType(types.contentType)).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         file,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
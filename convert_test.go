@@ -0,0 +1,86 @@
+package gdriver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadMimeTypes(t *testing.T) {
+	const docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	t.Run("disabled", func(t *testing.T) {
+		driver := &GDriver{}
+		mimeType, contentType := driver.uploadMimeTypes("Document.docx")
+		require.Equal(t, mimeTypeFile, mimeType)
+		require.Empty(t, contentType)
+	})
+
+	t.Run("docx", func(t *testing.T) {
+		driver := &GDriver{}
+		require.NoError(t, WithConvertToGoogleFormat(true)(driver))
+		mimeType, contentType := driver.uploadMimeTypes("Document.DOCX")
+		require.Equal(t, "application/vnd.google-apps.document", mimeType)
+		require.Equal(t, docx, contentType)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		driver := &GDriver{}
+		require.NoError(t, WithConvertToGoogleFormat(true)(driver))
+		mimeType, contentType := driver.uploadMimeTypes("File1")
+		require.Equal(t, mimeTypeFile, mimeType)
+		require.Empty(t, contentType)
+	})
+
+	t.Run("custom mapping", func(t *testing.T) {
+		driver := &GDriver{}
+		require.NoError(t, WithConvertToGoogleFormat(true)(driver))
+		require.NoError(t, WithMimeTypeMapping(map[string]string{
+			docx:       "",
+			"text/csv": "application/vnd.google-apps.spreadsheet",
+		})(driver))
+
+		mimeType, _ := driver.uploadMimeTypes("Document.docx")
+		require.Equal(t, mimeTypeFile, mimeType)
+
+		mimeType, contentType := driver.uploadMimeTypes("Table.csv")
+		require.Equal(t, "application/vnd.google-apps.spreadsheet", mimeType)
+		require.Equal(t, "text/csv", contentType)
+
+		mimeType, _ = driver.uploadMimeTypes("Table.xlsx")
+		require.Equal(t, "application/vnd.google-apps.spreadsheet", mimeType)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		driver := &GDriver{}
+		require.NoError(t, WithConvertToGoogleFormat(true)(driver))
+		require.NoError(t, WithEncryption(make([]byte, 16))(driver))
+		mimeType, _ := driver.uploadMimeTypes("Document.docx")
+		require.Equal(t, mimeTypeFile, mimeType)
+	})
+}
+
+func TestImport(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	fi, err := driver.Import(ImportCSVToSheet, "Folder1/Table1", bytes.NewBufferString("a,b\n1,2\n"))
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/Table1", fi.Path())
+	require.True(t, fi.IsGoogleSheet())
+
+	_, r, err := driver.ExportFile("Folder1/Table1", "text/csv")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "a,b\n1,2", strings.TrimSpace(strings.Replace(string(data), "\r\n", "\n", -1)))
+
+	_, err = driver.Import(ImportCSVToSheet, "Folder1/Table1", bytes.NewBufferString("a,b\n1,2\n"))
+	require.Equal(t, FileExistError{Path: "Folder1/Table1"}, err)
+
+	_, err = driver.Import(ImportFormat(100), "Folder1/Table2", bytes.NewBufferString(""))
+	require.EqualError(t, err, "unknown import format ImportFormat(100)")
+}
@@ -0,0 +1,263 @@
+// Package drivetest provides an in-memory fake of the google drive v3 api that can be used for hermetic tests.
+//
+// The fake implements the parts of the api gdriver uses: files (list with the query shapes gdriver sends, get, download,
+// export, create, update, copy, delete, multipart and resumable uploads), permissions, revisions, comments, changes, about
+// and push notification channels (notifications are not delivered).
+// Responses honor the fields parameter. Requests to other resources (e.g. shared drives) fail with a 501 googleapi error.
+// There is only one user (OwnerEmail) and google native files are not converted.
+//
+// Examples:
+//     srv := drivetest.NewServer()
+//     defer srv.Close()
+//     driver, err := gdriver.NewWithService(context.Background(), srv.ClientOptions())
+package drivetest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+const mimeTypeFolder = "application/vnd.google-apps.folder"
+
+// RootID is the drive id of the root directory (My Drive), it can also be addressed by the alias "root"
+const RootID = "0AFakeRootDirectory"
+
+// OwnerEmail is the email address of the user that owns all files of the fake
+const OwnerEmail = "owner@example.com"
+
+// file is a stored file with its contents
+type file struct {
+	meta        drive.File
+	content     []byte
+	permissions []*drive.Permission
+	revisions   []*revision
+	comments    []*drive.Comment
+}
+
+// revision is a stored version of the contents of a file
+type revision struct {
+	meta    drive.Revision
+	content []byte
+}
+
+// change is an entry of the changes log
+type change struct {
+	fileID  string
+	removed bool
+	time    string
+}
+
+// uploadSession is a started resumable upload
+type uploadSession struct {
+	fileID   string
+	meta     *metadata
+	mimeType string
+	content  []byte
+}
+
+// Server is a fake drive api server, use ClientOptions to connect a client to it
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	files    map[string]*file
+	sessions map[string]*uploadSession
+	channels map[string]*drive.Channel
+	changes  []change
+	lastID   int
+	now      func() time.Time
+}
+
+// NewServer starts a fake drive api server with an empty root directory
+func NewServer() *Server {
+	s := &Server{
+		files:    make(map[string]*file),
+		sessions: make(map[string]*uploadSession),
+		channels: make(map[string]*drive.Channel),
+		now:      time.Now,
+	}
+	now := s.timestamp()
+	s.files[RootID] = &file{meta: drive.File{
+		Kind:         "drive#file",
+		Id:           RootID,
+		Name:         "My Drive",
+		MimeType:     mimeTypeFolder,
+		CreatedTime:  now,
+		ModifiedTime: now,
+		Owners:       []*drive.User{owner()},
+	}, permissions: []*drive.Permission{ownerPermission()}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// ClientOptions returns the options a drive service needs to use the fake
+func (s *Server) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint(s.URL + "/drive/v3/"),
+		option.WithHTTPClient(s.Client()),
+	}
+}
+
+// Files returns a copy of the metadata of all stored files (including the root directory) sorted by their id
+func (s *Server) Files() []*drive.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make([]*drive.File, 0, len(s.files))
+	for _, f := range s.files {
+		files = append(files, copyFile(&f.meta))
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Id < files[j].Id
+	})
+	return files
+}
+
+// Content returns the contents of the file with the drive id id
+func (s *Server) Content(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.lookup(id)
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), f.content...), true
+}
+
+// lookup returns the file with the id (or alias), s.mu must be held
+func (s *Server) lookup(id string) (*file, bool) {
+	f, ok := s.files[resolveID(id)]
+	return f, ok
+}
+
+// newID returns a new unique drive id, s.mu must be held
+func (s *Server) newID() string {
+	s.lastID++
+	return fmt.Sprintf("1Fake%023d", s.lastID)
+}
+
+// timestamp returns the current time in the format drive uses
+func (s *Server) timestamp() string {
+	return s.now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// setContent replaces the contents of the file, updates the derived metadata and stores a new revision, s.mu must be held
+func (s *Server) setContent(f *file, content []byte) {
+	sum := md5.Sum(content)
+	f.content = content
+	f.meta.Size = int64(len(content))
+	f.meta.Md5Checksum = hex.EncodeToString(sum[:])
+	s.lastID++
+	f.meta.HeadRevisionId = fmt.Sprintf("0BFakeRevision%d", s.lastID)
+	f.revisions = append(f.revisions, &revision{
+		meta: drive.Revision{
+			Kind:              "drive#revision",
+			Id:                f.meta.HeadRevisionId,
+			MimeType:          f.meta.MimeType,
+			ModifiedTime:      s.timestamp(),
+			Size:              f.meta.Size,
+			Md5Checksum:       f.meta.Md5Checksum,
+			LastModifyingUser: owner(),
+		},
+		content: content,
+	})
+}
+
+// changed records a change of the file in the changes log, s.mu must be held
+func (s *Server) changed(id string, removed bool) {
+	s.changes = append(s.changes, change{fileID: id, removed: removed, time: s.timestamp()})
+}
+
+// children returns the files that have the directory id as a parent, s.mu must be held
+func (s *Server) children(id string) []*file {
+	var children []*file
+	for _, f := range s.files {
+		for _, parent := range f.meta.Parents {
+			if parent == id {
+				children = append(children, f)
+				break
+			}
+		}
+	}
+	return children
+}
+
+// remove deletes the file and all its descendants, s.mu must be held
+func (s *Server) remove(id string) {
+	for _, child := range s.children(id) {
+		s.remove(child.meta.Id)
+	}
+	delete(s.files, id)
+	s.changed(id, true)
+}
+
+// resolveID resolves the alias root
+func resolveID(id string) string {
+	if id == "root" {
+		return RootID
+	}
+	return id
+}
+
+func ownerPermission() *drive.Permission {
+	return &drive.Permission{
+		Kind:         "drive#permission",
+		Id:           "ownerPermission",
+		Type:         "user",
+		Role:         "owner",
+		EmailAddress: OwnerEmail,
+		DisplayName:  "Owner",
+	}
+}
+
+func owner() *drive.User {
+	return &drive.User{
+		Kind:         "drive#user",
+		DisplayName:  "Owner",
+		EmailAddress: OwnerEmail,
+		Me:           true,
+	}
+}
+
+// copyFile returns a deep enough copy of the metadata to be modified or encoded without holding s.mu
+func copyFile(f *drive.File) *drive.File {
+	c := *f
+	c.Parents = append([]string(nil), f.Parents...)
+	c.Properties = copyMap(f.Properties)
+	c.AppProperties = copyMap(f.AppProperties)
+	c.Owners = append([]*drive.User(nil), f.Owners...)
+	if f.Trashed {
+		c.ForceSendFields = []string{"Trashed"}
+	}
+	return &c
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func splitIDs(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, resolveID(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,154 @@
+package drivetest
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+func newService(t *testing.T) (*Server, *drive.Service) {
+	fake := NewServer()
+	srv, err := drive.NewService(context.Background(), fake.ClientOptions()...)
+	require.NoError(t, err)
+	return fake, srv
+}
+
+func TestParseQuery(t *testing.T) {
+	s := &Server{files: map[string]*file{
+		"folder": {meta: drive.File{Id: "folder", Name: "Folder", MimeType: mimeTypeFolder, Parents: []string{RootID}}},
+	}}
+	f := &file{meta: drive.File{
+		Id:            "file",
+		Name:          "Quarterly Report.txt",
+		MimeType:      "text/plain",
+		Parents:       []string{"folder"},
+		ModifiedTime:  "2019-03-01T10:00:00.000Z",
+		AppProperties: map[string]string{"origin": "it's me"},
+	}}
+
+	for query, expected := range map[string]bool{
+		"":                                                        true,
+		"'folder' in parents":                                     true,
+		"'root' in parents":                                       false,
+		"name = 'Quarterly Report.txt'":                           true,
+		"name='Quarterly Report.txt'":                             true,
+		"name != 'Quarterly Report.txt'":                          false,
+		"name contains 'quart'":                                   true,
+		"name contains 'report'":                                  true,
+		"name contains 'port'":                                    false,
+		"mimeType = 'text/plain' and trashed = false":             true,
+		"trashed = true":                                          false,
+		"not trashed = true":                                      true,
+		"(name = 'x' or name = 'y') and trashed = false":          false,
+		"name = 'x' or 'folder' in parents":                       true,
+		"modifiedTime > '2019-01-01T00:00:00'":                    true,
+		"modifiedTime < '2019-01-01T00:00:00Z'":                   false,
+		`appProperties has { key='origin' and value='it\'s me' }`: true,
+		"appProperties has { key='origin' and value='you' }":      false,
+	} {
+		match, err := parseQuery(query)
+		require.NoError(t, err, query)
+		require.Equal(t, expected, match(s, f), query)
+	}
+
+	for _, query := range []string{
+		"name = 'unterminated",
+		"name =",
+		"(name = 'x'",
+		"visibility = 'anyoneWithLink'",
+		"trashed = 'yes'",
+		"name = 'x' name = 'y'",
+	} {
+		_, err := parseQuery(query)
+		require.Error(t, err, query)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	list := &drive.FileList{
+		NextPageToken: "next",
+		Files: []*drive.File{{
+			Id:     "1",
+			Name:   "File1",
+			Size:   11,
+			Owners: []*drive.User{{EmailAddress: OwnerEmail, DisplayName: "Owner"}},
+		}},
+	}
+	v, err := selectFields(list, "nextPageToken, files(id,owners/emailAddress)")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"nextPageToken": "next",
+		"files": []interface{}{map[string]interface{}{
+			"id":     "1",
+			"owners": []interface{}{map[string]interface{}{"emailAddress": OwnerEmail}},
+		}},
+	}, v)
+
+	v, err = selectFields(list, "")
+	require.NoError(t, err)
+	require.Equal(t, list, v)
+
+	_, err = selectFields(list, "files(,id)")
+	require.Error(t, err)
+}
+
+func TestFiles(t *testing.T) {
+	fake, srv := newService(t)
+	defer fake.Close()
+
+	folder, err := srv.Files.Create(&drive.File{Name: "Folder1", MimeType: mimeTypeFolder}).Do()
+	require.NoError(t, err)
+	require.Equal(t, []string{RootID}, folder.Parents)
+
+	file, err := srv.Files.Create(&drive.File{Name: "File1", Parents: []string{folder.Id}}).
+		Media(strings.NewReader("Hello World"), googleapi.ContentType("text/plain")).Fields("id,size,md5Checksum,mimeType").Do()
+	require.NoError(t, err)
+	require.EqualValues(t, 11, file.Size)
+	require.Equal(t, "b10a8db164e0754105b7a99be72e3fe5", file.Md5Checksum)
+	require.Equal(t, "text/plain", file.MimeType)
+
+	// resumable uploads are used for media that is larger than the chunk size
+	large := bytes.Repeat([]byte("0123456789"), 100)
+	updated, err := srv.Files.Update(file.Id, &drive.File{Name: "File2"}).
+		Media(bytes.NewReader(large), googleapi.ChunkSize(256)).Fields("name,size").Do()
+	require.NoError(t, err)
+	require.Equal(t, "File2", updated.Name)
+	require.EqualValues(t, len(large), updated.Size)
+	content, ok := fake.Content(file.Id)
+	require.True(t, ok)
+	require.Equal(t, large, content)
+
+	response, err := srv.Files.Get(file.Id).Download()
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, large, data)
+
+	list, err := srv.Files.List().Q("'" + folder.Id + "' in parents and trashed = false").Fields("files(id,name)").Do()
+	require.NoError(t, err)
+	require.Len(t, list.Files, 1)
+	require.Equal(t, "File2", list.Files[0].Name)
+	require.Empty(t, list.Files[0].MimeType)
+
+	// deleting a folder deletes its descendants
+	require.NoError(t, srv.Files.Delete(folder.Id).Do())
+	_, err = srv.Files.Get(file.Id).Do()
+	require.Equal(t, 404, err.(*googleapi.Error).Code)
+	require.Len(t, fake.Files(), 1)
+}
+
+func TestNotImplemented(t *testing.T) {
+	fake, srv := newService(t)
+	defer fake.Close()
+
+	_, err := srv.Teamdrives.List().Do()
+	require.Error(t, err)
+	require.Equal(t, 501, err.(*googleapi.Error).Code)
+}
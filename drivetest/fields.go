@@ -0,0 +1,136 @@
+package drivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// fieldSelection is a parsed fields parameter, a nil selection selects everything
+type fieldSelection map[string]fieldSelection
+
+// parseFields parses a fields parameter like "nextPageToken,files(id,name,owners/emailAddress)"
+func parseFields(s string) (fieldSelection, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	selection := make(fieldSelection)
+	rest, err := selection.parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid field selection %s", s)
+	}
+	return selection, nil
+}
+
+// parse parses a comma separated list of fields into sel and returns the unparsed rest (starting with a closing parenthesis)
+func (sel fieldSelection) parse(s string) (string, error) {
+	for {
+		s = strings.TrimSpace(s)
+		i := strings.IndexAny(s, ",()")
+		if i < 0 {
+			i = len(s)
+		}
+		name := strings.TrimSpace(s[:i])
+		if name == "" {
+			return "", fmt.Errorf("invalid field selection")
+		}
+		// a/b/c selects the nested field c
+		target := sel
+		path := strings.Split(name, "/")
+		for _, part := range path[:len(path)-1] {
+			sub, ok := target[part]
+			if ok && sub == nil {
+				// the whole field is already selected
+				target = make(fieldSelection)
+				continue
+			}
+			if !ok {
+				sub = make(fieldSelection)
+				target[part] = sub
+			}
+			target = sub
+		}
+		last := path[len(path)-1]
+		s = s[i:]
+
+		if strings.HasPrefix(s, "(") {
+			sub, ok := target[last]
+			if !ok || sub == nil {
+				sub = make(fieldSelection)
+			}
+			rest, err := sub.parse(s[1:])
+			if err != nil {
+				return "", err
+			}
+			if !strings.HasPrefix(rest, ")") {
+				return "", fmt.Errorf("missing ) in field selection")
+			}
+			target[last] = sub
+			s = strings.TrimSpace(rest[1:])
+		} else {
+			// selecting the whole field overrides sub selections
+			target[last] = nil
+		}
+
+		switch {
+		case s == "" || strings.HasPrefix(s, ")"):
+			return s, nil
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		default:
+			return "", fmt.Errorf("invalid field selection")
+		}
+	}
+}
+
+// apply returns the selected fields of the decoded json value v
+func (sel fieldSelection) apply(v interface{}) interface{} {
+	if sel == nil {
+		return v
+	}
+	if _, all := sel["*"]; all {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for name, sub := range sel {
+			if value, ok := v[name]; ok {
+				out[name] = sub.apply(value)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = sel.apply(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// selectFields reduces the response v to the fields of the fields parameter
+func selectFields(v interface{}, fields string) (interface{}, error) {
+	sel, err := parseFields(fields)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalidParameter", "Invalid field selection %s", fields)
+	}
+	if sel == nil {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return sel.apply(decoded), nil
+}
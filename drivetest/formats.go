@@ -0,0 +1,85 @@
+package drivetest
+
+import (
+	"archive/zip"
+	"bytes"
+)
+
+const (
+	mimeTypeDocument     = "application/vnd.google-apps.document"
+	mimeTypeSpreadsheet  = "application/vnd.google-apps.spreadsheet"
+	mimeTypePresentation = "application/vnd.google-apps.presentation"
+	mimeTypeDrawing      = "application/vnd.google-apps.drawing"
+	mimeTypeScript       = "application/vnd.google-apps.script"
+
+	mimeTypeDocx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	mimeTypeXlsx = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimeTypePptx = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	mimeTypeOdt  = "application/vnd.oasis.opendocument.text"
+	mimeTypeOds  = "application/x-vnd.oasis.opendocument.spreadsheet"
+	mimeTypeOdp  = "application/vnd.oasis.opendocument.presentation"
+	mimeTypeEpub = "application/epub+zip"
+	mimeTypeZip  = "application/zip"
+	mimeTypePDF  = "application/pdf"
+)
+
+// exportFormats are the formats google native files can be exported to (a subset of what drive reports)
+var exportFormats = map[string][]string{
+	mimeTypeDocument:     {"text/plain", "text/html", "application/rtf", mimeTypePDF, mimeTypeDocx, mimeTypeOdt, mimeTypeEpub, mimeTypeZip},
+	mimeTypeSpreadsheet:  {"text/csv", "text/tab-separated-values", mimeTypePDF, mimeTypeXlsx, mimeTypeOds, mimeTypeZip},
+	mimeTypePresentation: {"text/plain", mimeTypePDF, mimeTypePptx, mimeTypeOdp},
+	mimeTypeDrawing:      {"image/png", "image/jpeg", "image/svg+xml", mimeTypePDF},
+	mimeTypeScript:       {"application/vnd.google-apps.script+json"},
+}
+
+// importFormats are the formats that can be converted to google native files (a subset of what drive reports)
+var importFormats = map[string][]string{
+	"text/plain":                {mimeTypeDocument},
+	"text/html":                 {mimeTypeDocument},
+	"application/rtf":           {mimeTypeDocument},
+	mimeTypeDocx:                {mimeTypeDocument},
+	mimeTypeOdt:                 {mimeTypeDocument},
+	"text/csv":                  {mimeTypeSpreadsheet},
+	"text/tab-separated-values": {mimeTypeSpreadsheet},
+	mimeTypeXlsx:                {mimeTypeSpreadsheet},
+	mimeTypeOds:                 {mimeTypeSpreadsheet},
+	mimeTypePptx:                {mimeTypePresentation},
+	mimeTypeOdp:                 {mimeTypePresentation},
+}
+
+// folderColorPalette are the colors folders can have
+var folderColorPalette = []string{
+	"#ac725e", "#d06b64", "#f83a22", "#fa573c", "#ff7537", "#ffad46", "#42d692", "#16a765",
+	"#7bd148", "#b3dc6c", "#fbe983", "#fad165", "#92e1c0", "#9fe1e7", "#9fc6e7", "#4986e7",
+	"#9a9cff", "#b99aff", "#c2c2c2", "#cabdbf", "#cca6ac", "#f691b2", "#cd74e6", "#a47ae2",
+}
+
+// isArchiveFormat returns whether the export format is a zip archive
+func isArchiveFormat(mimeType string) bool {
+	switch mimeType {
+	case mimeTypeDocx, mimeTypeXlsx, mimeTypePptx, mimeTypeOdt, mimeTypeOds, mimeTypeOdp, mimeTypeEpub, mimeTypeZip:
+		return true
+	}
+	return false
+}
+
+// exportContent returns the exported contents of a google native file,
+// the fake does not convert: the stored contents are exported as they are or wrapped in a zip archive for archive formats
+func exportContent(content []byte, mimeType string) ([]byte, error) {
+	if !isArchiveFormat(mimeType) {
+		return content, nil
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("content")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(content); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,778 @@
+package drivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// maxPageSize is the maximum amount of files files.list returns per page
+const maxPageSize = 1000
+
+// defaultPageSize is the amount of files files.list returns per page if no pageSize was requested
+const defaultPageSize = 100
+
+// apiError is an error that will be sent in the format of the drive api
+type apiError struct {
+	code    int
+	reason  string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(code int, reason, format string, a ...interface{}) *apiError {
+	return &apiError{code: code, reason: reason, message: fmt.Sprintf(format, a...)}
+}
+
+func notFound(id string) *apiError {
+	return errorf(http.StatusNotFound, "notFound", "File not found: %s.", id)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/upload")
+	if !strings.HasPrefix(p, "/drive/v3/") {
+		writeError(w, errorf(http.StatusNotFound, "notFound", "unknown path %s", r.URL.Path))
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(p, "/drive/v3/"), "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var v interface{}
+	var err error
+	switch {
+	case r.Method == http.MethodPut && r.URL.Query().Get("upload_id") != "":
+		s.uploadChunk(w, r)
+		return
+	case r.Method == http.MethodDelete && r.URL.Query().Get("upload_id") != "":
+		s.cancelSession(w, r)
+		return
+	case len(parts) == 1 && parts[0] == "about" && r.Method == http.MethodGet:
+		v = s.about()
+	case len(parts) == 1 && parts[0] == "files" && r.Method == http.MethodGet:
+		v, err = s.list(r)
+	case len(parts) == 1 && parts[0] == "files" && r.Method == http.MethodPost:
+		if r.URL.Query().Get("uploadType") == "resumable" {
+			err = s.startSession(w, r, "")
+			break
+		}
+		v, err = s.create(r)
+	case len(parts) == 2 && parts[0] == "files" && r.Method == http.MethodGet:
+		if r.URL.Query().Get("alt") == "media" {
+			err = s.download(w, r, parts[1])
+			break
+		}
+		v, err = s.get(parts[1])
+	case len(parts) == 2 && parts[0] == "files" && r.Method == http.MethodPatch:
+		if r.URL.Query().Get("uploadType") == "resumable" {
+			err = s.startSession(w, r, parts[1])
+			break
+		}
+		v, err = s.update(r, parts[1])
+	case len(parts) == 2 && parts[0] == "files" && r.Method == http.MethodDelete:
+		err = s.delete(parts[1])
+		if err == nil {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case len(parts) == 3 && parts[0] == "files" && parts[2] == "copy" && r.Method == http.MethodPost:
+		v, err = s.copy(r, parts[1])
+	case len(parts) == 3 && parts[0] == "files" && parts[2] == "export" && r.Method == http.MethodGet:
+		err = s.export(w, r, parts[1])
+	case len(parts) >= 3 && parts[0] == "files":
+		v, err = s.fileResource(w, r, parts[1], parts[2:])
+	case len(parts) == 2 && parts[0] == "changes" && parts[1] == "startPageToken" && r.Method == http.MethodGet:
+		v = s.startPageToken()
+	case len(parts) == 2 && parts[0] == "channels" && parts[1] == "stop" && r.Method == http.MethodPost:
+		err = s.stopChannel(w, r)
+	case len(parts) == 1 && parts[0] == "changes" && r.Method == http.MethodGet:
+		v, err = s.listChanges(r)
+	default:
+		err = errorf(http.StatusNotImplemented, "notImplemented", "%s %s is not implemented by drivetest", r.Method, r.URL.Path)
+	}
+
+	if err == nil && v != nil {
+		v, err = selectFields(v, r.URL.Query().Get("fields"))
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if v != nil {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func (s *Server) about() *drive.About {
+	var usage int64
+	for _, f := range s.files {
+		usage += int64(len(f.content))
+	}
+	return &drive.About{
+		Kind:               "drive#about",
+		User:               owner(),
+		ExportFormats:      exportFormats,
+		ImportFormats:      importFormats,
+		FolderColorPalette: folderColorPalette,
+		StorageQuota: &drive.AboutStorageQuota{
+			Usage:        usage,
+			UsageInDrive: usage,
+		},
+	}
+}
+
+// view returns the metadata of the file as it will be sent to the client, s.mu must be held
+func (s *Server) view(f *file) *drive.File {
+	meta := copyFile(&f.meta)
+	isFolder := f.meta.MimeType == mimeTypeFolder
+	isNative := strings.HasPrefix(f.meta.MimeType, "application/vnd.google-apps.")
+	meta.ExplicitlyTrashed = f.meta.Trashed
+	meta.LastModifyingUser = owner()
+	meta.Capabilities = &drive.FileCapabilities{
+		CanAddChildren:                        isFolder,
+		CanChangeCopyRequiresWriterPermission: !isFolder,
+		CanComment:                            true,
+		CanCopy:                               !isFolder,
+		CanDelete:                             true,
+		CanDownload:                           !isNative,
+		CanEdit:                               true,
+		CanListChildren:                       isFolder,
+		CanReadRevisions:                      true,
+		CanRemoveChildren:                     isFolder,
+		CanRename:                             true,
+		CanShare:                              true,
+		CanTrash:                              true,
+		CanUntrash:                            true,
+	}
+	for _, permission := range f.permissions {
+		p := *permission
+		meta.Permissions = append(meta.Permissions, &p)
+		meta.PermissionIds = append(meta.PermissionIds, p.Id)
+	}
+	meta.Shared = len(f.permissions) > 1
+
+	switch {
+	case isFolder:
+		meta.WebViewLink = "https://drive.google.com/drive/folders/" + f.meta.Id
+	case isNative:
+		meta.WebViewLink = "https://docs.google.com/open?id=" + f.meta.Id
+		for _, format := range exportFormats[f.meta.MimeType] {
+			if meta.ExportLinks == nil {
+				meta.ExportLinks = make(map[string]string)
+			}
+			meta.ExportLinks[format] = s.URL + "/drive/v3/files/" + url.PathEscape(f.meta.Id) + "/export?mimeType=" + url.QueryEscape(format)
+		}
+	default:
+		meta.WebViewLink = "https://drive.google.com/file/d/" + f.meta.Id + "/view?usp=drivesdk"
+		meta.WebContentLink = "https://drive.google.com/uc?id=" + f.meta.Id + "&export=download"
+	}
+	return meta
+}
+
+func (s *Server) get(id string) (*drive.File, error) {
+	f, ok := s.lookup(id)
+	if !ok {
+		return nil, notFound(id)
+	}
+	return s.view(f), nil
+}
+
+func (s *Server) list(r *http.Request) (*drive.FileList, error) {
+	query := r.URL.Query()
+	match, err := parseQuery(query.Get("q"))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid", "Invalid Value: %v", err)
+	}
+
+	pageSize := defaultPageSize
+	if v := query.Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 || pageSize > maxPageSize {
+			return nil, errorf(http.StatusBadRequest, "invalid", "Invalid value '%s'. Values must be within the range: [1, %d]", v, maxPageSize)
+		}
+	}
+	offset := 0
+	if v := query.Get("pageToken"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return nil, errorf(http.StatusBadRequest, "invalid", "Invalid Value: pageToken")
+		}
+	}
+
+	var files []*drive.File
+	for _, f := range s.files {
+		if f.meta.Id != RootID && match(s, f) {
+			files = append(files, s.view(f))
+		}
+	}
+	if err = sortFiles(files, query.Get("orderBy")); err != nil {
+		return nil, err
+	}
+
+	list := &drive.FileList{Kind: "drive#fileList", Files: []*drive.File{}}
+	if offset < len(files) {
+		end := offset + pageSize
+		if end < len(files) {
+			list.NextPageToken = strconv.Itoa(end)
+		} else {
+			end = len(files)
+		}
+		list.Files = files[offset:end]
+	}
+	return list, nil
+}
+
+// sortFiles sorts the files by the orderBy parameter of files.list, files are sorted by their creation if orderBy is empty
+func sortFiles(files []*drive.File, orderBy string) error {
+	type key struct {
+		field string
+		desc  bool
+	}
+	var keys []key
+	for _, field := range strings.Split(orderBy, ",") {
+		f := strings.Fields(field)
+		switch {
+		case len(f) == 0:
+			continue
+		case len(f) > 2 || (len(f) == 2 && f[1] != "desc"):
+			return errorf(http.StatusBadRequest, "invalid", "Invalid Value: orderBy %s", orderBy)
+		}
+		switch f[0] {
+		case "name", "name_natural", "folder", "createdTime", "modifiedTime", "quotaBytesUsed":
+		default:
+			return errorf(http.StatusBadRequest, "invalid", "Invalid Value: orderBy %s", orderBy)
+		}
+		keys = append(keys, key{field: f[0], desc: len(f) == 2})
+	}
+	keys = append(keys, key{field: "id"})
+
+	compare := func(a, b *drive.File, field string) int {
+		switch field {
+		case "name", "name_natural":
+			return strings.Compare(a.Name, b.Name)
+		case "folder":
+			// folders first
+			af, bf := a.MimeType == mimeTypeFolder, b.MimeType == mimeTypeFolder
+			switch {
+			case af == bf:
+				return 0
+			case af:
+				return -1
+			default:
+				return 1
+			}
+		case "createdTime":
+			return strings.Compare(a.CreatedTime, b.CreatedTime)
+		case "modifiedTime":
+			return strings.Compare(a.ModifiedTime, b.ModifiedTime)
+		case "quotaBytesUsed":
+			return int(a.Size - b.Size)
+		default:
+			return strings.Compare(a.Id, b.Id)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		for _, k := range keys {
+			c := compare(files[i], files[j], k.field)
+			if k.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request, id string) error {
+	f, ok := s.lookup(id)
+	if !ok {
+		return notFound(id)
+	}
+	if strings.HasPrefix(f.meta.MimeType, "application/vnd.google-apps.") {
+		return errorf(http.StatusForbidden, "fileNotDownloadable", "Only files with binary content can be downloaded. Use Export with Docs Editors files.")
+	}
+	w.Header().Set("Content-Type", f.meta.MimeType)
+	content := f.content
+	if rng := r.Header.Get("Range"); rng != "" {
+		start, end, err := parseRange(rng, int64(len(content)))
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+			return errorf(http.StatusRequestedRangeNotSatisfiable, "requestedRangeNotSatisfiable", "Request range not satisfiable")
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, err = w.Write(content[start : end+1])
+		return err
+	}
+	_, err := w.Write(content)
+	return err
+}
+
+// parseRange parses a Range header of the form bytes=start-end or bytes=start-
+func parseRange(s string, size int64) (int64, int64, error) {
+	spec := strings.TrimPrefix(s, "bytes=")
+	i := strings.IndexByte(spec, '-')
+	if spec == s || i < 0 {
+		return 0, 0, fmt.Errorf("invalid range %s", s)
+	}
+	start, err := strconv.ParseInt(spec[:i], 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, fmt.Errorf("invalid range %s", s)
+	}
+	end := size - 1
+	if spec[i+1:] != "" {
+		if end, err = strconv.ParseInt(spec[i+1:], 10, 64); err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid range %s", s)
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, nil
+}
+
+func (s *Server) export(w http.ResponseWriter, r *http.Request, id string) error {
+	f, ok := s.lookup(id)
+	if !ok {
+		return notFound(id)
+	}
+	mimeType := r.URL.Query().Get("mimeType")
+	if mimeType == "" {
+		return errorf(http.StatusBadRequest, "required", "Required parameter: mimeType")
+	}
+	formats, ok := exportFormats[f.meta.MimeType]
+	if !ok {
+		return errorf(http.StatusForbidden, "fileNotExportable", "Export only supports Docs Editors files.")
+	}
+	if !contains(formats, mimeType) {
+		return errorf(http.StatusBadRequest, "badRequest", "The requested conversion is not supported.")
+	}
+	content, err := exportContent(f.content, mimeType)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", mimeType)
+	_, err = w.Write(content)
+	return err
+}
+
+// readUpload reads the metadata and the media of a create or update request,
+// the drive library only adds the /upload prefix for the default endpoint so uploads are accepted on both paths
+func readUpload(r *http.Request) (meta *metadata, media []byte, mediaType string, err error) {
+	meta = new(metadata)
+	uploadType := r.URL.Query().Get("uploadType")
+	switch uploadType {
+	case "":
+		err = meta.decode(r.Body)
+		return meta, nil, "", err
+	case "media":
+		media, err = ioutil.ReadAll(r.Body)
+		return meta, media, r.Header.Get("Content-Type"), err
+	case "multipart":
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+			return nil, nil, "", errorf(http.StatusBadRequest, "badContent", "multipart upload without multipart body")
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, nil, "", errorf(http.StatusBadRequest, "badContent", "missing metadata part: %v", err)
+		}
+		if err = meta.decode(part); err != nil {
+			return nil, nil, "", err
+		}
+		part, err = mr.NextPart()
+		if err != nil {
+			return nil, nil, "", errorf(http.StatusBadRequest, "badContent", "missing media part: %v", err)
+		}
+		media, err = ioutil.ReadAll(part)
+		return meta, media, part.Header.Get("Content-Type"), err
+	default:
+		return nil, nil, "", errorf(http.StatusBadRequest, "invalid", "Invalid Value: uploadType %s", uploadType)
+	}
+}
+
+func (s *Server) create(r *http.Request) (*drive.File, error) {
+	meta, media, mediaType, err := readUpload(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.createFile(meta, media, mediaType, r.URL.Query().Get("uploadType") != "")
+}
+
+// createFile stores a new file, s.mu must be held
+func (s *Server) createFile(meta *metadata, media []byte, mediaType string, hasMedia bool) (*drive.File, error) {
+	parents := []string{RootID}
+	if meta.File.Parents != nil {
+		parents = splitIDs(strings.Join(meta.File.Parents, ","))
+	}
+	for _, parent := range parents {
+		p, ok := s.files[parent]
+		if !ok {
+			return nil, notFound(parent)
+		}
+		if p.meta.MimeType != mimeTypeFolder {
+			return nil, errorf(http.StatusForbidden, "teamDrivesParentLimit", "The parent %s is not a folder.", parent)
+		}
+	}
+
+	now := s.timestamp()
+	f := &file{meta: drive.File{
+		Kind:         "drive#file",
+		Id:           meta.File.Id,
+		Name:         meta.File.Name,
+		MimeType:     meta.File.MimeType,
+		Parents:      parents,
+		CreatedTime:  meta.File.CreatedTime,
+		ModifiedTime: meta.File.ModifiedTime,
+		Owners:       []*drive.User{owner()},
+		// drive allows writers to share new files
+		WritersCanShare: true,
+	}, permissions: []*drive.Permission{ownerPermission()}}
+	if f.meta.Id == "" {
+		f.meta.Id = s.newID()
+	} else if _, exists := s.files[f.meta.Id]; exists {
+		return nil, errorf(http.StatusConflict, "duplicate", "A file already exists with the provided ID.")
+	}
+	if f.meta.Name == "" {
+		f.meta.Name = "Untitled"
+	}
+	if f.meta.MimeType == "" {
+		f.meta.MimeType = "application/octet-stream"
+		if mediaType != "" {
+			f.meta.MimeType = mediaType
+		}
+	}
+	if f.meta.CreatedTime == "" {
+		f.meta.CreatedTime = now
+	}
+	if f.meta.ModifiedTime == "" {
+		f.meta.ModifiedTime = now
+	}
+	if hasMedia && f.meta.MimeType == mimeTypeFolder {
+		return nil, errorf(http.StatusBadRequest, "badContent", "Folders cannot have contents.")
+	}
+	meta.apply(&f.meta)
+	if f.meta.MimeType != mimeTypeFolder {
+		s.setContent(f, media)
+	}
+	s.files[f.meta.Id] = f
+	s.changed(f.meta.Id, false)
+	return s.view(f), nil
+}
+
+func (s *Server) update(r *http.Request, id string) (*drive.File, error) {
+	meta, media, _, err := readUpload(r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	return s.updateFile(id, meta, query.Get("addParents"), query.Get("removeParents"), media, query.Get("uploadType") != "")
+}
+
+// updateFile updates the metadata and the contents of a file, s.mu must be held
+func (s *Server) updateFile(id string, meta *metadata, addParents, removeParents string, media []byte, hasMedia bool) (*drive.File, error) {
+	f, ok := s.lookup(id)
+	if !ok {
+		return nil, notFound(id)
+	}
+	if meta.File.Parents != nil {
+		return nil, errorf(http.StatusForbidden, "fieldNotWritable", "The resource body includes fields which are not directly writable.")
+	}
+	if hasMedia && f.meta.MimeType == mimeTypeFolder {
+		return nil, errorf(http.StatusBadRequest, "badContent", "Folders cannot have contents.")
+	}
+
+	parents := f.meta.Parents
+	for _, parent := range splitIDs(removeParents) {
+		for i := 0; i < len(parents); i++ {
+			if parents[i] == parent {
+				parents = append(parents[:i:i], parents[i+1:]...)
+				i--
+			}
+		}
+	}
+	for _, parent := range splitIDs(addParents) {
+		p, ok := s.files[parent]
+		if !ok {
+			return nil, notFound(parent)
+		}
+		if p.meta.MimeType != mimeTypeFolder {
+			return nil, errorf(http.StatusForbidden, "teamDrivesParentLimit", "The parent %s is not a folder.", parent)
+		}
+		if parent == f.meta.Id || s.isAncestor(f.meta.Id, parent) {
+			return nil, errorf(http.StatusBadRequest, "invalidParent", "A folder cannot be moved into itself or its descendants.")
+		}
+		if !contains(parents, parent) {
+			parents = append(parents, parent)
+		}
+	}
+
+	updated := f.meta
+	updated.Parents = parents
+	updated.Properties = copyMap(f.meta.Properties)
+	updated.AppProperties = copyMap(f.meta.AppProperties)
+	if meta.File.Name != "" {
+		updated.Name = meta.File.Name
+	}
+	if meta.File.MimeType != "" {
+		updated.MimeType = meta.File.MimeType
+	}
+	meta.apply(&updated)
+	if meta.has("trashed") {
+		updated.TrashedTime = ""
+		if updated.Trashed {
+			updated.TrashedTime = s.timestamp()
+		}
+	}
+	if hasMedia || !meta.has("modifiedTime") {
+		updated.ModifiedTime = s.timestamp()
+	}
+	if meta.has("modifiedTime") {
+		updated.ModifiedTime = meta.File.ModifiedTime
+	}
+	f.meta = updated
+	if hasMedia {
+		s.setContent(f, media)
+	}
+	s.changed(f.meta.Id, false)
+	return s.view(f), nil
+}
+
+// isAncestor returns whether the directory ancestorID is an ancestor of id, s.mu must be held
+func (s *Server) isAncestor(ancestorID, id string) bool {
+	seen := make(map[string]bool)
+	queue := []string{id}
+	for len(queue) > 0 {
+		f, ok := s.files[queue[0]]
+		queue = queue[1:]
+		if !ok || seen[f.meta.Id] {
+			continue
+		}
+		seen[f.meta.Id] = true
+		for _, parent := range f.meta.Parents {
+			if parent == ancestorID {
+				return true
+			}
+			queue = append(queue, parent)
+		}
+	}
+	return false
+}
+
+func (s *Server) delete(id string) error {
+	f, ok := s.lookup(id)
+	if !ok {
+		return notFound(id)
+	}
+	if f.meta.Id == RootID {
+		return errorf(http.StatusForbidden, "insufficientFilePermissions", "The user does not have sufficient permissions for this file.")
+	}
+	s.remove(f.meta.Id)
+	return nil
+}
+
+func (s *Server) copy(r *http.Request, id string) (*drive.File, error) {
+	src, ok := s.lookup(id)
+	if !ok {
+		return nil, notFound(id)
+	}
+	if src.meta.MimeType == mimeTypeFolder {
+		return nil, errorf(http.StatusForbidden, "cannotCopyFile", "This file cannot be copied by the user.")
+	}
+	meta := new(metadata)
+	if err := meta.decode(r.Body); err != nil {
+		return nil, err
+	}
+
+	// the copy inherits everything that was not specified in the request
+	if meta.File.Name == "" {
+		meta.File.Name = "Copy of " + src.meta.Name
+	}
+	if meta.File.MimeType == "" {
+		meta.File.MimeType = src.meta.MimeType
+	}
+	if meta.File.Parents == nil {
+		meta.File.Parents = src.meta.Parents
+	}
+	if !meta.has("description") {
+		meta.File.Description = src.meta.Description
+		meta.fields["description"] = nil
+	}
+	properties := mergeProperties(src.meta.Properties, meta.properties)
+	appProperties := mergeProperties(src.meta.AppProperties, meta.appProperties)
+	meta.properties, meta.appProperties = nil, nil
+	f, err := s.createFile(meta, append([]byte(nil), src.content...), "", true)
+	if err != nil {
+		return nil, err
+	}
+	stored := s.files[f.Id]
+	stored.meta.Properties, stored.meta.AppProperties = properties, appProperties
+	return s.view(stored), nil
+}
+
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, id string) error {
+	meta := new(metadata)
+	if err := meta.decode(r.Body); err != nil {
+		return err
+	}
+	if id != "" {
+		if _, ok := s.lookup(id); !ok {
+			return notFound(id)
+		}
+	}
+	s.lastID++
+	sessionID := fmt.Sprintf("session%d", s.lastID)
+	s.sessions[sessionID] = &uploadSession{
+		fileID:   id,
+		meta:     meta,
+		mimeType: r.Header.Get("X-Upload-Content-Type"),
+	}
+
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("upload_id", sessionID)
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		params.Set("fields", fields)
+	}
+	location := s.URL + "/upload/drive/v3/files"
+	if id != "" {
+		location += "/" + url.PathEscape(id)
+	}
+	w.Header().Set("Location", location+"?"+params.Encode())
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// cancelSession discards a resumable upload, drive answers with 499 Client Closed Request
+func (s *Server) cancelSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("upload_id")
+	if _, ok := s.sessions[sessionID]; !ok {
+		writeError(w, errorf(http.StatusNotFound, "notFound", "upload session %s does not exist", sessionID))
+		return
+	}
+	delete(s.sessions, sessionID)
+	w.WriteHeader(499)
+}
+
+func (s *Server) uploadChunk(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("upload_id")
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		writeError(w, errorf(http.StatusNotFound, "notFound", "upload session %s does not exist", sessionID))
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Content-Range: bytes start-end/total, bytes */total or bytes start-end/*
+	contentRange := strings.TrimPrefix(r.Header.Get("Content-Range"), "bytes ")
+	i := strings.IndexByte(contentRange, '/')
+	if i < 0 {
+		writeError(w, errorf(http.StatusBadRequest, "badContent", "invalid Content-Range %s", r.Header.Get("Content-Range")))
+		return
+	}
+	total := int64(-1)
+	if contentRange[i+1:] != "*" {
+		if total, err = strconv.ParseInt(contentRange[i+1:], 10, 64); err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "badContent", "invalid Content-Range %s", r.Header.Get("Content-Range")))
+			return
+		}
+	}
+	if contentRange[:i] != "*" {
+		j := strings.IndexByte(contentRange[:i], '-')
+		start, err := strconv.ParseInt(contentRange[:max(j, 0)], 10, 64)
+		if j < 0 || err != nil || start > int64(len(session.content)) {
+			writeError(w, errorf(http.StatusBadRequest, "badContent", "invalid Content-Range %s", r.Header.Get("Content-Range")))
+			return
+		}
+		session.content = append(session.content[:start], data...)
+	}
+
+	if total < 0 || int64(len(session.content)) < total {
+		if len(session.content) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(session.content)-1))
+		}
+		if r.Header.Get("X-GUploader-No-308") == "yes" {
+			w.Header().Set("X-Http-Status-Code-Override", "308")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(308)
+		return
+	}
+
+	delete(s.sessions, sessionID)
+	var f *drive.File
+	if session.fileID == "" {
+		f, err = s.createFile(session.meta, session.content, session.mimeType, true)
+	} else {
+		f, err = s.updateFile(session.fileID, session.meta, "", "", session.content, true)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	v, err := selectFields(f, r.URL.Query().Get("fields"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*apiError)
+	if !ok {
+		e = errorf(http.StatusInternalServerError, "internalError", "%v", err)
+	}
+	writeJSON(w, e.code, map[string]interface{}{
+		"error": map[string]interface{}{
+			"errors": []map[string]string{{
+				"domain":  "global",
+				"reason":  e.reason,
+				"message": e.message,
+			}},
+			"code":    e.code,
+			"message": e.message,
+		},
+	})
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+package drivetest
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// metadata is the file resource of a create, update or copy request,
+// it remembers which fields were sent so updates only change those
+type metadata struct {
+	File          drive.File
+	fields        map[string]json.RawMessage
+	properties    map[string]*string
+	appProperties map[string]*string
+}
+
+// decode reads the metadata from r, an empty body is valid
+func (m *metadata) decode(r io.Reader) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.fields = make(map[string]json.RawMessage)
+	if len(body) == 0 {
+		return nil
+	}
+	var properties struct {
+		Properties    map[string]*string `json:"properties"`
+		AppProperties map[string]*string `json:"appProperties"`
+	}
+	for _, v := range []interface{}{&m.fields, &m.File, &properties} {
+		if err = json.Unmarshal(body, v); err != nil {
+			return errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+		}
+	}
+	m.properties, m.appProperties = properties.Properties, properties.AppProperties
+	return nil
+}
+
+// has returns whether the field was sent
+func (m *metadata) has(field string) bool {
+	_, ok := m.fields[field]
+	return ok
+}
+
+// apply sets the writable fields that were sent on f,
+// id, name, mimeType, parents and the timestamps are handled by the caller
+func (m *metadata) apply(f *drive.File) {
+	if m.has("description") {
+		f.Description = m.File.Description
+	}
+	if m.has("starred") {
+		f.Starred = m.File.Starred
+	}
+	if m.has("trashed") {
+		f.Trashed = m.File.Trashed
+	}
+	if m.has("folderColorRgb") {
+		f.FolderColorRgb = m.File.FolderColorRgb
+	}
+	if m.has("originalFilename") {
+		f.OriginalFilename = m.File.OriginalFilename
+	}
+	if m.has("writersCanShare") {
+		f.WritersCanShare = m.File.WritersCanShare
+	}
+	if m.has("copyRequiresWriterPermission") {
+		f.CopyRequiresWriterPermission = m.File.CopyRequiresWriterPermission
+	}
+	if len(m.properties) > 0 {
+		f.Properties = mergeProperties(f.Properties, m.properties)
+	}
+	if len(m.appProperties) > 0 {
+		f.AppProperties = mergeProperties(f.AppProperties, m.appProperties)
+	}
+}
+
+// mergeProperties returns a copy of properties with the changes applied, null values remove a property
+func mergeProperties(properties map[string]string, changes map[string]*string) map[string]string {
+	merged := copyMap(properties)
+	for k, v := range changes {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]string)
+		}
+		merged[k] = *v
+	}
+	return merged
+}
@@ -0,0 +1,366 @@
+package drivetest
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// predicate reports whether a file matches a query, s.mu must be held
+type predicate func(s *Server, f *file) bool
+
+// token is a token of a query, strings are unquoted
+type token struct {
+	value  string
+	quoted bool
+}
+
+// tokenize splits a query into tokens
+func tokenize(q string) ([]token, error) {
+	var tokens []token
+	runes := []rune(q)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in query `%s'", q)
+			}
+			i++
+			tokens = append(tokens, token{value: sb.String(), quoted: true})
+		case strings.ContainsRune("(){},", r):
+			tokens = append(tokens, token{value: string(r)})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("invalid operator in query `%s'", q)
+			}
+			tokens = append(tokens, token{value: op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("(){},=!<>'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{value: string(runes[start:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive descent parser for the drive query language
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseQuery parses a drive query (the q parameter of files.list), an empty query matches every file
+func parseQuery(q string) (predicate, error) {
+	tokens, err := tokenize(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return func(*Server, *file) bool { return true }, nil
+	}
+	p := &queryParser{tokens: tokens}
+	match, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected `%s' in query", p.tokens[p.pos].value)
+	}
+	return match, nil
+}
+
+// peek returns whether the next token is the unquoted keyword
+func (p *queryParser) peek(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && p.tokens[p.pos].value == keyword
+}
+
+func (p *queryParser) next() (token, error) {
+	if p.pos >= len(p.tokens) {
+		return token{}, fmt.Errorf("unexpected end of query")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *queryParser) expect(keyword string) error {
+	if !p.peek(keyword) {
+		return fmt.Errorf("expected `%s' in query", keyword)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *queryParser) or() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("or") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s *Server, f *file) bool { return l(s, f) || right(s, f) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("and") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s *Server, f *file) bool { return l(s, f) && right(s, f) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) unary() (predicate, error) {
+	switch {
+	case p.peek("not"):
+		p.pos++
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(s *Server, f *file) bool { return !inner(s, f) }, nil
+	case p.peek("("):
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	default:
+		return p.term()
+	}
+}
+
+// term parses a single condition
+//     'value' in field
+//     field has { key='k' and value='v' }
+//     field operator value
+func (p *queryParser) term() (predicate, error) {
+	first, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if first.quoted {
+		if err = p.expect("in"); err != nil {
+			return nil, err
+		}
+		field, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		return inField(first.value, field.value)
+	}
+
+	field := first.value
+	if p.peek("has") {
+		p.pos++
+		return p.has(field)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return compareField(field, op.value, value)
+}
+
+// inField builds the condition 'value' in field
+func inField(value, field string) (predicate, error) {
+	switch field {
+	case "parents":
+		id := resolveID(value)
+		return func(s *Server, f *file) bool { return contains(f.meta.Parents, id) }, nil
+	case "owners", "writers", "readers":
+		return func(s *Server, f *file) bool { return value == OwnerEmail || value == "me" }, nil
+	default:
+		return nil, fmt.Errorf("unsupported field `%s' for operator in", field)
+	}
+}
+
+// has parses { key='k' and value='v' } for properties and appProperties
+func (p *queryParser) has(field string) (predicate, error) {
+	if field != "properties" && field != "appProperties" {
+		return nil, fmt.Errorf("unsupported field `%s' for operator has", field)
+	}
+	var key, value string
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for i := 0; i < 2; i++ {
+		name, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("="); err != nil {
+			return nil, err
+		}
+		v, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch name.value {
+		case "key":
+			key = v.value
+		case "value":
+			value = v.value
+		default:
+			return nil, fmt.Errorf("unexpected `%s' in %s has", name.value, field)
+		}
+		if i == 0 {
+			if err = p.expect("and"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return func(s *Server, f *file) bool {
+		properties := f.meta.Properties
+		if field == "appProperties" {
+			properties = f.meta.AppProperties
+		}
+		v, ok := properties[key]
+		return ok && v == value
+	}, nil
+}
+
+// compareField builds the condition field op value
+func compareField(field, op string, value token) (predicate, error) {
+	switch field {
+	case "name", "mimeType", "fullText", "description":
+		if !value.quoted {
+			return nil, fmt.Errorf("expected a string for `%s'", field)
+		}
+		get := func(f *file) string {
+			switch field {
+			case "name":
+				return f.meta.Name
+			case "mimeType":
+				return f.meta.MimeType
+			case "description":
+				return f.meta.Description
+			default:
+				return f.meta.Name + " " + f.meta.Description + " " + string(f.content)
+			}
+		}
+		switch {
+		case op == "contains" && field == "name":
+			return func(s *Server, f *file) bool { return containsPrefix(get(f), value.value) }, nil
+		case op == "contains":
+			return func(s *Server, f *file) bool {
+				return strings.Contains(strings.ToLower(get(f)), strings.ToLower(value.value))
+			}, nil
+		case op == "=" && field != "fullText":
+			return func(s *Server, f *file) bool { return get(f) == value.value }, nil
+		case op == "!=" && field != "fullText":
+			return func(s *Server, f *file) bool { return get(f) != value.value }, nil
+		}
+	case "trashed", "starred", "sharedWithMe":
+		if value.quoted || (value.value != "true" && value.value != "false") {
+			return nil, fmt.Errorf("expected true or false for `%s'", field)
+		}
+		want := value.value == "true"
+		get := func(s *Server, f *file) bool {
+			switch field {
+			case "trashed":
+				return f.meta.Trashed
+			case "starred":
+				return f.meta.Starred
+			default:
+				return false
+			}
+		}
+		switch op {
+		case "=":
+			return func(s *Server, f *file) bool { return get(s, f) == want }, nil
+		case "!=":
+			return func(s *Server, f *file) bool { return get(s, f) != want }, nil
+		}
+	case "createdTime", "modifiedTime":
+		if !value.quoted {
+			return nil, fmt.Errorf("expected a string for `%s'", field)
+		}
+		get := func(f *file) string {
+			if field == "createdTime" {
+				return f.meta.CreatedTime
+			}
+			return f.meta.ModifiedTime
+		}
+		// RFC 3339 timestamps in UTC compare like strings
+		want := normalizeTime(value.value)
+		compare := map[string]func(a, b string) bool{
+			"=":  func(a, b string) bool { return a == b },
+			"!=": func(a, b string) bool { return a != b },
+			"<":  func(a, b string) bool { return a < b },
+			"<=": func(a, b string) bool { return a <= b },
+			">":  func(a, b string) bool { return a > b },
+			">=": func(a, b string) bool { return a >= b },
+		}[op]
+		if compare != nil {
+			return func(s *Server, f *file) bool { return compare(normalizeTime(get(f)), want) }, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported field `%s'", field)
+	}
+	return nil, fmt.Errorf("unsupported operator `%s' for `%s'", op, field)
+}
+
+// containsPrefix implements contains for names: drive matches the prefixes of the words in the name
+func containsPrefix(name, prefix string) bool {
+	name, prefix = strings.ToLower(name), strings.ToLower(prefix)
+	if strings.HasPrefix(name, prefix) {
+		return true
+	}
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTime brings timestamps into the form 2006-01-02T15:04:05.000Z so they can be compared
+func normalizeTime(s string) string {
+	s = strings.TrimSuffix(s, "Z")
+	if !strings.Contains(s, ".") {
+		s += ".000"
+	}
+	return s + "Z"
+}
@@ -0,0 +1,338 @@
+package drivetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// fileResource handles the sub resources of a file (permissions, revisions, comments, replies and watch),
+// parts is the path after files/{fileId}
+func (s *Server) fileResource(w http.ResponseWriter, r *http.Request, id string, parts []string) (interface{}, error) {
+	f, ok := s.lookup(id)
+	if !ok {
+		return nil, notFound(id)
+	}
+	switch {
+	case parts[0] == "permissions" && len(parts) == 1 && r.Method == http.MethodGet:
+		return &drive.PermissionList{Kind: "drive#permissionList", Permissions: s.view(f).Permissions}, nil
+	case parts[0] == "permissions" && len(parts) == 1 && r.Method == http.MethodPost:
+		return s.createPermission(r, f)
+	case parts[0] == "permissions" && len(parts) == 2 && r.Method == http.MethodDelete:
+		return nil, s.deletePermission(w, f, parts[1])
+	case parts[0] == "revisions" && len(parts) == 1 && r.Method == http.MethodGet:
+		list := &drive.RevisionList{Kind: "drive#revisionList", Revisions: []*drive.Revision{}}
+		for _, rev := range f.revisions {
+			meta := rev.meta
+			list.Revisions = append(list.Revisions, &meta)
+		}
+		return list, nil
+	case parts[0] == "revisions" && len(parts) == 2 && r.Method == http.MethodGet:
+		return s.getRevision(w, r, f, parts[1])
+	case parts[0] == "revisions" && len(parts) == 2 && r.Method == http.MethodPatch:
+		return s.updateRevision(r, f, parts[1])
+	case parts[0] == "comments" && len(parts) == 1 && r.Method == http.MethodGet:
+		return s.listComments(r, f), nil
+	case parts[0] == "comments" && len(parts) == 1 && r.Method == http.MethodPost:
+		return s.createComment(r, f)
+	case parts[0] == "comments" && len(parts) == 2 && r.Method == http.MethodDelete:
+		return nil, s.deleteComment(w, f, parts[1])
+	case parts[0] == "comments" && len(parts) == 3 && parts[2] == "replies" && r.Method == http.MethodPost:
+		return s.createReply(r, f, parts[1])
+	case parts[0] == "watch" && len(parts) == 1 && r.Method == http.MethodPost:
+		return s.watch(r, f)
+	}
+	return nil, errorf(http.StatusNotImplemented, "notImplemented", "%s %s is not implemented by drivetest", r.Method, r.URL.Path)
+}
+
+func (s *Server) createPermission(r *http.Request, f *file) (*drive.Permission, error) {
+	var permission drive.Permission
+	if err := json.NewDecoder(r.Body).Decode(&permission); err != nil {
+		return nil, errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	switch permission.Type {
+	case "user", "group":
+		if permission.EmailAddress == "" {
+			return nil, errorf(http.StatusBadRequest, "required", "The permission email address field is required.")
+		}
+		permission.Id = "perm-" + permission.EmailAddress
+	case "domain":
+		if permission.Domain == "" {
+			return nil, errorf(http.StatusBadRequest, "required", "The permission domain field is required.")
+		}
+		permission.Id = "perm-" + permission.Domain
+	case "anyone":
+		permission.Id = "anyoneWithLink"
+	default:
+		return nil, errorf(http.StatusBadRequest, "invalid", "Invalid permission type: %s", permission.Type)
+	}
+	switch permission.Role {
+	case "owner":
+		if r.URL.Query().Get("transferOwnership") != "true" {
+			return nil, errorf(http.StatusForbidden, "forbidden", "The transferOwnership parameter must be enabled when the permission role is 'owner'.")
+		}
+		// the fake only knows one user, the ownership cannot be transferred
+		return nil, errorf(http.StatusBadRequest, "invalidSharingRequest", "Bad Request. User message: \"Ownership cannot be transferred by drivetest.\"")
+	case "organizer", "fileOrganizer", "writer", "commenter", "reader":
+	default:
+		return nil, errorf(http.StatusBadRequest, "invalid", "Invalid permission role: %s", permission.Role)
+	}
+	permission.Kind = "drive#permission"
+
+	for i, existing := range f.permissions {
+		if existing.Id == permission.Id {
+			f.permissions[i] = &permission
+			s.changed(f.meta.Id, false)
+			return &permission, nil
+		}
+	}
+	f.permissions = append(f.permissions, &permission)
+	s.changed(f.meta.Id, false)
+	return &permission, nil
+}
+
+func (s *Server) deletePermission(w http.ResponseWriter, f *file, permissionID string) error {
+	for i, permission := range f.permissions {
+		if permission.Id != permissionID {
+			continue
+		}
+		if permission.Role == "owner" {
+			return errorf(http.StatusForbidden, "cannotRemoveOwner", "The owner of a file cannot be removed.")
+		}
+		f.permissions = append(f.permissions[:i:i], f.permissions[i+1:]...)
+		s.changed(f.meta.Id, false)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return errorf(http.StatusNotFound, "notFound", "Permission not found: %s.", permissionID)
+}
+
+// getRevision returns the metadata of the revision or writes its contents for alt=media
+func (s *Server) getRevision(w http.ResponseWriter, r *http.Request, f *file, revisionID string) (interface{}, error) {
+	for _, rev := range f.revisions {
+		if rev.meta.Id != revisionID {
+			continue
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			w.Header().Set("Content-Type", rev.meta.MimeType)
+			_, err := w.Write(rev.content)
+			return nil, err
+		}
+		meta := rev.meta
+		return &meta, nil
+	}
+	return nil, errorf(http.StatusNotFound, "notFound", "Revision not found: %s.", revisionID)
+}
+
+// updateRevision changes the keepForever flag of a revision, the other fields are read only in the fake
+func (s *Server) updateRevision(r *http.Request, f *file, revisionID string) (interface{}, error) {
+	var update drive.Revision
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	for _, rev := range f.revisions {
+		if rev.meta.Id == revisionID {
+			rev.meta.KeepForever = update.KeepForever
+			meta := rev.meta
+			return &meta, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "notFound", "Revision not found: %s.", revisionID)
+}
+
+func (s *Server) listComments(r *http.Request, f *file) *drive.CommentList {
+	includeDeleted := r.URL.Query().Get("includeDeleted") == "true"
+	list := &drive.CommentList{Kind: "drive#commentList", Comments: []*drive.Comment{}}
+	for _, comment := range f.comments {
+		if comment.Deleted && !includeDeleted {
+			continue
+		}
+		c := *comment
+		c.Replies = append([]*drive.Reply(nil), comment.Replies...)
+		list.Comments = append(list.Comments, &c)
+	}
+	return list
+}
+
+func (s *Server) createComment(r *http.Request, f *file) (*drive.Comment, error) {
+	var comment drive.Comment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		return nil, errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	if comment.Content == "" {
+		return nil, errorf(http.StatusBadRequest, "required", "Required: content")
+	}
+	s.lastID++
+	now := s.timestamp()
+	comment.Kind = "drive#comment"
+	comment.Id = "comment" + strconv.Itoa(s.lastID)
+	comment.Author = owner()
+	comment.CreatedTime = now
+	comment.ModifiedTime = now
+	comment.HtmlContent = comment.Content
+	f.comments = append(f.comments, &comment)
+	return &comment, nil
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, f *file, commentID string) error {
+	for _, comment := range f.comments {
+		if comment.Id == commentID && !comment.Deleted {
+			// deleted comments are kept without their contents
+			comment.Deleted = true
+			comment.Content = ""
+			comment.HtmlContent = ""
+			comment.ModifiedTime = s.timestamp()
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+	}
+	return errorf(http.StatusNotFound, "notFound", "Comment not found: %s.", commentID)
+}
+
+func (s *Server) createReply(r *http.Request, f *file, commentID string) (*drive.Reply, error) {
+	var reply drive.Reply
+	if err := json.NewDecoder(r.Body).Decode(&reply); err != nil {
+		return nil, errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	for _, comment := range f.comments {
+		if comment.Id != commentID || comment.Deleted {
+			continue
+		}
+		s.lastID++
+		now := s.timestamp()
+		reply.Kind = "drive#reply"
+		reply.Id = "reply" + strconv.Itoa(s.lastID)
+		reply.Author = owner()
+		reply.CreatedTime = now
+		reply.ModifiedTime = now
+		reply.HtmlContent = reply.Content
+		switch reply.Action {
+		case "resolve":
+			comment.Resolved = true
+		case "reopen":
+			comment.Resolved = false
+		}
+		comment.Replies = append(comment.Replies, &reply)
+		return &reply, nil
+	}
+	return nil, errorf(http.StatusNotFound, "notFound", "Comment not found: %s.", commentID)
+}
+
+// listChanges returns the changes since the page token, every file is reported once with its latest state
+func (s *Server) listChanges(r *http.Request) (*drive.ChangeList, error) {
+	query := r.URL.Query()
+	offset, err := strconv.Atoi(query.Get("pageToken"))
+	if err != nil || offset < 0 || offset > len(s.changes) {
+		return nil, errorf(http.StatusBadRequest, "invalid", "Invalid Value: pageToken")
+	}
+	pageSize := defaultPageSize
+	if v := query.Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 || pageSize > maxPageSize {
+			return nil, errorf(http.StatusBadRequest, "invalid", "Invalid value '%s'. Values must be within the range: [1, %d]", v, maxPageSize)
+		}
+	}
+	includeRemoved := query.Get("includeRemoved") != "false"
+
+	end := offset + pageSize
+	if end > len(s.changes) {
+		end = len(s.changes)
+	}
+	latest := make(map[string]int)
+	for i := offset; i < end; i++ {
+		latest[s.changes[i].fileID] = i
+	}
+
+	list := &drive.ChangeList{Kind: "drive#changeList", Changes: []*drive.Change{}}
+	for i := offset; i < end; i++ {
+		c := s.changes[i]
+		if latest[c.fileID] != i {
+			continue
+		}
+		f, exists := s.files[c.fileID]
+		removed := c.removed || !exists
+		if removed && !includeRemoved {
+			continue
+		}
+		change := &drive.Change{
+			Kind:    "drive#change",
+			Type:    "file",
+			FileId:  c.fileID,
+			Removed: removed,
+			Time:    c.time,
+		}
+		if !removed {
+			change.File = s.view(f)
+		}
+		list.Changes = append(list.Changes, change)
+	}
+	if end < len(s.changes) {
+		list.NextPageToken = strconv.Itoa(end)
+	} else {
+		list.NewStartPageToken = strconv.Itoa(end)
+	}
+	return list, nil
+}
+
+func (s *Server) startPageToken() *drive.StartPageToken {
+	return &drive.StartPageToken{
+		Kind:           "drive#startPageToken",
+		StartPageToken: fmt.Sprint(len(s.changes)),
+	}
+}
+
+// maxChannelLifetime is the longest lifetime of a notification channel for a file
+const maxChannelLifetime = 24 * time.Hour
+
+// watch creates a notification channel for the file, it expires after an hour if no expiration was requested
+func (s *Server) watch(r *http.Request, f *file) (*drive.Channel, error) {
+	var channel drive.Channel
+	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
+		return nil, errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	if channel.Id == "" || channel.Address == "" {
+		return nil, errorf(http.StatusBadRequest, "required", "Required")
+	}
+	if channel.Type != "web_hook" && channel.Type != "webhook" {
+		return nil, errorf(http.StatusBadRequest, "invalid", "Invalid value for type: %s", channel.Type)
+	}
+	if _, ok := s.channels[channel.Id]; ok {
+		return nil, errorf(http.StatusBadRequest, "channelIdNotUnique", "Channel id %s not unique", channel.Id)
+	}
+	now := s.now()
+	expiration := now.Add(time.Hour)
+	if channel.Expiration > 0 {
+		expiration = time.Unix(0, channel.Expiration*int64(time.Millisecond))
+		if max := now.Add(maxChannelLifetime); expiration.After(max) {
+			expiration = max
+		}
+	}
+	created := &drive.Channel{
+		Kind:        "api#channel",
+		Id:          channel.Id,
+		ResourceId:  "resource-" + f.meta.Id,
+		ResourceUri: s.URL + "/drive/v3/files/" + f.meta.Id + "?alt=json",
+		Token:       channel.Token,
+		Expiration:  expiration.UnixNano() / int64(time.Millisecond),
+	}
+	s.channels[channel.Id] = created
+	return created, nil
+}
+
+// stopChannel stops the channel with the id and resource id of the request body
+func (s *Server) stopChannel(w http.ResponseWriter, r *http.Request) error {
+	var channel drive.Channel
+	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
+		return errorf(http.StatusBadRequest, "parseError", "Parse Error: %v", err)
+	}
+	existing, ok := s.channels[channel.Id]
+	if !ok || existing.ResourceId != channel.ResourceId {
+		return errorf(http.StatusNotFound, "notFound", "Channel '%s' not found for project", channel.Id)
+	}
+	delete(s.channels, channel.Id)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
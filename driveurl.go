@@ -0,0 +1,183 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// ItemKind is the kind of item a drive url points to
+type ItemKind int
+
+const (
+	// ItemKindUnknown is used for urls that do not tell what they point to (e.g. https://drive.google.com/open?id=...)
+	ItemKindUnknown ItemKind = iota
+	// ItemKindFile is a file (e.g. https://drive.google.com/file/d/.../view)
+	ItemKindFile
+	// ItemKindFolder is a folder (e.g. https://drive.google.com/drive/folders/...)
+	ItemKindFolder
+	// ItemKindDocument is a google document
+	ItemKindDocument
+	// ItemKindSpreadsheet is a google spreadsheet
+	ItemKindSpreadsheet
+	// ItemKindPresentation is a google presentation
+	ItemKindPresentation
+	// ItemKindDrawing is a google drawing
+	ItemKindDrawing
+	// ItemKindForm is a google form
+	ItemKindForm
+)
+
+func (k ItemKind) String() string {
+	switch k {
+	case ItemKindFile:
+		return "file"
+	case ItemKindFolder:
+		return "folder"
+	case ItemKindDocument:
+		return "document"
+	case ItemKindSpreadsheet:
+		return "spreadsheet"
+	case ItemKindPresentation:
+		return "presentation"
+	case ItemKindDrawing:
+		return "drawing"
+	case ItemKindForm:
+		return "form"
+	}
+	return "unknown"
+}
+
+// docsKinds are the kinds of the docs.google.com/<type>/d/<id> urls
+var docsKinds = map[string]ItemKind{
+	"document":     ItemKindDocument,
+	"spreadsheets": ItemKindSpreadsheet,
+	"presentation": ItemKindPresentation,
+	"drawings":     ItemKindDrawing,
+	"forms":        ItemKindForm,
+	"file":         ItemKindFile,
+}
+
+// driveIDPattern matches drive ids
+var driveIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{10,}$`)
+
+// ParseDriveURL returns the drive id of the file or folder a drive url points to and what kind of item it is,
+// an ErrNotADriveURL will be returned if the url is not recognized.
+//
+// Examples:
+//     ParseDriveURL("https://drive.google.com/file/d/1A2b3C4d5E6f7G8h9I0j/view?usp=sharing")
+//     ParseDriveURL("https://drive.google.com/drive/folders/1A2b3C4d5E6f7G8h9I0j")
+//     ParseDriveURL("https://docs.google.com/document/d/1A2b3C4d5E6f7G8h9I0j/edit")
+func ParseDriveURL(u string) (id string, kind ItemKind, err error) {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", ItemKindUnknown, ErrNotADriveURL{URL: u}
+	}
+	parts := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	// urls of signed in users can contain the account index (e.g. /drive/u/1/folders/...)
+	if len(parts) >= 3 && parts[1] == "u" {
+		parts = append(parts[:1:1], parts[3:]...)
+	} else if len(parts) >= 2 && parts[0] == "u" {
+		parts = parts[2:]
+	}
+
+	switch strings.ToLower(parsed.Hostname()) {
+	case "drive.google.com":
+		switch {
+		case len(parts) >= 3 && parts[0] == "file" && parts[1] == "d":
+			id, kind = parts[2], ItemKindFile
+		case len(parts) >= 3 && parts[0] == "drive" && parts[1] == "folders":
+			id, kind = parts[2], ItemKindFolder
+		case len(parts) == 1 && parts[0] == "folderview":
+			id, kind = parsed.Query().Get("id"), ItemKindFolder
+		case len(parts) == 1 && parts[0] == "open":
+			id, kind = parsed.Query().Get("id"), ItemKindUnknown
+		case len(parts) == 1 && parts[0] == "uc":
+			id, kind = parsed.Query().Get("id"), ItemKindFile
+		}
+	case "docs.google.com":
+		switch {
+		case len(parts) >= 3 && parts[1] == "d" && docsKinds[parts[0]] != ItemKindUnknown:
+			// published forms (forms/d/e/...) have an id that is not a drive id, it will be rejected by driveIDPattern
+			id, kind = parts[2], docsKinds[parts[0]]
+		case len(parts) == 1 && parts[0] == "open":
+			id, kind = parsed.Query().Get("id"), ItemKindUnknown
+		case len(parts) == 1 && parts[0] == "uc":
+			id, kind = parsed.Query().Get("id"), ItemKindFile
+		}
+	}
+
+	if !driveIDPattern.MatchString(id) {
+		return "", ItemKindUnknown, ErrNotADriveURL{URL: u}
+	}
+	return id, kind, nil
+}
+
+// StatURL gives a FileInfo for the file or directory a drive url points to (see ParseDriveURL),
+// a FileNotExistError will be returned if it is not in the root directory.
+func (d *GDriver) StatURL(u string) (*FileInfo, error) {
+	id, _, err := ParseDriveURL(u)
+	if err != nil {
+		return nil, err
+	}
+	file, err := d.srv.Files.Get(id).Fields(append(fileInfoFields, "parents", "trashed")...).Do()
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil, FileNotExistError{Path: id}
+		}
+		return nil, err
+	}
+	if file.Trashed {
+		return nil, FileNotExistError{Path: id}
+	}
+	if file.Id == d.rootNode.item.Id {
+		return d.rootNode, nil
+	}
+	inRoot, parentPath, err := d.isInRoot(d.rootNode.item.Id, file, "")
+	if err != nil {
+		return nil, err
+	}
+	if !inRoot {
+		return nil, FileNotExistError{Path: id}
+	}
+	return &FileInfo{
+		item:         file,
+		parentPath:   parentPath,
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// OpenURL opens the file a drive url points to in the traditional os.Open way (see Open and ParseDriveURL),
+// the file must exist, O_CREATE is ignored.
+func (d *GDriver) OpenURL(u string, flag OpenFlag, opts ...PutOption) (File, error) {
+	if flag&O_RDONLY != 0 && flag&O_WRONLY != 0 {
+		return nil, errors.New("unable to open a file read and write at the same time")
+	}
+	file, err := d.StatURL(u)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: file.Path()}
+	}
+	if flag&O_WRONLY != 0 {
+		return &writeFile{
+			Driver:   d,
+			Path:     file.Path(),
+			FileInfo: file,
+			options:  opts,
+		}, nil
+	}
+	if flag&O_RDONLY != 0 {
+		return &readFile{
+			Driver:   d,
+			FileInfo: file,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown flag: %d", flag)
+}
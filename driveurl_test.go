@@ -0,0 +1,93 @@
+package gdriver
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDriveURL(t *testing.T) {
+	const id = "1A2b3C4d5E6f7G8h9I0j_kL-mN"
+	tests := []struct {
+		url  string
+		kind ItemKind
+	}{
+		{"https://drive.google.com/file/d/" + id + "/view?usp=sharing", ItemKindFile},
+		{"https://drive.google.com/file/d/" + id, ItemKindFile},
+		{"https://drive.google.com/file/u/1/d/" + id + "/edit", ItemKindFile},
+		{"https://drive.google.com/drive/folders/" + id, ItemKindFolder},
+		{"https://drive.google.com/drive/u/0/folders/" + id + "?usp=sharing", ItemKindFolder},
+		{"https://drive.google.com/folderview?id=" + id, ItemKindFolder},
+		{"https://drive.google.com/open?id=" + id, ItemKindUnknown},
+		{"https://drive.google.com/uc?id=" + id + "&export=download", ItemKindFile},
+		{"https://docs.google.com/document/d/" + id + "/edit", ItemKindDocument},
+		{"https://docs.google.com/document/u/2/d/" + id + "/edit#heading=h.1", ItemKindDocument},
+		{"https://docs.google.com/spreadsheets/d/" + id + "/edit#gid=0", ItemKindSpreadsheet},
+		{"https://docs.google.com/presentation/d/" + id + "/present", ItemKindPresentation},
+		{"https://docs.google.com/drawings/d/" + id + "/edit", ItemKindDrawing},
+		{"https://docs.google.com/forms/d/" + id + "/edit", ItemKindForm},
+		{" http://DRIVE.google.com/file/d/" + id + "/view ", ItemKindFile},
+	}
+	for _, test := range tests {
+		parsedID, kind, err := ParseDriveURL(test.url)
+		require.NoError(t, err, test.url)
+		require.Equal(t, id, parsedID, test.url)
+		require.Equal(t, test.kind, kind, test.url)
+	}
+
+	for _, u := range []string{
+		"",
+		id,
+		"ftp://drive.google.com/file/d/" + id,
+		"https://example.com/file/d/" + id,
+		"https://drive.google.com/drive/my-drive",
+		"https://drive.google.com/open",
+		"https://drive.google.com/open?id=",
+		"https://drive.google.com/file/d/x/view",
+		"https://docs.google.com/document/create",
+		"https://docs.google.com/forms/d/e/" + id + "/viewform",
+		"https://docs.google.com/unknown/d/" + id,
+	} {
+		_, _, err := ParseDriveURL(u)
+		require.Equal(t, ErrNotADriveURL{URL: u}, err, u)
+	}
+	require.Equal(t, "spreadsheet", ItemKindSpreadsheet.String())
+}
+
+func TestStatURL(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	folder, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+
+	stat, err := driver.StatURL("https://drive.google.com/file/d/" + fi.item.Id + "/view?usp=sharing")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/File1", stat.Path())
+
+	stat, err = driver.StatURL("https://drive.google.com/drive/folders/" + folder.item.Id)
+	require.NoError(t, err)
+	require.Equal(t, "Folder1", stat.Path())
+	require.True(t, stat.IsDir())
+
+	_, err = driver.StatURL("https://drive.google.com/file/d/0000000000000000000/view")
+	require.True(t, IsNotExist(err))
+	_, err = driver.StatURL("https://example.com")
+	require.IsType(t, ErrNotADriveURL{}, err)
+
+	f, err := driver.OpenURL("https://drive.google.com/open?id="+fi.item.Id, O_RDONLY)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, "Hello World", string(data))
+
+	_, err = driver.OpenURL("https://drive.google.com/drive/folders/"+folder.item.Id, O_RDONLY)
+	require.IsType(t, FileIsDirectoryError{}, err)
+	_, err = driver.OpenURL("https://drive.google.com/open?id="+fi.item.Id, 0)
+	require.EqualError(t, err, "unknown flag: 0")
+}
@@ -0,0 +1,305 @@
+package gdriver
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The encrypted content format looks like this:
+//
+//	header: magic (4 bytes) | version (1 byte) | chunk size (4 bytes) | salt (16 bytes) | nonce prefix (7 bytes)
+//	chunks: AES-GCM sealed chunks of chunk size plaintext bytes, the last chunk may be shorter
+//
+// Every file uses its own key which is derived from the master key and the salt.
+// The nonce of a chunk consists of the nonce prefix, the chunk counter and a flag that marks the last chunk,
+// this way reordered, truncated or extended streams will be detected.
+// The header is used as additional data for every chunk.
+const (
+	encryptionMagic           = "GDEC"
+	encryptionVersion         = 1
+	encryptionAlgorithm       = "aes-gcm-stream"
+	encryptionDefaultChunk    = 64 * 1024
+	encryptionSaltSize        = 16
+	encryptionNoncePrefixSize = 7
+	encryptionHeaderSize      = len(encryptionMagic) + 1 + 4 + encryptionSaltSize + encryptionNoncePrefixSize
+
+	appPropertyEncryption          = "gdriver-encryption"
+	appPropertyEncryptionVersion   = "gdriver-encryption-version"
+	appPropertyEncryptionChunkSize = "gdriver-encryption-chunk-size"
+)
+
+// WithEncryption enables client side encryption, all contents will be encrypted before uploading and decrypted after downloading.
+// key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+// Names and directories are not encrypted.
+//
+// Note that FileInfo.Size() and GetFileHash refer to the encrypted contents.
+func WithEncryption(key []byte) Option {
+	return func(driver *GDriver) error {
+		if _, err := aes.NewCipher(key); err != nil {
+			return fmt.Errorf("invalid encryption key: %v", err)
+		}
+		driver.encryptionKey = append([]byte(nil), key...)
+		return nil
+	}
+}
+
+// IsEncrypted returns true if the contents of this file are encrypted by WithEncryption
+func (i *FileInfo) IsEncrypted() bool {
+	return i.item.AppProperties[appPropertyEncryption] == encryptionAlgorithm
+}
+
+func encryptionAppProperties(chunkSize int) map[string]string {
+	return map[string]string{
+		appPropertyEncryption:          encryptionAlgorithm,
+		appPropertyEncryptionVersion:   strconv.Itoa(encryptionVersion),
+		appPropertyEncryptionChunkSize: strconv.Itoa(chunkSize),
+	}
+}
+
+// deriveEncryptionAEAD creates the AEAD for one file
+func deriveEncryptionAEAD(masterKey, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, masterKey)
+	mac.Write(salt) // nolint: errcheck
+	block, err := aes.NewCipher(mac.Sum(nil)[:len(masterKey)])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptionNonce(dst, prefix []byte, counter uint32, last bool) []byte {
+	dst = append(dst[:0], prefix...)
+	dst = dst[:encryptionNoncePrefixSize+4]
+	binary.BigEndian.PutUint32(dst[encryptionNoncePrefixSize:], counter)
+	if last {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+type encryptReader struct {
+	src       io.Reader
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int
+	counter   uint32
+	nonce     []byte
+	plain     []byte
+	pending   int
+	sealed    []byte
+	out       []byte
+	done      bool
+	err       error
+}
+
+func newEncryptReader(src io.Reader, key []byte, chunkSize int) (*encryptReader, error) {
+	header := make([]byte, encryptionHeaderSize)
+	copy(header, encryptionMagic)
+	header[len(encryptionMagic)] = encryptionVersion
+	binary.BigEndian.PutUint32(header[len(encryptionMagic)+1:], uint32(chunkSize))
+	if _, err := io.ReadFull(rand.Reader, header[len(encryptionMagic)+5:]); err != nil {
+		return nil, err
+	}
+
+	aead, err := deriveEncryptionAEAD(key, header[len(encryptionMagic)+5:len(encryptionMagic)+5+encryptionSaltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptReader{
+		src:       src,
+		aead:      aead,
+		header:    header,
+		chunkSize: chunkSize,
+		nonce:     make([]byte, 0, aead.NonceSize()),
+		plain:     make([]byte, chunkSize+1),
+		sealed:    make([]byte, 0, chunkSize+aead.Overhead()),
+		out:       append([]byte(nil), header...),
+	}, nil
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		if e.done {
+			return 0, io.EOF
+		}
+		e.err = e.seal()
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// seal reads the next chunk from the source and encrypts it
+// one byte more than the chunk size is read to find out if this is the last chunk
+func (e *encryptReader) seal() error {
+	n, err := io.ReadFull(e.src, e.plain[e.pending:])
+	n += e.pending
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	chunk := e.plain[:n]
+	if !last {
+		chunk = e.plain[:e.chunkSize]
+	}
+
+	prefix := e.header[encryptionHeaderSize-encryptionNoncePrefixSize:]
+	e.nonce = encryptionNonce(e.nonce, prefix, e.counter, last)
+	e.sealed = e.aead.Seal(e.sealed[:0], e.nonce, chunk, e.header)
+	e.out = e.sealed
+
+	if last {
+		e.done = true
+		return nil
+	}
+
+	// carry the lookahead byte into the next chunk
+	e.plain[0] = e.plain[e.chunkSize]
+	e.pending = 1
+
+	e.counter++
+	if e.counter == 0 {
+		return errors.New("too many chunks to encrypt")
+	}
+	return nil
+}
+
+type decryptReader struct {
+	src     io.ReadCloser
+	path    string
+	key     []byte
+	aead    cipher.AEAD
+	header  []byte
+	counter uint32
+	nonce   []byte
+	sealed  []byte
+	pending int
+	opened  []byte
+	out     []byte
+	done    bool
+	err     error
+}
+
+func newDecryptReader(src io.ReadCloser, key []byte, path string) *decryptReader {
+	return &decryptReader{
+		src:  src,
+		path: path,
+		key:  key,
+	}
+}
+
+func (d *decryptReader) readHeader() error {
+	header := make([]byte, encryptionHeaderSize)
+	if _, err := io.ReadFull(d.src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return DecryptionError{Path: d.path, Reason: "header is incomplete"}
+		}
+		return err
+	}
+	if !bytes.Equal(header[:len(encryptionMagic)], []byte(encryptionMagic)) {
+		return DecryptionError{Path: d.path, Reason: "invalid header"}
+	}
+	if version := header[len(encryptionMagic)]; version != encryptionVersion {
+		return DecryptionError{Path: d.path, Reason: fmt.Sprintf("unsupported format version %d", version)}
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[len(encryptionMagic)+1:]))
+	if chunkSize <= 0 || chunkSize > 64*1024*1024 {
+		return DecryptionError{Path: d.path, Reason: fmt.Sprintf("invalid chunk size %d", chunkSize)}
+	}
+
+	aead, err := deriveEncryptionAEAD(d.key, header[len(encryptionMagic)+5:len(encryptionMagic)+5+encryptionSaltSize])
+	if err != nil {
+		return err
+	}
+
+	d.aead = aead
+	d.header = header
+	d.nonce = make([]byte, 0, aead.NonceSize())
+	d.sealed = make([]byte, chunkSize+aead.Overhead()+1)
+	d.opened = make([]byte, 0, chunkSize)
+	return nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.open()
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// open reads the next chunk from the source and decrypts it
+func (d *decryptReader) open() error {
+	if d.aead == nil {
+		if err := d.readHeader(); err != nil {
+			return err
+		}
+	}
+
+	n, err := io.ReadFull(d.src, d.sealed[d.pending:])
+	n += d.pending
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	chunk := d.sealed[:n]
+	if !last {
+		chunk = d.sealed[:len(d.sealed)-1]
+	}
+
+	prefix := d.header[encryptionHeaderSize-encryptionNoncePrefixSize:]
+	d.nonce = encryptionNonce(d.nonce, prefix, d.counter, last)
+	d.opened, err = d.aead.Open(d.opened[:0], d.nonce, chunk, d.header)
+	if err != nil {
+		return DecryptionError{Path: d.path, Reason: fmt.Sprintf("chunk %d failed authentication", d.counter)}
+	}
+	d.out = d.opened
+
+	if last {
+		d.done = true
+		return nil
+	}
+
+	d.sealed[0] = d.sealed[len(d.sealed)-1]
+	d.pending = 1
+
+	d.counter++
+	if d.counter == 0 {
+		return DecryptionError{Path: d.path, Reason: "too many chunks"}
+	}
+	return nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.src.Close()
+}
@@ -0,0 +1,147 @@
+package gdriver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encryptBytes(t *testing.T, key, plain []byte, chunkSize int) []byte {
+	r, err := newEncryptReader(bytes.NewReader(plain), key, chunkSize)
+	require.NoError(t, err)
+	encrypted, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	return encrypted
+}
+
+func decryptBytes(key, encrypted []byte) ([]byte, error) {
+	return ioutil.ReadAll(newDecryptReader(ioutil.NopCloser(bytes.NewReader(encrypted)), key, "File1"))
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	for _, chunkSize := range []int{16, encryptionDefaultChunk} {
+		for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 15} {
+			plain := make([]byte, size)
+			_, err := rand.Read(plain)
+			require.NoError(t, err)
+
+			encrypted := encryptBytes(t, key, plain, chunkSize)
+			require.NotEqual(t, plain, encrypted)
+
+			decrypted, err := decryptBytes(key, encrypted)
+			require.NoError(t, err)
+			require.Equal(t, len(plain), len(decrypted))
+			require.EqualValues(t, plain, decrypted)
+		}
+	}
+}
+
+func TestEncryptionTamperDetection(t *testing.T) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	chunkSize := 16
+	plain := []byte("Hello World, this spans multiple chunks")
+	encrypted := encryptBytes(t, key, plain, chunkSize)
+	sealedChunkSize := chunkSize + 16
+
+	t.Run("modified chunk", func(t *testing.T) {
+		tampered := append([]byte(nil), encrypted...)
+		tampered[encryptionHeaderSize+3] ^= 0xFF
+		_, err := decryptBytes(key, tampered)
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("modified header", func(t *testing.T) {
+		tampered := append([]byte(nil), encrypted...)
+		tampered[encryptionHeaderSize-1] ^= 0xFF
+		_, err := decryptBytes(key, tampered)
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := decryptBytes(key, encrypted[:encryptionHeaderSize+2*sealedChunkSize])
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("appended", func(t *testing.T) {
+		_, err := decryptBytes(key, append(append([]byte(nil), encrypted...), 0))
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("reordered chunks", func(t *testing.T) {
+		tampered := append([]byte(nil), encrypted[:encryptionHeaderSize]...)
+		tampered = append(tampered, encrypted[encryptionHeaderSize+sealedChunkSize:encryptionHeaderSize+2*sealedChunkSize]...)
+		tampered = append(tampered, encrypted[encryptionHeaderSize:encryptionHeaderSize+sealedChunkSize]...)
+		tampered = append(tampered, encrypted[encryptionHeaderSize+2*sealedChunkSize:]...)
+		_, err := decryptBytes(key, tampered)
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey := make([]byte, 16)
+		_, err := rand.Read(otherKey)
+		require.NoError(t, err)
+		_, err = decryptBytes(otherKey, encrypted)
+		require.IsType(t, DecryptionError{}, err)
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		_, err := decryptBytes(key, plain)
+		require.EqualError(t, err, "unable to decrypt `File1': invalid header")
+	})
+}
+
+func TestWithEncryption(t *testing.T) {
+	t.Run("invalid key", func(t *testing.T) {
+		require.Error(t, WithEncryption([]byte("short"))(&GDriver{}))
+	})
+
+	t.Run("put and get", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		require.NoError(t, WithEncryption(key)(driver))
+
+		plain := make([]byte, 3*encryptionDefaultChunk+15)
+		_, err = rand.Read(plain)
+		require.NoError(t, err)
+
+		fi, err := driver.PutFile("Folder1/File1", bytes.NewReader(plain))
+		require.NoError(t, err)
+		require.True(t, fi.IsEncrypted())
+		require.Equal(t, "Folder1/File1", fi.Path())
+
+		fi, r, err := driver.GetFile("Folder1/File1")
+		require.NoError(t, err)
+		require.True(t, fi.IsEncrypted())
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.EqualValues(t, plain, received)
+
+		// without the key the contents cannot be read
+		driver.encryptionKey = nil
+		_, _, err = driver.GetFile("Folder1/File1")
+		require.EqualError(t, err, "`Folder1/File1' is encrypted, use WithEncryption to read it")
+
+		// overwrite without encryption
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		fi, r, err = driver.GetFile("Folder1/File1")
+		require.NoError(t, err)
+		require.False(t, fi.IsEncrypted())
+		received, err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello World", string(received))
+	})
+}
@@ -1,7 +1,10 @@
 package gdriver
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // CallbackError will be returned if the callback returned an error
@@ -9,10 +12,32 @@ type CallbackError struct {
 	NestedError error
 }
 
+// SkipAll can be returned by a callback (e.g. of ListDirectory, ListTrash or Walk) to stop the iteration,
+// no further pages will be fetched and the function returns nil instead of a CallbackError
+var SkipAll = errors.New("skip everything and stop the iteration")
+
 func (e CallbackError) Error() string {
 	return fmt.Sprintf("callback throwed an error: %v", e.NestedError)
 }
 
+// wrapCallbackError wraps an error returned by a callback in a CallbackError, SkipAll will be returned as it is,
+// use it in callbacks of listFiles and the walk, they stop on SkipAll and return nil
+func wrapCallbackError(err error) error {
+	if err == SkipAll {
+		return err
+	}
+	return CallbackError{NestedError: err}
+}
+
+// callbackError returns the error of a listing whose callback returned err,
+// nil for SkipAll and a CallbackError for all other errors
+func callbackError(err error) error {
+	if err == SkipAll {
+		return nil
+	}
+	return CallbackError{NestedError: err}
+}
+
 // FileNotExistError will be thrown if an file was not found
 type FileNotExistError struct {
 	Path string
@@ -31,10 +56,10 @@ func (e FileExistError) Error() string {
 	return fmt.Sprintf("`%s' already exists", e.Path)
 }
 
-// IsNotExist returns true if the error is an FileNotExistError
+// IsNotExist returns true if the error is or wraps an FileNotExistError
 func IsNotExist(e error) bool {
-	_, ok := e.(FileNotExistError)
-	return ok
+	var notExist FileNotExistError
+	return errors.As(e, &notExist)
 }
 
 // IsExist returns true if the error is an FileExistError
@@ -60,3 +85,234 @@ type FileIsNotDirectoryError struct {
 func (e FileIsNotDirectoryError) Error() string {
 	return fmt.Sprintf("`%s' is not a directory", e.Path)
 }
+
+// DecryptionError will be thrown if the contents of an encrypted file could not be decrypted
+type DecryptionError struct {
+	Path   string
+	Reason string
+}
+
+func (e DecryptionError) Error() string {
+	return fmt.Sprintf("unable to decrypt `%s': %s", e.Path, e.Reason)
+}
+
+// ChecksumMismatchError will be thrown if the checksum of a file does not match the expected checksum
+type ChecksumMismatchError struct {
+	Path     string
+	FileID   string
+	Expected []byte
+	Actual   []byte
+	Method   HashMethod
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s checksum mismatch for `%s' (id %s): expected %s, got %s", e.Method, e.Path, e.FileID, e.Expected, e.Actual)
+}
+
+// IsChecksumMismatch returns true if the error is or wraps an ChecksumMismatchError
+func IsChecksumMismatch(e error) bool {
+	var mismatch ChecksumMismatchError
+	return errors.As(e, &mismatch)
+}
+
+// PartialChainError will be thrown by GetParentChain if the parents of a file do not lead to the root directory
+type PartialChainError struct {
+	// ReachedID is the id of the last file that could be reached
+	ReachedID string
+}
+
+func (e PartialChainError) Error() string {
+	return fmt.Sprintf("parent chain does not reach the root directory, reached `%s'", e.ReachedID)
+}
+
+// SharedDriveMoveError will be thrown if a directory cannot be moved because the move crosses a shared drive boundary,
+// use MoveWithCopyFallback to move the directory anyway
+type SharedDriveMoveError struct {
+	Path    string
+	NewPath string
+}
+
+func (e SharedDriveMoveError) Error() string {
+	return fmt.Sprintf("unable to move `%s' to `%s': directories cannot be moved into a shared drive", e.Path, e.NewPath)
+}
+
+// UnsupportedExportFormatError will be thrown if a file cannot be exported to the requested mime type
+type UnsupportedExportFormatError struct {
+	Path         string
+	MimeType     string
+	ValidFormats []string
+}
+
+func (e UnsupportedExportFormatError) Error() string {
+	if len(e.ValidFormats) == 0 {
+		return fmt.Sprintf("`%s' cannot be exported", e.Path)
+	}
+	return fmt.Sprintf("`%s' cannot be exported to `%s', valid formats are: %s", e.Path, e.MimeType, strings.Join(e.ValidFormats, ", "))
+}
+
+// ErrRevisionNotFound will be thrown if a revision of a file does not exist
+type ErrRevisionNotFound struct {
+	RevisionID string
+}
+
+func (e ErrRevisionNotFound) Error() string {
+	return fmt.Sprintf("revision `%s' does not exist", e.RevisionID)
+}
+
+// OwnershipTransferError will be thrown if the ownership of a file cannot be transferred
+type OwnershipTransferError struct {
+	Path   string
+	Reason string
+}
+
+func (e OwnershipTransferError) Error() string {
+	return fmt.Sprintf("unable to transfer ownership of `%s': %s", e.Path, e.Reason)
+}
+
+// SharingRestrictionsError will be thrown if the sharing restrictions of a file cannot be changed
+type SharingRestrictionsError struct {
+	Path   string
+	Reason string
+}
+
+func (e SharingRestrictionsError) Error() string {
+	return fmt.Sprintf("unable to change the sharing restrictions of `%s': %s", e.Path, e.Reason)
+}
+
+// QueryError will be returned if drive rejected a query passed to ListByQuery
+type QueryError struct {
+	Query       string
+	NestedError error
+}
+
+func (e QueryError) Error() string {
+	return fmt.Sprintf("query `%s' failed: %v", e.Query, e.NestedError)
+}
+
+// InvalidNameError will be thrown if a name was rejected by the NameValidator (see WithNameValidator)
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid name `%s': %s", e.Name, e.Reason)
+}
+
+// ErrRevisionConflict will be thrown if a file was changed since the revision passed to IfRevision
+type ErrRevisionConflict struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e ErrRevisionConflict) Error() string {
+	return fmt.Sprintf("`%s' was changed: expected revision `%s', got `%s'", e.Path, e.Expected, e.Actual)
+}
+
+// CASConflictError will be thrown by CompareAndSwap if the md5 checksum of the file is not the expected one
+type CASConflictError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e CASConflictError) Error() string {
+	return fmt.Sprintf("`%s' was changed: expected md5 `%s', got `%s'", e.Path, e.Expected, e.Actual)
+}
+
+// NoRevisionError will be thrown by CompareAndSwap if a file has no md5 checksum and no head revision (e.g. google native files)
+type NoRevisionError struct {
+	Path string
+}
+
+func (e NoRevisionError) Error() string {
+	return fmt.Sprintf("`%s' has no revision that can be compared", e.Path)
+}
+
+// InvalidFolderColorError will be thrown if a color is not part of the folder color palette
+type InvalidFolderColorError struct {
+	Color   string
+	Palette []string
+}
+
+func (e InvalidFolderColorError) Error() string {
+	return fmt.Sprintf("`%s' is not a valid folder color, valid colors are: %s", e.Color, strings.Join(e.Palette, ", "))
+}
+
+// SharedDriveNotEmptyError will be thrown if a shared drive cannot be deleted because it still contains files
+type SharedDriveNotEmptyError struct {
+	ID string
+}
+
+func (e SharedDriveNotEmptyError) Error() string {
+	return fmt.Sprintf("shared drive `%s' is not empty", e.ID)
+}
+
+// PermissionCopyError will be thrown if permissions could not be recreated on a copy, the copy itself was created
+type PermissionCopyError struct {
+	Path string
+	// Errors holds the error of every permission (by the id of the source permission) that could not be recreated
+	Errors map[string]error
+}
+
+func (e PermissionCopyError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return fmt.Sprintf("unable to copy %d permission(s) to `%s': %v", len(ids), e.Path, e.Errors[ids[0]])
+}
+
+// NativeSizeUnknownError will be thrown if the size of a google native file is requested, drive does not report a size for them
+type NativeSizeUnknownError struct {
+	Path string
+}
+
+func (e NativeSizeUnknownError) Error() string {
+	return fmt.Sprintf("the size of the google native file `%s' is unknown", e.Path)
+}
+
+// ErrNotADriveURL will be thrown if a url does not point to a file or folder in drive (see ParseDriveURL)
+type ErrNotADriveURL struct {
+	URL string
+}
+
+func (e ErrNotADriveURL) Error() string {
+	return fmt.Sprintf("`%s' is not a drive url", e.URL)
+}
+
+// AuthError will be thrown by ForceRefreshToken if the token could not be refreshed
+type AuthError struct {
+	Reason string
+}
+
+func (e AuthError) Error() string {
+	return fmt.Sprintf("authentication failed: %s", e.Reason)
+}
+
+// ErrUploadTooLarge will be thrown if the contents of an upload exceed the limit set with WithMaxUploadSize,
+// Size is the size of the contents if it was known before the upload, otherwise -1
+type ErrUploadTooLarge struct {
+	Path  string
+	Limit int64
+	Size  int64
+}
+
+func (e ErrUploadTooLarge) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("`%s' exceeds the maximum upload size of %d bytes", e.Path, e.Limit)
+	}
+	return fmt.Sprintf("`%s' has %d bytes, that exceeds the maximum upload size of %d bytes", e.Path, e.Size, e.Limit)
+}
+
+// UnsafeLocalPathError will be thrown if a remote path cannot be mapped to a local path,
+// because it contains a name like ".." that would point outside of the local directory
+type UnsafeLocalPathError struct {
+	Path string
+}
+
+func (e UnsafeLocalPathError) Error() string {
+	return fmt.Sprintf("`%s' cannot be stored locally, it would point outside of the local directory", e.Path)
+}
@@ -0,0 +1,337 @@
+package gdriver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// ExportMapping maps the mime types of google native files to the mime types they will be exported to,
+// google native files without an entry will be exported to pdf
+type ExportMapping map[string]string
+
+// DefaultExportMapping returns the mapping that is used if no other mapping was passed with WithExportMapping:
+// documents, spreadsheets and presentations will be exported to office formats and drawings to png
+func DefaultExportMapping() ExportMapping {
+	return ExportMapping{
+		mimeTypeGoogleDocument:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		mimeTypeGoogleSpreadsheet:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		mimeTypeGooglePresentation: "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		mimeTypeGoogleDrawing:      "image/png",
+	}
+}
+
+// Set overrides the export format for nativeMimeType and returns the mapping,
+// an empty exportMimeType removes the entry
+//
+// Examples:
+//     DefaultExportMapping().Set("application/vnd.google-apps.document", "application/vnd.oasis.opendocument.text")
+func (m ExportMapping) Set(nativeMimeType, exportMimeType string) ExportMapping {
+	if exportMimeType == "" {
+		delete(m, nativeMimeType)
+		return m
+	}
+	m[nativeMimeType] = exportMimeType
+	return m
+}
+
+// Format returns the mime type google native files of nativeMimeType will be exported to
+func (m ExportMapping) Format(nativeMimeType string) string {
+	if mimeType, ok := m[nativeMimeType]; ok {
+		return mimeType
+	}
+	return mimeTypePDF
+}
+
+// Extension returns the extension (e.g. ".docx") for google native files of nativeMimeType
+func (m ExportMapping) Extension(nativeMimeType string) string {
+	return ExportExtension(m.Format(nativeMimeType))
+}
+
+// ExportExtension returns the file extension for the export format mimeType, or an empty string if unknown
+func ExportExtension(mimeType string) string {
+	return exportExtensions[mimeType]
+}
+
+// WithExportMapping overrides entries of DefaultExportMapping that will be used by ExportFile and ExportDirectory,
+// map a mime type to an empty string to export it to pdf
+func WithExportMapping(mapping ExportMapping) Option {
+	return func(driver *GDriver) error {
+		driver.exportMapping = DefaultExportMapping()
+		for from, to := range mapping {
+			driver.exportMapping.Set(from, to)
+		}
+		return nil
+	}
+}
+
+// getExportMapping returns the mapping passed with WithExportMapping or the DefaultExportMapping
+func (d *GDriver) getExportMapping() ExportMapping {
+	if d.exportMapping == nil {
+		return DefaultExportMapping()
+	}
+	return d.exportMapping
+}
+
+// exportFormat returns the mime type file will be exported to using mapping,
+// an UnsupportedExportFormatError will be returned if drive cannot export the file to that format
+func (d *GDriver) exportFormat(file *FileInfo, mapping ExportMapping) (string, error) {
+	return d.checkExportFormat(file, mapping.Format(file.item.MimeType))
+}
+
+// checkExportFormat returns mimeType if file can be exported to it, otherwise an UnsupportedExportFormatError
+func (d *GDriver) checkExportFormat(file *FileInfo, mimeType string) (string, error) {
+	exportFormats, err := d.ExportFormats()
+	if err != nil {
+		return "", err
+	}
+	validFormats := exportFormats[file.item.MimeType]
+	if !containsString(validFormats, mimeType) {
+		return "", UnsupportedExportFormatError{
+			Path:         file.Path(),
+			MimeType:     mimeType,
+			ValidFormats: validFormats,
+		}
+	}
+	return mimeType, nil
+}
+
+// exportExtensions are the file extensions that will be appended to exported files
+var exportExtensions = map[string]string{
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	mimeTypePDF:            ".pdf",
+	"application/rtf":      ".rtf",
+	"application/zip":      ".zip",
+	"application/epub+zip": ".epub",
+	"application/vnd.google-apps.script+json": ".json",
+	"text/plain":                ".txt",
+	"text/html":                 ".html",
+	"text/csv":                  ".csv",
+	"text/tab-separated-values": ".tsv",
+	"image/png":                 ".png",
+	"image/jpeg":                ".jpg",
+	"image/svg+xml":             ".svg",
+}
+
+type exportOptions struct {
+	reporter ProgressReporter
+}
+
+// ExportOption can be used to pass optional options to ExportDirectory
+type ExportOption func(options *exportOptions)
+
+// ExportReporter reports the progress of every file that ExportDirectory writes to reporter
+func ExportReporter(reporter ProgressReporter) ExportOption {
+	return func(options *exportOptions) {
+		options.reporter = reporter
+	}
+}
+
+// ExportedFile is the outcome of one file of ExportDirectory
+type ExportedFile struct {
+	// Path is the path of the file on the drive
+	Path string
+	// LocalPath is the path of the written file, it is empty if the file could not be exported
+	LocalPath string
+	Err       error
+}
+
+// ExportResult holds the outcomes of ExportDirectory
+type ExportResult struct {
+	Files []ExportedFile
+}
+
+// Failed returns the files that could not be exported
+func (r *ExportResult) Failed() []ExportedFile {
+	var failed []ExportedFile
+	for _, file := range r.Files {
+		if file.Err != nil {
+			failed = append(failed, file)
+		}
+	}
+	return failed
+}
+
+// ExportDirectory writes the directory remotePath with all its descendants to localDir.
+// Binary files are downloaded as they are, google native files are exported to the format of mapping
+// (if mapping is nil the mapping of WithExportMapping is used) and get the extension of the export format appended.
+// Files that exist with the same local name get a suffix (e.g. "Report (1).docx").
+// Files that cannot be downloaded or exported (e.g. because there is no mapping or the file is too large) are reported in the ExportResult,
+// the returned error is only set if the export could not be started or writing to localDir failed.
+// Names (like "..") that would point outside of localDir are rejected with an UnsafeLocalPathError,
+// files are reported in the ExportResult, directories stop the export.
+func (d *GDriver) ExportDirectory(remotePath, localDir string, mapping ExportMapping, opts ...ExportOption) (*ExportResult, error) {
+	var options exportOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if mapping == nil {
+		mapping = d.getExportMapping()
+	}
+
+	dir, err := d.getFile(d.rootNode, remotePath, "files(id,mimeType)")
+	if err != nil {
+		return nil, err
+	}
+	if !dir.IsDir() {
+		return nil, FileIsNotDirectoryError{Path: remotePath}
+	}
+	if err = os.MkdirAll(localDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// collect the tree first, so the totals are known
+	var items []*FileInfo
+	var files int
+	err = d.Walk(remotePath, func(f *FileInfo) error {
+		items = append(items, f)
+		if !f.IsDir() {
+			files++
+		}
+		return nil
+	}, WalkSorted(), walkFields(googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields)))))
+	if err != nil {
+		return nil, err
+	}
+
+	// the local directories are tracked by id, because drive allows multiple files with the same name
+	localDirs := map[string]string{dir.item.Id: localDir}
+	taken := make(map[string]map[string]*FileInfo)
+	localName := func(parentDir, name string, isDir bool) (string, error) {
+		// names like ".." would point outside of localDir
+		if _, err := localPath(parentDir, name); err != nil {
+			return "", err
+		}
+		if taken[parentDir] == nil {
+			taken[parentDir] = make(map[string]*FileInfo)
+		}
+		if _, ok := taken[parentDir][name]; ok {
+			name = conflictName(name, isDir, taken[parentDir])
+		}
+		taken[parentDir][name] = nil
+		return filepath.Join(parentDir, name), nil
+	}
+
+	result := &ExportResult{}
+	report := newBatchReport(files, nil, options.reporter)
+	for _, f := range items {
+		parentDir := localDirs[exportParentID(f)]
+		if f.IsDir() {
+			localPath, err := localName(parentDir, d.pathEscaping.escape(f.Name()), true)
+			if err != nil {
+				return nil, err
+			}
+			if err = os.MkdirAll(localPath, 0755); err != nil {
+				return nil, err
+			}
+			localDirs[f.item.Id] = localPath
+			continue
+		}
+
+		report.started(f.Path())
+		exported := ExportedFile{Path: f.Path()}
+		var size int64
+		var localPath string
+		body, ext, err := d.exportContents(f, mapping)
+		if err == nil {
+			if localPath, err = localName(parentDir, d.pathEscaping.escape(f.Name())+ext, false); err != nil {
+				body.Close() // nolint: errcheck
+			}
+		}
+		if err == nil {
+			var writeErr error
+			size, err, writeErr = writeLocalFile(localPath, body)
+			if writeErr != nil {
+				return nil, writeErr
+			}
+			if err == nil {
+				exported.LocalPath = localPath
+			}
+		}
+		exported.Err = err
+		report.finished(f.Path(), size, err)
+		result.Files = append(result.Files, exported)
+	}
+	return result, nil
+}
+
+// exportParentID returns the id of the parent directory of a file listed by ExportDirectory
+func exportParentID(f *FileInfo) string {
+	if len(f.item.Parents) == 0 {
+		return ""
+	}
+	return f.item.Parents[0]
+}
+
+// exportContents returns the contents of a file for ExportDirectory and the extension that should be appended to its name
+func (d *GDriver) exportContents(f *FileInfo, mapping ExportMapping) (io.ReadCloser, string, error) {
+	if !f.IsGoogleNative() {
+		body, err := d.downloadDecoded(f)
+		return body, "", err
+	}
+
+	body, mimeType, err := d.exportFile(f, "", mapping)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, ExportExtension(mimeType), nil
+}
+
+// localPath returns the local path of the slash separated relativePath in localDir,
+// an UnsafeLocalPathError will be returned if a part of relativePath is empty, "." or ".." or the path is not inside localDir
+func localPath(localDir, relativePath string) (string, error) {
+	for _, part := range strings.Split(relativePath, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsRune(part, filepath.Separator) {
+			return "", UnsafeLocalPathError{Path: relativePath}
+		}
+	}
+	name := filepath.Join(localDir, filepath.FromSlash(relativePath))
+	rel, err := filepath.Rel(localDir, name)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", UnsafeLocalPathError{Path: relativePath}
+	}
+	return name, nil
+}
+
+// writeLocalFile writes the contents of body to the file name and closes body,
+// readErr is set if body could not be read (the incomplete file will be removed), writeErr if the file could not be written
+func writeLocalFile(name string, body io.ReadCloser) (n int64, readErr, writeErr error) {
+	defer body.Close()
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	r := &errorRecordingReader{Reader: body}
+	n, writeErr = io.Copy(f, r)
+	if closeErr := f.Close(); writeErr == nil {
+		writeErr = closeErr
+	}
+	if r.err != nil {
+		os.Remove(name) // nolint: errcheck
+		return n, r.err, nil
+	}
+	return n, nil, writeErr
+}
+
+// errorRecordingReader records the first error (other than io.EOF) of the reader
+type errorRecordingReader struct {
+	io.Reader
+	err error
+}
+
+func (r *errorRecordingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
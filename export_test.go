@@ -0,0 +1,129 @@
+package gdriver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestExportMapping(t *testing.T) {
+	mapping := DefaultExportMapping()
+	require.Equal(t, ".docx", mapping.Extension(mimeTypeGoogleDocument))
+	require.Equal(t, "image/png", mapping.Format(mimeTypeGoogleDrawing))
+	require.Equal(t, mimeTypePDF, mapping.Format("application/vnd.google-apps.jam"))
+	require.Equal(t, ".pdf", mapping.Extension("application/vnd.google-apps.jam"))
+
+	mapping.Set(mimeTypeGoogleDocument, "application/vnd.oasis.opendocument.text").Set(mimeTypeGoogleDrawing, "")
+	require.Equal(t, ".odt", mapping.Extension(mimeTypeGoogleDocument))
+	require.Equal(t, mimeTypePDF, mapping.Format(mimeTypeGoogleDrawing))
+	// the default mapping must not be changed
+	require.Equal(t, "image/png", DefaultExportMapping().Format(mimeTypeGoogleDrawing))
+	require.Empty(t, ExportExtension("application/x-unknown"))
+
+	driver := &GDriver{
+		formats: &formatsCache{
+			exportFormats: map[string][]string{
+				mimeTypeGoogleDocument: {"text/plain", mimeTypePDF},
+				mimeTypeGoogleDrawing:  {"image/png", mimeTypePDF},
+			},
+		},
+	}
+	require.NoError(t, WithExportMapping(ExportMapping{mimeTypeGoogleDocument: "text/plain"})(driver))
+	file := func(mimeType string) *FileInfo {
+		return &FileInfo{item: &drive.File{Name: "File1", MimeType: mimeType}}
+	}
+
+	mimeType, err := driver.exportFormat(file(mimeTypeGoogleDocument), driver.getExportMapping())
+	require.NoError(t, err)
+	require.Equal(t, "text/plain", mimeType)
+	mimeType, err = driver.exportFormat(file(mimeTypeGoogleDrawing), driver.getExportMapping())
+	require.NoError(t, err)
+	require.Equal(t, "image/png", mimeType)
+
+	// forms cannot be exported to pdf
+	_, err = driver.exportFormat(file(mimeTypeGoogleForm), driver.getExportMapping())
+	require.Equal(t, UnsupportedExportFormatError{Path: "File1", MimeType: mimeTypePDF}, err)
+}
+
+func TestExportDirectory(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/Report.docx", "Hello Universe")
+	_, err := driver.Import(ImportHTMLToDoc, "Folder1/Folder2/Report", bytes.NewBufferString("<p>Hello World</p>"))
+	require.NoError(t, err)
+	_, err = driver.Import(ImportCSVToSheet, "Folder1/Sheet", bytes.NewBufferString("a,b\n1,2\n"))
+	require.NoError(t, err)
+
+	localDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(localDir)
+
+	// spreadsheets cannot be exported to this format
+	result, err := driver.ExportDirectory("Folder1", localDir, DefaultExportMapping().Set(mimeTypeGoogleSpreadsheet, "image/x-unknown"))
+	require.NoError(t, err)
+	require.Len(t, result.Files, 4)
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	require.Equal(t, "Folder1/Sheet", failed[0].Path)
+	require.IsType(t, UnsupportedExportFormatError{}, failed[0].Err)
+
+	contents, err := ioutil.ReadFile(filepath.Join(localDir, "File1"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(contents))
+	// the entries are visited sorted by name, so the exported document comes first
+	contents, err = ioutil.ReadFile(filepath.Join(localDir, "Folder2", "Report.docx"))
+	require.NoError(t, err)
+	// docx files are zip archives
+	require.True(t, bytes.HasPrefix(contents, []byte("PK")))
+
+	// the binary file collides with the exported document
+	contents, err = ioutil.ReadFile(filepath.Join(localDir, "Folder2", "Report (1).docx"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello Universe", string(contents))
+}
+
+func TestLocalPath(t *testing.T) {
+	localDir := filepath.Join("tmp", "export")
+	name, err := localPath(localDir, "Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(localDir, "Folder1", "File1"), name)
+
+	for _, relativePath := range []string{"", ".", "..", "../File1", "Folder1/../../File1", "Folder1//File1", "Folder1/."} {
+		_, err = localPath(localDir, relativePath)
+		require.Equal(t, UnsafeLocalPathError{Path: relativePath}, err, relativePath)
+	}
+}
+
+func TestExportDirectoryUnsafeNames(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/..", "Hello World")
+	newFile(t, driver, "Folder2/../File1", "Hello Universe")
+
+	parentDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(parentDir)
+	localDir := filepath.Join(parentDir, "Export")
+
+	// files are reported
+	result, err := driver.ExportDirectory("Folder1", localDir, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Files, 1)
+	require.Equal(t, UnsafeLocalPathError{Path: ".."}, result.Files[0].Err)
+
+	// directories stop the export
+	_, err = driver.ExportDirectory("Folder2", localDir, nil)
+	require.Equal(t, UnsafeLocalPathError{Path: ".."}, err)
+
+	entries, err := ioutil.ReadDir(parentDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -27,12 +27,7 @@ func (f *readFile) Info() *FileInfo {
 func (f *readFile) getReader() error {
 	var lastErr error
 	f.once.Do(func() {
-		response, err := f.Driver.srv.Files.Get(f.item.Id).Download()
-		if err != nil {
-			lastErr = err
-			return
-		}
-		f.reader = response.Body
+		f.reader, lastErr = f.Driver.downloadDecoded(f.FileInfo)
 	})
 	return lastErr
 }
@@ -48,6 +43,44 @@ func (f *readFile) Read(p []byte) (int, error) {
 	return f.reader.Read(p)
 }
 
+// WriteTo writes the contents to w using a buffer of the size set by WithCopyBufferSize and reports the progress to
+// the function set by WithTransferProgress, n is the amount of bytes that were written to w even if an error occurred
+func (f *readFile) WriteTo(w io.Writer) (n int64, err error) {
+	if err = f.getReader(); err != nil {
+		return 0, err
+	}
+	progress := f.Driver.transferProgress(f.Path(), f.contentSize())
+	buf := make([]byte, f.Driver.getCopyBufferSize())
+	for {
+		nr, readErr := f.reader.Read(buf)
+		if nr > 0 {
+			nw, writeErr := w.Write(buf[:nr])
+			n += int64(nw)
+			progress(n)
+			if writeErr != nil {
+				return n, writeErr
+			}
+			if nw != nr {
+				return n, io.ErrShortWrite
+			}
+		}
+		if readErr == io.EOF {
+			return n, nil
+		}
+		if readErr != nil {
+			return n, readErr
+		}
+	}
+}
+
+// contentSize returns the size of the decoded contents, or -1 if it is unknown
+func (f *readFile) contentSize() int64 {
+	if f.IsGoogleNative() || (f.IsEncrypted() && !f.IsCompressed()) {
+		return -1
+	}
+	return f.OriginalSize()
+}
+
 func (f *readFile) Close() error {
 	if err := f.getReader(); err != nil {
 		return err
@@ -59,10 +92,13 @@ type writeFile struct {
 	Driver *GDriver
 	Path   string
 	*FileInfo
+	options  []PutOption
 	writer   *io.PipeWriter
 	mu       sync.Mutex
 	doneChan chan struct{}
 	putError error
+	// uploaded is set if the contents were uploaded by ReadFrom
+	uploaded bool
 }
 
 func (f *writeFile) Info() *FileInfo {
@@ -71,18 +107,20 @@ func (f *writeFile) Info() *FileInfo {
 
 func (f *writeFile) getWriter() error {
 	f.mu.Lock()
+	if f.uploaded {
+		f.mu.Unlock()
+		return errors.New("the contents were already written with ReadFrom")
+	}
 	if f.doneChan == nil {
-		var reader io.Reader
+		var reader *io.PipeReader
 		// open a pipe and use the writer part for Write()
 		reader, f.writer = io.Pipe()
 		// the channel is used to notify the Close() or Write() function if something goes wrong
 		f.doneChan = make(chan struct{})
 		go func() {
-			if f.FileInfo == nil {
-				f.FileInfo, f.putError = f.Driver.PutFile(f.Path, reader)
-			} else {
-				f.putError = f.Driver.updateFileContents(f.FileInfo.item.Id, reader)
-			}
+			f.putError = f.upload(reader)
+			// unblock pending writes if the upload stopped reading
+			reader.CloseWithError(f.putError) // nolint: errcheck
 			f.doneChan <- struct{}{}
 		}()
 	}
@@ -91,6 +129,22 @@ func (f *writeFile) getWriter() error {
 	return err
 }
 
+// upload uploads the contents of r to the file
+func (f *writeFile) upload(r io.Reader) error {
+	if f.FileInfo == nil {
+		var err error
+		f.FileInfo, err = f.Driver.PutFile(f.Path, r, f.options...)
+		return err
+	}
+	var options putOptions
+	for _, opt := range f.options {
+		opt(&options)
+	}
+	err := f.Driver.updateFileContents(f.FileInfo, r, options.revision)
+	f.Driver.audit("PutFile", f.Path, "")(&err)
+	return err
+}
+
 func (f *writeFile) Write(p []byte) (int, error) {
 	if err := f.getWriter(); err != nil {
 		return 0, err
@@ -98,11 +152,51 @@ func (f *writeFile) Write(p []byte) (int, error) {
 	return f.writer.Write(p)
 }
 
+// ReadFrom uploads the contents of r, if nothing was written before r is passed to the upload directly.
+// If r is an os.File or an io.Seeker its size will be announced to drive and used as the total for WithTransferProgress.
+// The file cannot be written after ReadFrom uploaded its contents.
+func (f *writeFile) ReadFrom(r io.Reader) (int64, error) {
+	f.mu.Lock()
+	if f.uploaded || f.doneChan != nil {
+		f.mu.Unlock()
+		// the upload was already started by Write, continue it
+		if err := f.getWriter(); err != nil {
+			return 0, err
+		}
+		return io.Copy(f.writer, r)
+	}
+	f.uploaded = true
+	f.mu.Unlock()
+
+	size := readerSize(r)
+	if size < 0 {
+		size = seekerSize(r)
+	}
+	counter := &progressReader{
+		Reader:   r,
+		size:     size,
+		progress: f.Driver.transferProgress(f.Path, size),
+	}
+	var body io.Reader = counter
+	if rs, ok := r.(io.ReadSeeker); ok {
+		// keep r seekable, so the upload can rewind it (see WithUploadSpool)
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err == nil {
+			body = &progressReadSeeker{progressReader: counter, seeker: rs, start: start}
+		}
+	}
+	f.putError = f.upload(body)
+	return counter.n, f.putError
+}
+
 func (f *writeFile) Read(p []byte) (int, error) {
 	return 0, errors.New("open the file with O_RDONLY for writing")
 }
 
 func (f *writeFile) Close() error {
+	if f.writer == nil {
+		return f.putError
+	}
 	closeErr := f.writer.Close()
 	if f.doneChan != nil {
 		<-f.doneChan
@@ -112,3 +206,63 @@ func (f *writeFile) Close() error {
 	}
 	return closeErr
 }
+
+// seekerSize returns the amount of bytes that are left to read in r if it is an io.Seeker, otherwise -1
+func seekerSize(r io.Reader) int64 {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return -1
+	}
+	if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+		return -1
+	}
+	return end - offset
+}
+
+// progressReader counts the bytes that were read and reports them
+type progressReader struct {
+	io.Reader
+	n        int64
+	size     int64
+	progress func(transferred int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		r.progress(r.n)
+	}
+	return n, err
+}
+
+// Size implements SizedReader, it returns -1 if the size is unknown
+func (r *progressReader) Size() int64 {
+	if r.size < 0 {
+		return -1
+	}
+	return r.size - r.n
+}
+
+// progressReadSeeker is a progressReader for an io.ReadSeeker, start is the initial position of the reader
+type progressReadSeeker struct {
+	*progressReader
+	seeker io.Seeker
+	start  int64
+}
+
+func (r *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.seeker.Seek(offset, whence)
+	if err == nil {
+		r.n = pos - r.start
+	}
+	return pos, err
+}
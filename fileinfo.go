@@ -3,20 +3,25 @@ package gdriver
 import (
 	"fmt"
 	"path"
+	"strings"
 	"time"
 
 	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 )
 
 // FileInfo represents file information for a file or directory
 type FileInfo struct {
-	item       *drive.File
-	parentPath string
+	item         *drive.File
+	parentPath   string
+	pathEscaping PathEscaping
+	// isRoot is set for the root directory of the driver, its path is empty
+	isRoot bool
 }
 
-// Name returns the name of the file or directory
+// Name returns the name of the file or directory as it is stored in drive, it can contain path separators (see WithPathEscaping)
 func (i *FileInfo) Name() string {
-	return sanitizeName(i.item.Name)
+	return i.item.Name
 }
 
 // ParentPath returns the parent path of the file or directory
@@ -24,9 +29,26 @@ func (i *FileInfo) ParentPath() string {
 	return i.parentPath
 }
 
-// Path returns the full path to this file or directory
+// Path returns the full path to this file or directory, path separators in the name are escaped (see WithPathEscaping)
 func (i *FileInfo) Path() string {
-	return path.Join(i.parentPath, i.Name())
+	if i.isRoot {
+		return ""
+	}
+	return path.Join(i.parentPath, i.pathEscaping.escape(i.item.Name))
+}
+
+// HeadRevisionID returns the id of the current revision of the contents, it is empty for directories and google native files
+func (i *FileInfo) HeadRevisionID() string {
+	return i.item.HeadRevisionId
+}
+
+// folderColorField requests the field that is returned by FolderColor
+const folderColorField = "folderColorRgb"
+
+// FolderColor returns the color of a directory (e.g. "#ac725e"), it is empty for files
+// and if it was not requested (see StatFolderColor, ListFolderColor and WalkFolderColor)
+func (i *FileInfo) FolderColor() string {
+	return i.item.FolderColorRgb
 }
 
 // Size returns the bytes for this file
@@ -34,8 +56,11 @@ func (i *FileInfo) Size() int64 {
 	return i.item.Size
 }
 
-// CreationTime returns the time when this file was created
+// CreationTime returns the time when this file was created, or the zero time if it was not requested (see WithMinimalFields)
 func (i *FileInfo) CreationTime() time.Time {
+	if i.item.CreatedTime == "" {
+		return time.Time{}
+	}
 	t, err := time.Parse(time.RFC3339, i.item.CreatedTime)
 	if err != nil {
 		panic(fmt.Errorf("unable to parse CreatedTime (`%s'): %v", i.item.CreatedTime, err))
@@ -43,8 +68,11 @@ func (i *FileInfo) CreationTime() time.Time {
 	return t
 }
 
-// ModifiedTime returns the time when this file was modified
+// ModifiedTime returns the time when this file was modified, or the zero time if it was not requested (see WithMinimalFields)
 func (i *FileInfo) ModifiedTime() time.Time {
+	if i.item.ModifiedTime == "" {
+		return time.Time{}
+	}
 	t, err := time.Parse(time.RFC3339, i.item.ModifiedTime)
 	if err != nil {
 		panic(fmt.Errorf("unable to parse ModifiedTime (`%s'): %v", i.item.ModifiedTime, err))
@@ -52,26 +80,249 @@ func (i *FileInfo) ModifiedTime() time.Time {
 	return t
 }
 
+// TrashedTime returns the time when this file was trashed, it is only set for files listed by ListTrash
+func (i *FileInfo) TrashedTime() time.Time {
+	if i.item.TrashedTime == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, i.item.TrashedTime)
+	if err != nil {
+		panic(fmt.Errorf("unable to parse TrashedTime (`%s'): %v", i.item.TrashedTime, err))
+	}
+	return t
+}
+
+// ExplicitlyTrashed returns true if this file was trashed explicitly and not because one of its parents was trashed,
+// it is only set for files listed by ListTrash
+func (i *FileInfo) ExplicitlyTrashed() bool {
+	return i.item.ExplicitlyTrashed
+}
+
 // IsDir returns true if this file is a directory
 func (i *FileInfo) IsDir() bool {
 	return i.item.MimeType == mimeTypeFolder
 }
 
+// IsGoogleDoc returns true if this file is a Google Docs document
+func (i *FileInfo) IsGoogleDoc() bool {
+	return i.item.MimeType == mimeTypeGoogleDocument
+}
+
+// IsGoogleSheet returns true if this file is a Google Sheets spreadsheet
+func (i *FileInfo) IsGoogleSheet() bool {
+	return i.item.MimeType == mimeTypeGoogleSpreadsheet
+}
+
+// IsGoogleSlide returns true if this file is a Google Slides presentation
+func (i *FileInfo) IsGoogleSlide() bool {
+	return i.item.MimeType == mimeTypeGooglePresentation
+}
+
+// IsGoogleForm returns true if this file is a Google Forms form
+func (i *FileInfo) IsGoogleForm() bool {
+	return i.item.MimeType == mimeTypeGoogleForm
+}
+
+// IsGoogleNative returns true if this file has a native Google type (including directories),
+// the contents of these files cannot be downloaded with GetFile
+func (i *FileInfo) IsGoogleNative() bool {
+	return strings.HasPrefix(i.item.MimeType, mimeTypeGooglePrefix)
+}
+
+// Capabilities describes what the current user is allowed to do with a file or directory
+type Capabilities struct {
+	CanEdit     bool
+	CanShare    bool
+	CanDelete   bool
+	CanTrash    bool
+	CanDownload bool
+	CanRename   bool
+}
+
+// withFileFields adds the fields extra to the fields of a listing, which always have the form files(...)
+func withFileFields(fields googleapi.Field, extra string) googleapi.Field {
+	return googleapi.Field(strings.TrimSuffix(string(fields), ")") + "," + extra + ")")
+}
+
+// optionalFields are the fields that Stat, ListDirectory and Walk only request if an option asks for them
+type optionalFields struct {
+	capabilities        bool
+	sharingRestrictions bool
+	folderColor         bool
+}
+
+// requested returns true if any optional field was requested
+func (o optionalFields) requested() bool {
+	return o.capabilities || o.sharingRestrictions || o.folderColor
+}
+
+// apply adds the requested optional fields to fields
+func (o optionalFields) apply(fields googleapi.Field) googleapi.Field {
+	if o.capabilities {
+		fields = withFileFields(fields, capabilitiesField)
+	}
+	if o.sharingRestrictions {
+		fields = withFileFields(fields, sharingRestrictionsFields)
+	}
+	if o.folderColor {
+		fields = withFileFields(fields, folderColorField)
+	}
+	return fields
+}
+
+// capabilitiesField requests the fields that are returned by Capabilities
+const capabilitiesField = "capabilities(canEdit,canShare,canDelete,canTrash,canDownload,canRename)"
+
+// Capabilities returns what the current user is allowed to do with this file or directory,
+// all capabilities are false if they were not requested (see StatCapabilities, ListCapabilities and WalkCapabilities)
+func (i *FileInfo) Capabilities() Capabilities {
+	if i.item.Capabilities == nil {
+		return Capabilities{}
+	}
+	return Capabilities{
+		CanEdit:     i.item.Capabilities.CanEdit,
+		CanShare:    i.item.Capabilities.CanShare,
+		CanDelete:   i.item.Capabilities.CanDelete,
+		CanTrash:    i.item.Capabilities.CanTrash,
+		CanDownload: i.item.Capabilities.CanDownload,
+		CanRename:   i.item.Capabilities.CanRename,
+	}
+}
+
 // DriveFile returns the underlaying drive.File
 func (i *FileInfo) DriveFile() *drive.File {
 	return i.item
 }
 
-func sanitizeName(s string) string {
-	runes := []rune(s)
-	for i, r := range runes {
-		if isPathSeperator(r) || r == '\'' {
-			runes[i] = '-'
+func isPathSeperator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// Clone returns a deep copy of the FileInfo, changes to the copy (including its drive.File) do not affect the original
+func (i *FileInfo) Clone() *FileInfo {
+	clone := *i
+	if i.item != nil {
+		clone.item = cloneFile(i.item)
+	}
+	return &clone
+}
+
+// cloneFile copies the nested structs, slices and maps of a drive.File
+func cloneFile(f *drive.File) *drive.File {
+	c := *f
+	c.AppProperties = cloneStringMap(f.AppProperties)
+	c.ExportLinks = cloneStringMap(f.ExportLinks)
+	c.Properties = cloneStringMap(f.Properties)
+	c.Parents = cloneStrings(f.Parents)
+	c.PermissionIds = cloneStrings(f.PermissionIds)
+	c.Spaces = cloneStrings(f.Spaces)
+	c.ForceSendFields = cloneStrings(f.ForceSendFields)
+	c.NullFields = cloneStrings(f.NullFields)
+	if f.Capabilities != nil {
+		capabilities := *f.Capabilities
+		capabilities.ForceSendFields = cloneStrings(capabilities.ForceSendFields)
+		capabilities.NullFields = cloneStrings(capabilities.NullFields)
+		c.Capabilities = &capabilities
+	}
+	if f.ContentHints != nil {
+		hints := *f.ContentHints
+		if hints.Thumbnail != nil {
+			thumbnail := *hints.Thumbnail
+			thumbnail.ForceSendFields = cloneStrings(thumbnail.ForceSendFields)
+			thumbnail.NullFields = cloneStrings(thumbnail.NullFields)
+			hints.Thumbnail = &thumbnail
+		}
+		hints.ForceSendFields = cloneStrings(hints.ForceSendFields)
+		hints.NullFields = cloneStrings(hints.NullFields)
+		c.ContentHints = &hints
+	}
+	if f.ImageMediaMetadata != nil {
+		metadata := *f.ImageMediaMetadata
+		if metadata.Location != nil {
+			location := *metadata.Location
+			location.ForceSendFields = cloneStrings(location.ForceSendFields)
+			location.NullFields = cloneStrings(location.NullFields)
+			metadata.Location = &location
+		}
+		metadata.ForceSendFields = cloneStrings(metadata.ForceSendFields)
+		metadata.NullFields = cloneStrings(metadata.NullFields)
+		c.ImageMediaMetadata = &metadata
+	}
+	if f.VideoMediaMetadata != nil {
+		metadata := *f.VideoMediaMetadata
+		metadata.ForceSendFields = cloneStrings(metadata.ForceSendFields)
+		metadata.NullFields = cloneStrings(metadata.NullFields)
+		c.VideoMediaMetadata = &metadata
+	}
+	c.LastModifyingUser = cloneUser(f.LastModifyingUser)
+	c.SharingUser = cloneUser(f.SharingUser)
+	c.TrashingUser = cloneUser(f.TrashingUser)
+	if f.Owners != nil {
+		c.Owners = make([]*drive.User, len(f.Owners))
+		for j, owner := range f.Owners {
+			c.Owners[j] = cloneUser(owner)
+		}
+	}
+	if f.Permissions != nil {
+		c.Permissions = make([]*drive.Permission, len(f.Permissions))
+		for j, permission := range f.Permissions {
+			c.Permissions[j] = clonePermission(permission)
+		}
+	}
+	return &c
+}
+
+func cloneUser(u *drive.User) *drive.User {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	c.ForceSendFields = cloneStrings(u.ForceSendFields)
+	c.NullFields = cloneStrings(u.NullFields)
+	return &c
+}
+
+func clonePermission(p *drive.Permission) *drive.Permission {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	if p.TeamDrivePermissionDetails != nil {
+		c.TeamDrivePermissionDetails = make([]*drive.PermissionTeamDrivePermissionDetails, len(p.TeamDrivePermissionDetails))
+		for j, details := range p.TeamDrivePermissionDetails {
+			if details == nil {
+				continue
+			}
+			d := *details
+			d.ForceSendFields = cloneStrings(details.ForceSendFields)
+			d.NullFields = cloneStrings(details.NullFields)
+			c.TeamDrivePermissionDetails[j] = &d
 		}
 	}
-	return string(runes)
+	c.ForceSendFields = cloneStrings(p.ForceSendFields)
+	c.NullFields = cloneStrings(p.NullFields)
+	return &c
 }
 
-func isPathSeperator(r rune) bool {
-	return r == '/' || r == '\\'
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// setParentPath changes the parent path of the FileInfo, e.g. after it was moved
+func (i *FileInfo) setParentPath(parentPath string) {
+	i.parentPath = parentPath
 }
@@ -0,0 +1,65 @@
+package gdriver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestFileInfoClone(t *testing.T) {
+	original := &FileInfo{
+		item: &drive.File{
+			Id:            "1",
+			Name:          "File1",
+			Parents:       []string{"root"},
+			AppProperties: map[string]string{"key": "value"},
+			Capabilities:  &drive.FileCapabilities{CanEdit: true},
+			Owners:        []*drive.User{{DisplayName: "Owner"}},
+			Permissions:   []*drive.Permission{{Id: "anyoneWithLink", Type: "anyone"}},
+			Size:          11,
+		},
+		parentPath: "Folder1",
+	}
+
+	clone := original.Clone()
+	require.Equal(t, original, clone)
+	require.False(t, original.item == clone.item)
+
+	clone.setParentPath("Folder2")
+	clone.item.Name = "File2"
+	clone.item.Parents[0] = "2"
+	clone.item.AppProperties["key"] = "changed"
+	clone.item.Capabilities.CanEdit = false
+	clone.item.Owners[0].DisplayName = "Changed"
+	clone.item.Permissions[0].Type = "user"
+
+	require.Equal(t, "Folder2/File2", clone.Path())
+	require.Equal(t, "Folder1/File1", original.Path())
+	require.Equal(t, []string{"root"}, original.item.Parents)
+	require.Equal(t, map[string]string{"key": "value"}, original.item.AppProperties)
+	require.True(t, original.Capabilities().CanEdit)
+	require.Equal(t, "Owner", original.item.Owners[0].DisplayName)
+	require.Equal(t, "anyone", original.item.Permissions[0].Type)
+
+	require.NotPanics(t, func() {
+		(&FileInfo{}).Clone()
+	})
+}
+
+func TestStatReturnsCopyOfCache(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	require.NoError(t, driver.BackfillMetadataCache(""))
+
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	fi.setParentPath("Folder2")
+	fi.item.Name = "File2"
+
+	fi, err = driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/File1", fi.Path())
+}
@@ -0,0 +1,37 @@
+package gdriver
+
+import "fmt"
+
+// FolderCreationPolicy controls whether PutFile and Touch create missing parent directories
+type FolderCreationPolicy int
+
+const (
+	// FolderCreationAuto creates missing parent directories (default)
+	FolderCreationAuto FolderCreationPolicy = iota
+	// FolderCreationFail returns a FileNotExistError if a parent directory is missing
+	FolderCreationFail
+)
+
+func (p FolderCreationPolicy) String() string {
+	switch p {
+	case FolderCreationAuto:
+		return "auto"
+	case FolderCreationFail:
+		return "fail"
+	default:
+		return fmt.Sprintf("FolderCreationPolicy(%d)", int(p))
+	}
+}
+
+// WithFolderCreation sets the FolderCreationPolicy, it defaults to FolderCreationAuto
+func WithFolderCreation(policy FolderCreationPolicy) Option {
+	return func(driver *GDriver) error {
+		switch policy {
+		case FolderCreationAuto, FolderCreationFail:
+		default:
+			return fmt.Errorf("unknown folder creation policy %s", policy)
+		}
+		driver.folderCreation = policy
+		return nil
+	}
+}
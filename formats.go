@@ -0,0 +1,169 @@
+package gdriver
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// formatsCache holds the export and import formats and the folder color palette reported by drive
+type formatsCache struct {
+	mu                 sync.Mutex
+	exportFormats      map[string][]string
+	importFormats      map[string][]string
+	folderColorPalette []string
+}
+
+// ExportFormats returns the formats google native files can be exported to,
+// the keys are the mime types of the native files, the values the mime types they can be exported to.
+// The formats are fetched once and cached, use RefreshFormats to update them.
+func (d *GDriver) ExportFormats() (map[string][]string, error) {
+	if err := d.loadFormats(false); err != nil {
+		return nil, err
+	}
+	d.formats.mu.Lock()
+	defer d.formats.mu.Unlock()
+	return d.formats.exportFormats, nil
+}
+
+// ImportFormats returns the formats that can be converted to google native files,
+// the keys are the mime types of the uploaded files, the values the google native mime types they can be converted to.
+// The formats are fetched once and cached, use RefreshFormats to update them.
+func (d *GDriver) ImportFormats() (map[string][]string, error) {
+	if err := d.loadFormats(false); err != nil {
+		return nil, err
+	}
+	d.formats.mu.Lock()
+	defer d.formats.mu.Unlock()
+	return d.formats.importFormats, nil
+}
+
+// FolderColorPalette returns the colors (e.g. "#ac725e") that can be used for SetFolderColor.
+// The palette is fetched once and cached, use RefreshFormats to update it.
+func (d *GDriver) FolderColorPalette() ([]string, error) {
+	if err := d.loadFormats(false); err != nil {
+		return nil, err
+	}
+	d.formats.mu.Lock()
+	defer d.formats.mu.Unlock()
+	return d.formats.folderColorPalette, nil
+}
+
+// RefreshFormats fetches the export and import formats and the folder color palette again
+func (d *GDriver) RefreshFormats() error {
+	return d.loadFormats(true)
+}
+
+func (d *GDriver) loadFormats(refresh bool) error {
+	d.formats.mu.Lock()
+	defer d.formats.mu.Unlock()
+	if !refresh && d.formats.exportFormats != nil {
+		d.metrics.cacheHit()
+		return nil
+	}
+
+	about, err := d.srv.About.Get().Fields("exportFormats", "importFormats", "folderColorPalette").Do()
+	if err != nil {
+		return err
+	}
+	d.formats.exportFormats = about.ExportFormats
+	d.formats.importFormats = about.ImportFormats
+	d.formats.folderColorPalette = about.FolderColorPalette
+	if d.formats.exportFormats == nil {
+		d.formats.exportFormats = make(map[string][]string)
+	}
+	if d.formats.importFormats == nil {
+		d.formats.importFormats = make(map[string][]string)
+	}
+	return nil
+}
+
+// ExportFile exports a google native file (e.g. a google document) to mimeType,
+// if mimeType is empty the format of the mapping passed with WithExportMapping (or DefaultExportMapping) is used.
+// An UnsupportedExportFormatError will be returned if the file cannot be exported to mimeType
+//
+// Examples:
+//     ExportFile("Document", "application/pdf")
+//     ExportFile("Document", "")
+func (d *GDriver) ExportFile(path, mimeType string) (*FileInfo, io.ReadCloser, error) {
+	file, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.IsDir() {
+		return nil, nil, FileIsDirectoryError{Path: path}
+	}
+
+	body, _, err := d.exportFile(file, mimeType, d.getExportMapping())
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, body, nil
+}
+
+// exportFile exports the google native file to mimeType and returns the contents and the mime type,
+// if mimeType is empty the format of mapping is used
+func (d *GDriver) exportFile(file *FileInfo, mimeType string, mapping ExportMapping) (io.ReadCloser, string, error) {
+	var err error
+	if mimeType == "" {
+		mimeType, err = d.exportFormat(file, mapping)
+	} else {
+		mimeType, err = d.checkExportFormat(file, mimeType)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	response, err := d.srv.Files.Export(file.item.Id, mimeType).Download()
+	if err != nil {
+		return nil, "", err
+	}
+	return response.Body, mimeType, nil
+}
+
+// GetFileSizeWithoutDownloading returns the size drive reports for a file without downloading it.
+// Drive does not report a size for google native files, -1 and a NativeSizeUnknownError are returned for them,
+// use GetExportSize to get the size of their exported form.
+func (d *GDriver) GetFileSizeWithoutDownloading(path string) (int64, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id,name,mimeType,size)")
+	if err != nil {
+		return 0, err
+	}
+	if file.IsDir() {
+		return 0, FileIsDirectoryError{Path: path}
+	}
+	if file.IsGoogleNative() {
+		return -1, NativeSizeUnknownError{Path: path}
+	}
+	return file.Size(), nil
+}
+
+// GetExportSize returns the size of a google native file exported to mimeType,
+// drive does not report the size of exports, so the file is exported and the bytes are counted.
+// If mimeType is empty the format of the mapping passed with WithExportMapping (or DefaultExportMapping) is used.
+//
+// Examples:
+//     GetExportSize("Document", "application/pdf")
+func (d *GDriver) GetExportSize(path, mimeType string) (int64, error) {
+	_, r, err := d.ExportFile(path, mimeType)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(ioutil.Discard, r)
+	if cerr := r.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
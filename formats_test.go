@@ -0,0 +1,98 @@
+package gdriver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestFormats(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/drive/v3/about", r.URL.Path)
+		requests++
+		fmt.Fprintf(w, `{"exportFormats":{"%s":["text/plain","application/pdf"]},"importFormats":{"text/csv":["%s"]},"folderColorPalette":["#ac725e","#d06b64"]}`, mimeTypeGoogleDocument, mimeTypeGoogleSpreadsheet)
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{
+		srv:     srv,
+		formats: &formatsCache{},
+	}
+
+	exportFormats, err := driver.ExportFormats()
+	require.NoError(t, err)
+	require.Equal(t, []string{"text/plain", "application/pdf"}, exportFormats[mimeTypeGoogleDocument])
+
+	importFormats, err := driver.ImportFormats()
+	require.NoError(t, err)
+	require.Equal(t, []string{mimeTypeGoogleSpreadsheet}, importFormats["text/csv"])
+
+	palette, err := driver.FolderColorPalette()
+	require.NoError(t, err)
+	require.Equal(t, []string{"#ac725e", "#d06b64"}, palette)
+	require.Equal(t, InvalidFolderColorError{Color: "#000000", Palette: palette}, driver.SetFolderColor("Folder1", "#000000"))
+	require.Equal(t, 1, requests)
+
+	require.NoError(t, driver.RefreshFormats())
+	require.Equal(t, 2, requests)
+}
+
+func TestExportFile(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	_, err := driver.srv.Files.Create(&drive.File{
+		Name:     "Document1",
+		MimeType: mimeTypeGoogleDocument,
+		Parents:  []string{driver.rootNode.item.Id},
+	}).Do()
+	require.NoError(t, err)
+
+	_, r, err := driver.ExportFile("Document1", "text/plain")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	_, _, err = driver.ExportFile("Document1", "image/x-unknown")
+	require.IsType(t, UnsupportedExportFormatError{}, err)
+	require.Contains(t, err.(UnsupportedExportFormatError).ValidFormats, "text/plain")
+}
+
+func TestGetFileSize(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	_, err := driver.srv.Files.Create(&drive.File{
+		Name:     "Document1",
+		MimeType: mimeTypeGoogleDocument,
+		Parents:  []string{driver.rootNode.item.Id},
+	}).Do()
+	require.NoError(t, err)
+
+	size, err := driver.GetFileSizeWithoutDownloading("Folder1/File1")
+	require.NoError(t, err)
+	require.EqualValues(t, 11, size)
+
+	size, err = driver.GetFileSizeWithoutDownloading("Document1")
+	require.IsType(t, NativeSizeUnknownError{}, err)
+	require.EqualValues(t, -1, size)
+
+	_, err = driver.GetFileSizeWithoutDownloading("Folder1")
+	require.IsType(t, FileIsDirectoryError{}, err)
+
+	_, err = driver.GetExportSize("Document1", "text/plain")
+	require.NoError(t, err)
+	_, err = driver.GetExportSize("Document1", "image/x-unknown")
+	require.IsType(t, UnsupportedExportFormatError{}, err)
+}
@@ -1,21 +1,59 @@
 package gdriver
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/oauth2"
 	drive "google.golang.org/api/drive/v3"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 // GDriver can be used to access google drive in a traditional file-folder-path pattern
 type GDriver struct {
-	srv      *drive.Service
-	rootNode *FileInfo
+	client                *http.Client
+	srv                   *drive.Service
+	rootNode              *FileInfo
+	encryptionKey         []byte
+	convertToGoogleFormat bool
+	mimeTypeMapping       map[string]string
+	compression           *compressionOptions
+	uploadSessionFunc     UploadSessionFunc
+	auditLog              *auditLog
+	traversalConcurrency  int
+	formats               *formatsCache
+	nameValidator         NameValidator
+	userAgent             string
+	exportMapping         ExportMapping
+	uploadSpool           *uploadSpool
+	copyBufferSize        int
+	transferProgressFunc  TransferProgressFunc
+	folderCreation        FolderCreationPolicy
+	minimalFields         bool
+	enforceSingleParent   bool
+	metrics               *DriveMetrics
+	pathEscaping          PathEscaping
+	tokenSource           oauth2.TokenSource
+	simpleUploadThreshold int64
+	metadataCache         *metadataCache
+	maxUploadSize         int64
+	rebuildPolicy         RebuildPolicy
+	// clock returns the current time (if not nil), it is used in tests
+	clock func() time.Time
+	// beforeRevisionCheck is called (if not nil) before the revision is checked for IfRevision, it is used in tests
+	beforeRevisionCheck func()
 }
 
 // HashMethod is the hashing method to use for GetFileHash
@@ -26,19 +64,32 @@ const (
 	HashMethodMD5 HashMethod = 0
 )
 
+func (m HashMethod) String() string {
+	switch m {
+	case HashMethodMD5:
+		return "MD5"
+	default:
+		return fmt.Sprintf("HashMethod(%d)", int(m))
+	}
+}
+
 const (
-	mimeTypeFolder = "application/vnd.google-apps.folder"
-	mimeTypeFile   = "application/octet-stream"
+	mimeTypeFolder             = "application/vnd.google-apps.folder"
+	mimeTypeFile               = "application/octet-stream"
+	mimeTypeGooglePrefix       = "application/vnd.google-apps."
+	mimeTypeGoogleDocument     = "application/vnd.google-apps.document"
+	mimeTypeGoogleSpreadsheet  = "application/vnd.google-apps.spreadsheet"
+	mimeTypeGooglePresentation = "application/vnd.google-apps.presentation"
+	mimeTypeGoogleForm         = "application/vnd.google-apps.form"
+	mimeTypeGoogleDrawing      = "application/vnd.google-apps.drawing"
+	mimeTypePDF                = "application/pdf"
 )
 
 var (
-	fileInfoFields []googleapi.Field
-	listFields     []googleapi.Field
-)
-
-func init() {
 	fileInfoFields = []googleapi.Field{
+		"appProperties",
 		"createdTime",
+		"headRevisionId",
 		"id",
 		"mimeType",
 		"modifiedTime",
@@ -48,18 +99,54 @@ func init() {
 	listFields = []googleapi.Field{
 		googleapi.Field(fmt.Sprintf("files(%s)", googleapi.CombineFields(fileInfoFields))),
 	}
+)
+
+// md5ListFields returns the fields of listFields with the md5 checksum of the files,
+// it is built on every call so it always contains the current fileInfoFields
+func md5ListFields() googleapi.Field {
+	return googleapi.Field(fmt.Sprintf("files(%s,md5Checksum)", googleapi.CombineFields(fileInfoFields)))
 }
 
 // New creates a new Google Drive Driver, client must me an authenticated instance for google drive
+// Files are created and updated with enforceSingleParent=true, see WithEnforceSingleParent
 func New(client *http.Client, opts ...Option) (*GDriver, error) {
-	driver := &GDriver{}
+	return newDriver(client, "", opts...)
+}
+
+// NewWithService creates a new Google Drive Driver from client options (e.g. option.WithCredentialsFile),
+// use option.WithEndpoint to talk to a different server (like the fake of the drivetest package)
+//
+// Examples:
+//     NewWithService(ctx, []option.ClientOption{option.WithEndpoint(url), option.WithHTTPClient(client)})
+func NewWithService(ctx context.Context, clientOptions []option.ClientOption, opts ...Option) (*GDriver, error) {
+	clientOptions = append([]option.ClientOption{option.WithScopes(drive.DriveScope)}, clientOptions...)
+	client, endpoint, err := htransport.NewClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create Drive client: %v", err)
+	}
+	return newDriver(client, endpoint, opts...)
+}
+
+// newDriver creates the driver, endpoint overrides the base path of the drive api if it is not empty
+func newDriver(client *http.Client, endpoint string, opts ...Option) (*GDriver, error) {
+	driver := &GDriver{
+		formats:             &formatsCache{},
+		enforceSingleParent: true,
+		metrics:             &DriveMetrics{},
+		tokenSource:         clientTokenSource(client),
+		metadataCache:       &metadataCache{},
+	}
+	driver.client = withMetricsTransport(withCacheInvalidationTransport(withSingleParentTransport(withUserAgentTransport(client, driver), driver), driver), driver.metrics)
 
 	var err error
 
-	driver.srv, err = drive.New(client)
+	driver.srv, err = drive.New(driver.client)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to retrieve Drive client: %v", err)
 	}
+	if endpoint != "" {
+		driver.srv.BasePath = endpoint
+	}
 
 	if _, err = driver.SetRootDirectory(""); err != nil {
 		return nil, err
@@ -90,17 +177,100 @@ func (d *GDriver) SetRootDirectory(path string) (*FileInfo, error) {
 	if !file.IsDir() {
 		return nil, FileIsNotDirectoryError{Path: path}
 	}
-	d.rootNode = file
+	// the paths of all files are relative to the root directory
+	root := *file
+	root.isRoot = true
+	d.rootNode = &root
+	d.metadataCache.clear()
 	return file, nil
 }
 
-// Stat gives a FileInfo for a file or directory
-func (d *GDriver) Stat(path string) (*FileInfo, error) {
-	return d.getFile(d.rootNode, path, listFields...)
+type statOptions struct {
+	optionalFields
+}
+
+// StatOption can be used to pass optional options to Stat
+type StatOption func(options *statOptions)
+
+// StatFolderColor requests the color of the directory, see FileInfo.FolderColor
+func StatFolderColor() StatOption {
+	return func(options *statOptions) {
+		options.folderColor = true
+	}
+}
+
+// StatCapabilities requests the capabilities of the file, see FileInfo.Capabilities
+func StatCapabilities() StatOption {
+	return func(options *statOptions) {
+		options.capabilities = true
+	}
+}
+
+// Stat gives a FileInfo for a file or directory, the metadata cached by BackfillMetadataCache is used if present
+// and no additional fields are requested with opts
+func (d *GDriver) Stat(path string, opts ...StatOption) (*FileInfo, error) {
+	var options statOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !options.requested() {
+		if fi, ok := d.metadataCache.get(path); ok {
+			d.metrics.cacheHit()
+			return fi, nil
+		}
+	}
+	return d.getFile(d.rootNode, path, options.apply(d.defaultListFields()))
+}
+
+// GetFileParentInfo returns the directory that contains the file or directory path,
+// a FileNotExistError will be returned if path is the root directory.
+// The parent is resolved along path, so for files with multiple parents the directory in path is returned.
+func (d *GDriver) GetFileParentInfo(filePath string) (*FileInfo, error) {
+	file, err := d.getFile(d.rootNode, filePath, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+	if file == d.rootNode {
+		return nil, FileNotExistError{Path: filePath}
+	}
+	return d.getFile(d.rootNode, file.parentPath, d.defaultListFields())
+}
+
+// GetFullMetadata fetches the metadata of a file or directory with the specified fields (e.g. "capabilities", "permissions"),
+// all fields are requested if no fields are specified. Unlike FileInfo.DriveFile the metadata is always fetched from drive.
+//
+// Examples:
+//     GetFullMetadata("Folder1/File1", "owners", "permissions")
+func (d *GDriver) GetFullMetadata(path string, fields ...string) (*drive.File, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+	requestFields := make([]googleapi.Field, len(fields))
+	for i, field := range fields {
+		requestFields[i] = googleapi.Field(field)
+	}
+	if len(requestFields) == 0 {
+		requestFields = []googleapi.Field{"*"}
+	}
+	return d.srv.Files.Get(file.item.Id).SupportsTeamDrives(true).Fields(requestFields...).Do()
 }
 
 // ListDirectory will get all contents of a directory, calling fileFunc with the collected file information
-func (d *GDriver) ListDirectory(path string, fileFunc func(*FileInfo) error) error {
+// return SkipAll in fileFunc to stop the listing
+func (d *GDriver) ListDirectory(path string, fileFunc func(*FileInfo) error, opts ...ListOption) error {
+	var options listOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.filter.validate(); err != nil {
+		return err
+	}
+	return d.listDirectory(path, options.apply(d.defaultListFields()), options.filter, fileFunc)
+}
+
+// listDirectory lists the directory like ListDirectory and requests fields for the files, only files that match filter are listed
+func (d *GDriver) listDirectory(path string, fields googleapi.Field, filter listFilter, fileFunc func(*FileInfo) error) error {
 	file, err := d.getFile(d.rootNode, path, "files(id,name,mimeType)")
 	if err != nil {
 		return err
@@ -108,10 +278,15 @@ func (d *GDriver) ListDirectory(path string, fileFunc func(*FileInfo) error) err
 	if !file.IsDir() {
 		return FileIsNotDirectoryError{Path: path}
 	}
+	query := fmt.Sprintf("'%s' in parents and trashed = false", file.item.Id)
+	if clause := filter.clause(); clause != "" {
+		query += " and " + clause
+	}
+	fields = filter.fields(fields)
 	var pageToken string
 
 	for {
-		call := d.srv.Files.List().Q(fmt.Sprintf("'%s' in parents and trashed = false", file.item.Id)).Fields(append(listFields, "nextPageToken")...)
+		call := d.srv.Files.List().Q(query).Fields(fields, "nextPageToken")
 
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
@@ -127,11 +302,16 @@ func (d *GDriver) ListDirectory(path string, fileFunc func(*FileInfo) error) err
 		}
 
 		for i := 0; i < len(descendants.Files); i++ {
-			if err = fileFunc(&FileInfo{
-				item:       descendants.Files[i],
-				parentPath: file.Path(),
-			}); err != nil {
-				return CallbackError{NestedError: err}
+			descendant := &FileInfo{
+				item:         descendants.Files[i],
+				parentPath:   file.Path(),
+				pathEscaping: d.pathEscaping,
+			}
+			if !filter.includesSize(descendant) {
+				continue
+			}
+			if err = fileFunc(descendant); err != nil {
+				return callbackError(err)
 			}
 		}
 
@@ -142,60 +322,377 @@ func (d *GDriver) ListDirectory(path string, fileFunc func(*FileInfo) error) err
 	return nil
 }
 
+// ChunkedListDirectory lists the contents of a directory like ListDirectory,
+// but sends them in slices of at most chunkSize files on the first channel.
+// The first channel will be closed when the listing is done, afterwards the result (nil on success) is sent on the error channel.
+// The chunks must be received until the channel is closed, otherwise the listing goroutine blocks forever.
+func (d *GDriver) ChunkedListDirectory(path string, chunkSize int) (<-chan []*FileInfo, <-chan error) {
+	chunks := make(chan []*FileInfo)
+	errs := make(chan error, 1)
+	if chunkSize <= 0 {
+		close(chunks)
+		errs <- errors.New("chunk size must be greater than zero")
+		close(errs)
+		return chunks, errs
+	}
+
+	go func() {
+		defer close(errs)
+		chunk := make([]*FileInfo, 0, chunkSize)
+		err := d.ListDirectory(path, func(f *FileInfo) error {
+			chunk = append(chunk, f)
+			if len(chunk) == chunkSize {
+				chunks <- chunk
+				chunk = make([]*FileInfo, 0, chunkSize)
+			}
+			return nil
+		})
+		if err == nil && len(chunk) > 0 {
+			chunks <- chunk
+		}
+		close(chunks)
+		errs <- err
+	}()
+	return chunks, errs
+}
+
+// DirCursor holds the position of ReadDirN in a directory, the zero value starts at the first entry
+type DirCursor struct {
+	// PageToken is the token of the next page of the listing
+	PageToken string
+	// Done is true if all entries were returned
+	Done bool
+	// buffered holds the entries of the last page that were not returned yet
+	buffered []*drive.File
+	// lastPage is true if the last page was fetched
+	lastPage bool
+}
+
+// ReadDirN returns at most n entries of the directory path starting at the position of cursor and advances cursor,
+// io.EOF is returned if all entries were returned.
+// If n <= 0 all remaining entries are returned (without io.EOF).
+//
+// Examples:
+//     var cursor DirCursor
+//     for {
+//         files, err := ReadDirN("Pictures", 100, &cursor)
+//         if err == io.EOF {
+//             break
+//         }
+//     }
+func (d *GDriver) ReadDirN(path string, n int, cursor *DirCursor) ([]*FileInfo, error) {
+	if cursor == nil {
+		return nil, errors.New("cursor cannot be nil")
+	}
+	if cursor.Done {
+		if n <= 0 {
+			return []*FileInfo{}, nil
+		}
+		return nil, io.EOF
+	}
+	file, err := d.getFile(d.rootNode, path, "files(id,name,mimeType)")
+	if err != nil {
+		return nil, err
+	}
+	if !file.IsDir() {
+		return nil, FileIsNotDirectoryError{Path: path}
+	}
+
+	files := []*FileInfo{}
+	for n <= 0 || len(files) < n {
+		if len(cursor.buffered) == 0 {
+			if cursor.lastPage {
+				cursor.Done = true
+				break
+			}
+			call := d.srv.Files.List().Q(fmt.Sprintf("'%s' in parents and trashed = false", file.item.Id)).Fields(d.defaultListFields(), "nextPageToken")
+			if cursor.PageToken != "" {
+				call = call.PageToken(cursor.PageToken)
+			}
+			page, err := call.Do()
+			if err != nil {
+				return nil, err
+			}
+			cursor.buffered = page.Files
+			cursor.PageToken = page.NextPageToken
+			cursor.lastPage = page.NextPageToken == ""
+			continue
+		}
+
+		entries := cursor.buffered
+		if n > 0 && len(entries) > n-len(files) {
+			entries = entries[:n-len(files)]
+		}
+		cursor.buffered = cursor.buffered[len(entries):]
+		for _, entry := range entries {
+			files = append(files, &FileInfo{
+				item:         entry,
+				parentPath:   file.Path(),
+				pathEscaping: d.pathEscaping,
+			})
+		}
+	}
+	if len(cursor.buffered) == 0 && cursor.lastPage {
+		cursor.Done = true
+	}
+
+	if len(files) == 0 && n > 0 {
+		return nil, io.EOF
+	}
+	return files, nil
+}
+
+// GetFilesCount counts the files and directories that are descendants of the directory path
+func (d *GDriver) GetFilesCount(path string) (files, dirs int, err error) {
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return 0, 0, err
+	}
+	if !file.IsDir() {
+		return 0, 0, FileIsNotDirectoryError{Path: path}
+	}
+
+	pending := []string{file.item.Id}
+	for len(pending) > 0 {
+		parentID := pending[0]
+		pending = pending[1:]
+		err = d.listFiles(fmt.Sprintf("'%s' in parents and trashed = false", parentID), "files(id,mimeType)", func(f *drive.File) error {
+			if f.MimeType == mimeTypeFolder {
+				dirs++
+				pending = append(pending, f.Id)
+			} else {
+				files++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return files, dirs, nil
+}
+
+// GetFileCountByMimeType counts the descendants of the directory path by their mime type,
+// directories are counted as application/vnd.google-apps.folder
+func (d *GDriver) GetFileCountByMimeType(path string) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := d.Walk(path, func(f *FileInfo) error {
+		counts[f.item.MimeType]++
+		return nil
+	}, walkFields("files(id,mimeType)"))
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// listFiles calls fn for every file that matches the query, fields must be in the form of files(...)
+// if fn returns SkipAll the listing stops and nil will be returned
+func (d *GDriver) listFiles(query string, fields googleapi.Field, fn func(*drive.File) error) error {
+	var pageToken string
+	for {
+		call := d.srv.Files.List().Q(query).Fields(fields, "nextPageToken")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		list, err := call.Do()
+		if err != nil {
+			return err
+		}
+		if list == nil {
+			return errors.New("no file information present")
+		}
+
+		for _, file := range list.Files {
+			if err = fn(file); err != nil {
+				if err == SkipAll {
+					return nil
+				}
+				return err
+			}
+		}
+
+		if pageToken = list.NextPageToken; pageToken == "" {
+			return nil
+		}
+	}
+}
+
 // MakeDirectory creates a directory for the specified path, it will create non existent directores automatically
 //
 // Examples:
 //     MakeDirectory("Pictures/Holidays") // will create Pictures and Holidays
-func (d *GDriver) MakeDirectory(path string) (*FileInfo, error) {
+func (d *GDriver) MakeDirectory(path string) (_ *FileInfo, err error) {
+	defer d.audit("MakeDirectory", path, "")(&err)
 	return d.makeDirectoryByParts(strings.FieldsFunc(path, isPathSeperator))
 }
 
+// GetOrCreateDirectory works like MakeDirectory, created is true if the directory was created and false if it already existed.
+// A FileIsNotDirectoryError will be returned if a file exists at path.
+func (d *GDriver) GetOrCreateDirectory(path string) (_ *FileInfo, created bool, err error) {
+	defer d.audit("GetOrCreateDirectory", path, "")(&err)
+	fi, created, err := d.getOrCreateDirectoryByParts(strings.FieldsFunc(path, isPathSeperator))
+	if err != nil {
+		return nil, false, err
+	}
+	if !fi.IsDir() {
+		return nil, false, FileIsNotDirectoryError{Path: path}
+	}
+	return fi, created, nil
+}
+
 func (d *GDriver) makeDirectoryByParts(pathParts []string) (*FileInfo, error) {
+	fi, _, err := d.getOrCreateDirectoryByParts(pathParts)
+	return fi, err
+}
+
+// getOrCreateDirectoryByParts creates the non existing directories of the path and returns the last one,
+// created is true if the last directory was created
+func (d *GDriver) getOrCreateDirectoryByParts(pathParts []string) (_ *FileInfo, created bool, err error) {
 	parentNode := d.rootNode
 	for i := 0; i < len(pathParts); i++ {
-		query := fmt.Sprintf("'%s' in parents and name='%s' and trashed = false", parentNode.item.Id, sanitizeName(pathParts[i]))
+		query := fmt.Sprintf("'%s' in parents and %s and trashed = false", parentNode.item.Id, d.nameQuery(pathParts[i]))
 		files, err := d.srv.Files.List().Q(query).Fields(listFields...).Do()
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		if files == nil {
-			return nil, fmt.Errorf("no file information present (in `%s')", path.Join(pathParts[:i+1]...))
+			return nil, false, fmt.Errorf("no file information present (in `%s')", path.Join(pathParts[:i+1]...))
 		}
 
 		if len(files.Files) <= 0 {
 			// file not found => create directory
 			if !parentNode.IsDir() {
-				return nil, fmt.Errorf("unable to create directory in `%s': `%s' is not a directory", path.Join(pathParts[:i]...), parentNode.Name())
+				return nil, false, fmt.Errorf("unable to create directory in `%s': `%s' is not a directory", path.Join(pathParts[:i]...), parentNode.Name())
+			}
+			if err = d.validateName(pathParts[i]); err != nil {
+				return nil, false, err
 			}
 			var createdDir *drive.File
-			createdDir, err = d.srv.Files.Create(&drive.File{
-				Name:     sanitizeName(pathParts[i]),
-				MimeType: mimeTypeFolder,
-				Parents: []string{
-					parentNode.item.Id,
-				},
-			}).Fields(fileInfoFields...).Do()
+			createdDir, created, err = d.createDirectory(parentNode.item.Id, d.pathEscaping.unescape(pathParts[i]))
 			if err != nil {
-				return nil, err
+				return nil, false, err
 			}
 			parentNode = &FileInfo{
-				item:       createdDir,
-				parentPath: path.Join(pathParts[:i]...),
+				item:         createdDir,
+				parentPath:   path.Join(pathParts[:i]...),
+				pathEscaping: d.pathEscaping,
 			}
 		} else if len(files.Files) > 1 {
-			return nil, fmt.Errorf("multiple entries found for `%s'", path.Join(pathParts[:i+1]...))
+			return nil, false, fmt.Errorf("multiple entries found for `%s'", path.Join(pathParts[:i+1]...))
 		} else { // if len(files.Files) == 1
+			created = false
 			parentNode = &FileInfo{
-				item:       files.Files[0],
-				parentPath: path.Join(pathParts[:i]...),
+				item:         files.Files[0],
+				parentPath:   path.Join(pathParts[:i]...),
+				pathEscaping: d.pathEscaping,
 			}
 		}
 	}
-	return parentNode, nil
+	return parentNode, created, nil
+}
+
+// directoryLocks serializes the creation of directories with the same name in the same parent directory
+var directoryLocks = newKeyedMutex()
+
+// createDirectory creates the directory name in the directory parentID, unless it was created concurrently.
+// Directories that are created at the same time by other processes are detected afterwards,
+// in that case the oldest directory is kept and the other ones are deleted, created is only true if the returned directory was created by this call.
+func (d *GDriver) createDirectory(parentID, name string) (_ *drive.File, created bool, err error) {
+	unlock := directoryLocks.lock(parentID + "/" + name)
+	defer unlock()
+
+	query := fmt.Sprintf("'%s' in parents and name='%s' and mimeType='%s' and trashed = false", parentID, escapeQueryValue(name), mimeTypeFolder)
+	// another goroutine might have created the directory while we were waiting
+	existing, err := d.srv.Files.List().Q(query).Fields(listFields...).Do()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(existing.Files) > 0 {
+		return oldestFile(existing.Files), false, nil
+	}
+
+	createdDir, err := d.srv.Files.Create(&drive.File{
+		Name:     name,
+		MimeType: mimeTypeFolder,
+		Parents: []string{
+			parentID,
+		},
+	}).SupportsTeamDrives(true).Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err = d.srv.Files.List().Q(query).Fields(listFields...).Do()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(existing.Files) <= 1 {
+		return createdDir, true, nil
+	}
+	survivor := oldestFile(existing.Files)
+	if survivor.Id == createdDir.Id {
+		return createdDir, true, nil
+	}
+	// another process created the directory at the same time, adopt the older one
+	if err = d.srv.Files.Delete(createdDir.Id).Do(); err != nil {
+		return nil, false, err
+	}
+	return survivor, false, nil
+}
+
+// oldestFile returns the file that was created first, files with the same creation time are ordered by their id
+func oldestFile(files []*drive.File) *drive.File {
+	oldest := files[0]
+	for _, file := range files[1:] {
+		if file.CreatedTime < oldest.CreatedTime || (file.CreatedTime == oldest.CreatedTime && file.Id < oldest.Id) {
+			oldest = file
+		}
+	}
+	return oldest
+}
+
+// keyedMutex provides a mutex per key
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedMutexEntry)}
+}
+
+// lock locks the mutex of key and returns the function that unlocks it
+func (m *keyedMutex) lock(key string) func() {
+	m.mu.Lock()
+	entry, ok := m.locks[key]
+	if !ok {
+		entry = &keyedMutexEntry{}
+		m.locks[key] = entry
+	}
+	entry.refs++
+	m.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+		m.mu.Lock()
+		if entry.refs--; entry.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
 }
 
 // DeleteDirectory will delete a directory and its descendants
-func (d *GDriver) DeleteDirectory(path string) error {
+func (d *GDriver) DeleteDirectory(path string) (err error) {
+	defer d.audit("DeleteDirectory", path, "")(&err)
 	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
 	if err != nil {
 		return err
@@ -207,11 +704,71 @@ func (d *GDriver) DeleteDirectory(path string) error {
 	if file == d.rootNode {
 		return errors.New("root cannot be deleted")
 	}
-	return d.srv.Files.Delete(file.item.Id).Do()
+	return d.srv.Files.Delete(file.item.Id).SupportsTeamDrives(true).Do()
+}
+
+// PurgeDirectory deletes all children of a directory but keeps the directory itself,
+// directories among the children will be deleted with their descendants
+func (d *GDriver) PurgeDirectory(path string) (err error) {
+	defer d.audit("PurgeDirectory", path, "")(&err)
+	dir, err := d.getPurgeDirectory(path)
+	if err != nil {
+		return err
+	}
+	return d.purgeDirectory(dir, false)
+}
+
+// PurgeDirectoryRecursive deletes all descendants of a directory but keeps the directory itself.
+// Unlike PurgeDirectory it descends into the sub directories and deletes their contents first,
+// this is slower but avoids deleting very large trees with one request
+func (d *GDriver) PurgeDirectoryRecursive(path string) (err error) {
+	defer d.audit("PurgeDirectoryRecursive", path, "")(&err)
+	dir, err := d.getPurgeDirectory(path)
+	if err != nil {
+		return err
+	}
+	return d.purgeDirectory(dir, true)
+}
+
+// getPurgeDirectory returns the directory for PurgeDirectory and PurgeDirectoryRecursive
+func (d *GDriver) getPurgeDirectory(path string) (*FileInfo, error) {
+	dir, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.IsDir() {
+		return nil, FileIsNotDirectoryError{Path: path}
+	}
+	return dir, nil
+}
+
+// purgeDirectory deletes all children of dir, if recursive is set sub directories will be emptied before they are deleted
+func (d *GDriver) purgeDirectory(dir *FileInfo, recursive bool) error {
+	children, err := d.listChildren(dir)
+	if err != nil {
+		return err
+	}
+	var backoff batchBackoff
+	for _, child := range children {
+		if recursive && child.IsDir() {
+			if err = d.purgeDirectory(child, true); err != nil {
+				return err
+			}
+		}
+		id := child.item.Id
+		err = retry(&backoff, func() error {
+			return d.srv.Files.Delete(id).Do()
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Delete will delete a file or directory, if directory it will also delete its descendants
-func (d *GDriver) Delete(path string) error {
+func (d *GDriver) Delete(path string) (err error) {
+	defer d.audit("Delete", path, "")(&err)
 	file, err := d.getFile(d.rootNode, path)
 	if err != nil {
 		return err
@@ -232,111 +789,782 @@ func (d *GDriver) GetFile(path string) (*FileInfo, io.ReadCloser, error) {
 		return nil, nil, FileIsDirectoryError{Path: path}
 	}
 
-	response, err := d.srv.Files.Get(file.item.Id).Download()
+	body, err := d.downloadDecoded(file)
 	if err != nil {
 		return nil, nil, err
 	}
+	return file, body, nil
+}
+
+// GetFileContentAt returns length bytes of the contents of a file starting at offset,
+// if the file ends before offset+length only the available bytes are returned
+//
+// Examples:
+//     GetFileContentAt("Pictures/Holidays.png", 0, 8) // returns the png header
+func (d *GDriver) GetFileContentAt(path string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, fmt.Errorf("invalid range: offset %d, length %d", offset, length)
+	}
+	file, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: path}
+	}
+
+	// encrypted and compressed files must be decoded from the beginning
+	encoded := isEncodedFile(file.item)
+	if length == 0 || (!encoded && offset >= file.item.Size) {
+		return []byte{}, nil
+	}
+
+	call := d.srv.Files.Get(file.item.Id)
+	if !encoded {
+		call.Header().Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+	response, err := call.Download()
+	if err != nil {
+		return nil, err
+	}
+	body, err := d.decodeContents(file, response.Body)
+	if err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	defer body.Close()
+
+	if response.StatusCode != http.StatusPartialContent {
+		if _, err = io.CopyN(ioutil.Discard, body, offset); err != nil {
+			if err == io.EOF {
+				return []byte{}, nil
+			}
+			return nil, err
+		}
+	}
+	return ioutil.ReadAll(io.LimitReader(body, length))
+}
+
+// GetFileRevisionContent returns the contents of a revision of a file,
+// note that the contents are returned as they are stored (encrypted or compressed revisions will not be decoded)
+func (d *GDriver) GetFileRevisionContent(path, revisionID string) (io.ReadCloser, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: path}
+	}
 
-	return file, response.Body, nil
+	response, err := d.srv.Revisions.Get(file.item.Id, revisionID).Download()
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil, ErrRevisionNotFound{RevisionID: revisionID}
+		}
+		return nil, err
+	}
+	return response.Body, nil
 }
 
 // GetFileHash returns the hash of a file with the present method
+// if the file is encrypted the hash is the hash of the encrypted contents
 func (d *GDriver) GetFileHash(path string, method HashMethod) (*FileInfo, []byte, error) {
 	switch method {
 	case HashMethodMD5:
 	default:
 		return nil, nil, fmt.Errorf("Unknown method %d", method)
 	}
-	file, err := d.getFile(d.rootNode, path, "files(id, md5Checksum)")
+	file, err := d.getFile(d.rootNode, path, "files(id, md5Checksum, headRevisionId)")
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.IsDir() {
+		return nil, nil, FileIsDirectoryError{Path: path}
+	}
+
+	return file, []byte(file.item.Md5Checksum), nil
+}
+
+// CompareAndSwap uploads r to the existing file path (see PutFile) only if the hex encoded md5 checksum of its contents is expectedMD5,
+// otherwise a CASConflictError will be returned.
+// The swap is not atomic: the upload is guarded with IfRevision, which detects most writes between the comparison and the upload,
+// but a write that happens between the revision check and the update is not detected.
+// Files without a revision (e.g. google native files) cannot be swapped, a NoRevisionError will be returned for them.
+func (d *GDriver) CompareAndSwap(path string, expectedMD5 string, r io.Reader) (*FileInfo, error) {
+	file, hash, err := d.GetFileHash(path, HashMethodMD5)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) == 0 && file.item.HeadRevisionId == "" {
+		return nil, NoRevisionError{Path: path}
+	}
+	if string(hash) != expectedMD5 {
+		return nil, CASConflictError{Path: path, Expected: expectedMD5, Actual: string(hash)}
+	}
+	fi, err := d.PutFile(path, r, IfRevision(file.item.HeadRevisionId))
+	if _, ok := err.(ErrRevisionConflict); ok {
+		_, hash, err = d.GetFileHash(path, HashMethodMD5)
+		if err != nil {
+			return nil, err
+		}
+		return nil, CASConflictError{Path: path, Expected: expectedMD5, Actual: string(hash)}
+	}
+	return fi, err
+}
+
+// PutFileIfChanged uploads r to path (see PutFile) only if the hex encoded md5 checksum of the existing file is not localMD5,
+// uploaded reports whether r was uploaded, if not the existing file is returned.
+// Encrypted files are always uploaded, drive only knows the checksum of the encrypted contents.
+//     Example:
+//         fi, uploaded, err := PutFileIfChanged("Folder1/File1", f, "b10a8db164e0754105b7a99be72e3fe5")
+func (d *GDriver) PutFileIfChanged(path string, r io.ReadSeeker, localMD5 string) (fi *FileInfo, uploaded bool, err error) {
+	file, err := d.getFile(d.rootNode, path, md5ListFields())
+	if err != nil && !IsNotExist(err) {
+		return nil, false, err
+	}
+	if file != nil {
+		if file.IsDir() {
+			return nil, false, FileIsDirectoryError{Path: path}
+		}
+		if file.item.Md5Checksum != "" && strings.EqualFold(file.item.Md5Checksum, localMD5) {
+			return file, false, nil
+		}
+	}
+	fi, err = d.PutFile(path, r)
+	if err != nil {
+		return nil, false, err
+	}
+	return fi, true, nil
+}
+
+// GetFileOwnerEmail returns the email address of the owner of a file or directory,
+// if the file has multiple owners the first one will be returned
+func (d *GDriver) GetFileOwnerEmail(path string) (string, error) {
+	emails, err := d.GetFileAllOwnerEmails(path)
+	if err != nil {
+		return "", err
+	}
+	if len(emails) == 0 {
+		return "", fmt.Errorf("`%s' has no owner", path)
+	}
+	return emails[0], nil
+}
+
+// GetFileAllOwnerEmails returns the email addresses of all owners of a file or directory
+// (files in shared drives have no owners)
+func (d *GDriver) GetFileAllOwnerEmails(path string) ([]string, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id,owners(emailAddress))")
+	if err != nil {
+		return nil, err
+	}
+	item := file.item
+	if file == d.rootNode {
+		// the root node was fetched without the owners
+		if item, err = d.srv.Files.Get(item.Id).Fields("id", "owners(emailAddress)").Do(); err != nil {
+			return nil, err
+		}
+	}
+
+	emails := make([]string, 0, len(item.Owners))
+	for _, owner := range item.Owners {
+		emails = append(emails, owner.EmailAddress)
+	}
+	return emails, nil
+}
+
+// TransferOwnership makes newOwnerEmail the owner of a file or directory, the caller becomes a writer.
+// This is only possible for files in My Drive (files in shared drives have no owners) and the caller must be the current owner.
+func (d *GDriver) TransferOwnership(path, newOwnerEmail string) (err error) {
+	defer d.audit("TransferOwnership", path, newOwnerEmail)(&err)
+	file, err := d.getFile(d.rootNode, path, "files(id,ownedByMe,teamDriveId)")
+	if err != nil {
+		return err
+	}
+	if file == d.rootNode {
+		return OwnershipTransferError{Path: path, Reason: "root cannot be transferred"}
+	}
+	if file.item.TeamDriveId != "" {
+		return OwnershipTransferError{Path: path, Reason: "files in shared drives have no owner"}
+	}
+	if !file.item.OwnedByMe {
+		return OwnershipTransferError{Path: path, Reason: "only the owner can transfer the ownership"}
+	}
+
+	_, err = d.srv.Permissions.Create(file.item.Id, &drive.Permission{
+		Type:         "user",
+		Role:         "owner",
+		EmailAddress: newOwnerEmail,
+	}).TransferOwnership(true).Fields("id").Do()
+	if reason, ok := ownershipTransferErrorReason(err); ok {
+		return OwnershipTransferError{Path: path, Reason: reason}
+	}
+	return err
+}
+
+// GetShareableLink makes a file or directory readable for anyone with the link and returns the link
+func (d *GDriver) GetShareableLink(path string) (_ string, err error) {
+	defer d.audit("GetShareableLink", path, "")(&err)
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return "", err
+	}
+	if file == d.rootNode {
+		return "", errors.New("root cannot be shared")
+	}
+
+	_, err = d.srv.Permissions.Create(file.item.Id, &drive.Permission{
+		Type:               "anyone",
+		Role:               "reader",
+		AllowFileDiscovery: false,
+	}).SupportsTeamDrives(true).Fields("id").Do()
+	if err != nil {
+		return "", err
+	}
+
+	item, err := d.srv.Files.Get(file.item.Id).SupportsTeamDrives(true).Fields("webViewLink").Do()
+	if err != nil {
+		return "", err
+	}
+	return item.WebViewLink, nil
+}
+
+// RevokeShareableLink removes the permission that was created by GetShareableLink and all other permissions for anyone,
+// it does nothing if the file or directory is not shared with anyone
+func (d *GDriver) RevokeShareableLink(path string) (err error) {
+	defer d.audit("RevokeShareableLink", path, "")(&err)
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	var pageToken string
+	for {
+		call := d.srv.Permissions.List(file.item.Id).SupportsTeamDrives(true).Fields("nextPageToken", "permissions(id,type)")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		list, err := call.Do()
+		if err != nil {
+			return err
+		}
+		for _, permission := range list.Permissions {
+			if permission.Type == "anyone" {
+				ids = append(ids, permission.Id)
+			}
+		}
+		if pageToken = list.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+
+	for _, id := range ids {
+		err = d.srv.Permissions.Delete(file.item.Id, id).SupportsTeamDrives(true).Do()
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DownloadLinks holds the links that can be used to download a file (see GetDownloadLinks)
+type DownloadLinks struct {
+	// WebContentLink downloads the contents, it is empty for directories and google native files
+	WebContentLink string
+	// WebViewLink opens the file in the browser
+	WebViewLink string
+	// ExportLinks maps the export formats of google native files to their download links
+	ExportLinks map[string]string
+}
+
+// GetDownloadLinks returns the links that can be used to download a file or directory with other clients.
+// Note that the links are not public, they still require an authenticated request with access to the file
+// (use GetShareableLink to share a file) and they do not expire.
+func (d *GDriver) GetDownloadLinks(path string) (*DownloadLinks, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+	item, err := d.srv.Files.Get(file.item.Id).SupportsTeamDrives(true).Fields("webContentLink", "webViewLink", "exportLinks").Do()
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadLinks{
+		WebContentLink: item.WebContentLink,
+		WebViewLink:    item.WebViewLink,
+		ExportLinks:    item.ExportLinks,
+	}, nil
+}
+
+// ownershipTransferErrorReason returns the reason if the error was caused by a disallowed ownership transfer
+func ownershipTransferErrorReason(err error) (string, bool) {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return "", false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "invalidSharingRequest", "consentMessageRequired", "ownershipChangeAcrossDomainNotPermitted", "ownerOnTeamDriveItemNotSupported":
+			return item.Message, true
+		}
+	}
+	return "", false
+}
+
+// GetParentIDs returns the ids of the parent directories of a file or directory
+func (d *GDriver) GetParentIDs(path string) ([]string, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id,parents)")
+	if err != nil {
+		return nil, err
+	}
+	item := file.item
+	if file == d.rootNode {
+		// the root node was fetched without the parents
+		if item, err = d.srv.Files.Get(item.Id).Fields("id", "parents").Do(); err != nil {
+			return nil, err
+		}
+	}
+	return item.Parents, nil
+}
+
+// HasMultipleParents returns true if a file or directory has more than one parent directory
+func (d *GDriver) HasMultipleParents(path string) (bool, error) {
+	parents, err := d.GetParentIDs(path)
+	if err != nil {
+		return false, err
+	}
+	return len(parents) > 1, nil
+}
+
+// GetParentChain returns the file or directory of path and all its ancestors (excluding the root directory),
+// ordered from the root directory to the file.
+// If the parents do not lead to the root directory the chain that could be reached will be returned along with a PartialChainError.
+func (d *GDriver) GetParentChain(path string) ([]*FileInfo, error) {
+	fields := append(fileInfoFields, "parents")
+	file, err := d.getFile(d.rootNode, path, googleapi.Field(fmt.Sprintf("files(%s)", googleapi.CombineFields(fields))))
+	if err != nil {
+		return nil, err
+	}
+	if file == d.rootNode {
+		return []*FileInfo{}, nil
+	}
+
+	chain := []*drive.File{file.item}
+	var chainErr error
+	for item := file.item; ; {
+		parentID := chainParent(item, d.rootNode.item.Id)
+		if parentID == d.rootNode.item.Id {
+			break
+		}
+		if parentID == "" {
+			chainErr = PartialChainError{ReachedID: item.Id}
+			break
+		}
+		if item, err = d.srv.Files.Get(parentID).Fields(fields...).Do(); err != nil {
+			return nil, err
+		}
+		chain = append([]*drive.File{item}, chain...)
+	}
+
+	infos := make([]*FileInfo, len(chain))
+	var parentPath string
+	for i, item := range chain {
+		infos[i] = &FileInfo{
+			item:         item,
+			parentPath:   parentPath,
+			pathEscaping: d.pathEscaping,
+		}
+		parentPath = infos[i].Path()
+	}
+	return infos, chainErr
+}
+
+// chainParent returns rootID if it is a parent of file, otherwise the first parent
+func chainParent(file *drive.File, rootID string) string {
+	for _, parentID := range file.Parents {
+		if parentID == rootID {
+			return rootID
+		}
+	}
+	if len(file.Parents) == 0 {
+		return ""
+	}
+	return file.Parents[0]
+}
+
+// EnsureFile returns the file at the specified path, if it does not exist it will be uploaded using PutFile.
+// created is true if the file was uploaded, r will only be read in that case.
+func (d *GDriver) EnsureFile(filePath string, r io.Reader) (_ *FileInfo, created bool, err error) {
+	fi, err := d.Stat(filePath)
+	if err == nil {
+		if fi.IsDir() {
+			return nil, false, FileIsDirectoryError{Path: filePath}
+		}
+		return fi, false, nil
+	}
+	if !IsNotExist(err) {
+		return nil, false, err
+	}
+
+	fi, err = d.PutFile(filePath, r)
+	if err != nil {
+		return nil, false, err
+	}
+	return fi, true, nil
+}
+
+// PutFileFromReader uploads size bytes of r to the specified path (see PutFile), r will be read from its current position
+func (d *GDriver) PutFileFromReader(filePath string, r io.ReadSeeker, size int64) (*FileInfo, error) {
+	if size < 0 {
+		return nil, errors.New("size cannot be negative")
+	}
+	return d.PutFile(filePath, &sizedReader{Reader: io.LimitReader(r, size), size: size})
+}
+
+// sizedReader implements SizedReader for a reader with a known size
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+func (r *sizedReader) Size() int64 {
+	return r.size
+}
+
+type putOptions struct {
+	conflict ConflictPolicy
+	revision string
+	// contentLength is the size of the contents announced with PutContentLength, or -1
+	contentLength int64
+}
+
+// PutOption can be used to pass optional options to PutFile
+type PutOption func(options *putOptions)
+
+// PutConflict controls what PutFile does if the file already exists, defaults to ConflictOverwrite.
+// ConflictSkip returns the existing file without reading the contents,
+// ConflictRename uploads the file with the first free suffix (e.g. "report (1).pdf").
+func PutConflict(conflict ConflictPolicy) PutOption {
+	return func(options *putOptions) {
+		options.conflict = conflict
+	}
+}
+
+// Touch creates an empty file (and non existing parent directories) if path does not exist,
+// otherwise the modified time of the file or directory will be set to now without changing its contents
+func (d *GDriver) Touch(filePath string) (_ *FileInfo, err error) {
+	defer d.audit("Touch", filePath, "")(&err)
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("path cannot be empty")
+	}
+
+	parentNode, err := d.makeParentDirectory(pathParts)
+	if err != nil {
+		return nil, err
+	}
+	file, err := d.getFileByParts(parentNode, pathParts[amountOfParts-1:], "files(id)")
+	if IsNotExist(err) {
+		return d.createFileInParent(parentNode, filePath, pathParts, bytes.NewReader(nil), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := d.srv.Files.Update(file.item.Id, &drive.File{
+		ModifiedTime: d.now().UTC().Format(time.RFC3339Nano),
+	}).Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         item,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// IfRevision only updates the contents of an existing file if its head revision is still rev (see FileInfo.HeadRevisionID),
+// otherwise an ErrRevisionConflict will be returned.
+// The revision is checked immediately before the update, but a write that happens between the check and the update is not detected.
+// Google native files have no head revision and cannot be updated with this option.
+func IfRevision(rev string) PutOption {
+	return func(options *putOptions) {
+		options.revision = rev
+	}
+}
+
+// PutFile uploads a file to the specified path
+// it creates non existing directories (see WithFolderCreation)
+// If r implements SizedReader (or is a bytes.Buffer, bytes.Reader, strings.Reader or os.File) or PutContentLength is used the size will be announced to drive.
+// Failed uploads are only retried if the driver was created with WithUploadSpool.
+//
+// Examples:
+//     PutFile("Reports/report.pdf", r, PutConflict(ConflictRename)) // uploads to Reports/report (1).pdf if Reports/report.pdf exists
+func (d *GDriver) PutFile(filePath string, r io.Reader, opts ...PutOption) (_ *FileInfo, err error) {
+	defer d.audit("PutFile", filePath, "")(&err)
+	options := putOptions{
+		conflict:      ConflictOverwrite,
+		contentLength: -1,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.contentLength >= 0 {
+		r = &sizedReader{Reader: r, size: options.contentLength}
+	}
+
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("path cannot be empty")
+	}
+
+	if options.conflict == ConflictRename {
+		return d.putFileRenamed(filePath, pathParts, r)
+	}
+
+	// check if there is already a file
+	existentFile, err := d.getFileByParts(d.rootNode, pathParts, listFields...)
+	if err != nil {
+		if !IsNotExist(err) {
+			return nil, err
+		}
+		existentFile = nil
+	}
+
+	if existentFile == d.rootNode {
+		return nil, errors.New("root cannot be uploaded")
+	}
+
+	// we found a file, just update this file
+	if existentFile != nil {
+		switch options.conflict {
+		case ConflictOverwrite:
+		case ConflictSkip:
+			return existentFile, nil
+		case ConflictError:
+			return nil, FileExistError{Path: filePath}
+		default:
+			return nil, fmt.Errorf("unknown conflict policy %s", options.conflict)
+		}
+		if err = d.updateFileContents(existentFile, r, options.revision); err != nil {
+			return nil, err
+		}
+
+		return existentFile, nil
+	}
+
+	return d.createFile(filePath, pathParts, r, nil)
+}
+
+// putFileRenamed creates the file, if the name is already taken the first free suffix will be used (see conflictName)
+func (d *GDriver) putFileRenamed(filePath string, pathParts []string, r io.Reader) (*FileInfo, error) {
+	amountOfParts := len(pathParts)
+	parentNode, err := d.getFileByParts(d.rootNode, pathParts[:amountOfParts-1], "files(id,name,mimeType)")
+	if err != nil {
+		if IsNotExist(err) {
+			// there cannot be a conflict in a new directory
+			return d.createFile(filePath, pathParts, r, nil)
+		}
+		return nil, err
+	}
+
+	// look up the name and the names with a suffix the same way the existence of a file is checked
+	name := d.pathEscaping.unescape(pathParts[amountOfParts-1])
+	taken := make(map[string]*FileInfo)
+	for candidate := name; ; candidate = conflictName(name, false, taken) {
+		_, err = d.getFileByParts(parentNode, []string{d.pathEscaping.escape(candidate)})
+		if IsNotExist(err) {
+			pathParts = append(pathParts[:amountOfParts-1:amountOfParts-1], d.pathEscaping.escape(candidate))
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		taken[candidate] = nil
+	}
+	return d.createFile(path.Join(pathParts...), pathParts, r, nil)
+}
+
+func (d *GDriver) createFile(filePath string, pathParts []string, r io.Reader, metadata *drive.File) (*FileInfo, error) {
+	// validate the name before the parent directories are created
+	if err := d.validateName(pathParts[len(pathParts)-1]); err != nil {
+		return nil, err
+	}
+	parentNode, err := d.makeParentDirectory(pathParts)
+	if err != nil {
+		return nil, err
+	}
+	return d.createFileInParent(parentNode, filePath, pathParts, r, metadata)
+}
+
+// makeParentDirectory creates the non existing parent directories of the path and returns the parent directory,
+// if the FolderCreationFail policy is set the parent directory must exist
+func (d *GDriver) makeParentDirectory(pathParts []string) (*FileInfo, error) {
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 1 {
+		return d.rootNode, nil
+	}
+	var parentNode *FileInfo
+	var err error
+	if d.folderCreation == FolderCreationFail {
+		parentNode, err = d.getFileByParts(d.rootNode, pathParts[:amountOfParts-1], "files(id,name,mimeType)")
+	} else {
+		parentNode, err = d.makeDirectoryByParts(pathParts[:amountOfParts-1])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !parentNode.IsDir() {
+		return nil, fmt.Errorf("unable to create file in `%s': `%s' is not a directory", path.Join(pathParts[:amountOfParts-1]...), parentNode.Name())
+	}
+	return parentNode, nil
+}
+
+// createFileInParent creates the file in the existing directory parentNode
+func (d *GDriver) createFileInParent(parentNode *FileInfo, filePath string, pathParts []string, r io.Reader, metadata *drive.File) (fi *FileInfo, err error) {
+	if err = d.validateName(pathParts[len(pathParts)-1]); err != nil {
+		return nil, err
+	}
+	err = d.withUploadRetry(filePath, r, func(r io.Reader) error {
+		fi, err = d.uploadFileInParent(parentNode, filePath, pathParts, r, metadata)
+		return err
+	})
+	return fi, err
+}
+
+// uploadFileInParent uploads the contents of r as a new file in parentNode, it is called for every attempt of createFileInParent
+func (d *GDriver) uploadFileInParent(parentNode *FileInfo, filePath string, pathParts []string, r io.Reader, metadata *drive.File) (*FileInfo, error) {
+	amountOfParts := len(pathParts)
+	name := d.pathEscaping.unescape(pathParts[amountOfParts-1])
+	mimeType, contentType := d.uploadMimeTypes(name)
+	contents, err := d.encodeContents(name, r, contentType != "")
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		contents.contentType = contentType
+	}
+
+	newFile := &drive.File{
+		Name:          name,
+		MimeType:      mimeType,
+		AppProperties: contents.appProperties,
+		Parents: []string{
+			parentNode.item.Id,
+		},
+	}
+	if metadata != nil {
+		newFile.ModifiedTime = metadata.ModifiedTime
+		// the mime type only describes the contents if they are stored unencoded
+		if metadata.MimeType != "" && contentType == "" && len(contents.appProperties) == 0 {
+			newFile.MimeType = metadata.MimeType
+		}
+	}
+
+	var file *drive.File
+	if d.uploadInSession(contents) {
+		file, err = d.uploadResumable(filePath, http.MethodPost, "files", newFile, contents)
+	} else {
+		file, err = d.srv.Files.Create(newFile).Fields(fileInfoFields...).Media(contents.reader, d.mediaOptions(contents)...).Do()
+	}
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	if file.IsDir() {
-		return nil, nil, FileIsDirectoryError{Path: path}
+	if file, err = d.finishContents(file, contents); err != nil {
+		return nil, err
 	}
-
-	return file, []byte(file.item.Md5Checksum), nil
+	return &FileInfo{
+		item:         file,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
+	}, nil
 }
 
-// PutFile uploads a file to the specified path
-// it creates non existing directories
-func (d *GDriver) PutFile(filePath string, r io.Reader) (*FileInfo, error) {
-	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
-	amountOfParts := len(pathParts)
-	if amountOfParts <= 0 {
-		return nil, errors.New("path cannot be empty")
-	}
+// updateFileContents uploads new contents for the file, if revision is not empty the head revision of the file must match it
+func (d *GDriver) updateFileContents(file *FileInfo, r io.Reader, revision string) error {
+	return d.withUploadRetry(file.Path(), r, func(r io.Reader) error {
+		return d.uploadFileContents(file, r, revision)
+	})
+}
 
-	// check if there is already a file
-	existentFile, err := d.getFileByParts(d.rootNode, pathParts, listFields...)
+// uploadFileContents uploads the contents of r to the existing file, it is called for every attempt of updateFileContents
+func (d *GDriver) uploadFileContents(file *FileInfo, r io.Reader, revision string) error {
+	contents, err := d.encodeContents(file.Name(), r, false)
 	if err != nil {
-		if !IsNotExist(err) {
-			return nil, err
-		}
-		existentFile = nil
-	}
-
-	if existentFile == d.rootNode {
-		return nil, errors.New("root cannot be uploaded")
+		return err
 	}
 
-	// we found a file, just update this file
-	if existentFile != nil {
-		if err = d.updateFileContents(existentFile.item.Id, r); err != nil {
-			return nil, err
+	// remove content properties that do not apply anymore
+	var staleAppProperties []string
+	for _, key := range contentAppProperties {
+		if _, ok := contents.appProperties[key]; ok {
+			continue
+		}
+		if _, ok := file.item.AppProperties[key]; ok {
+			staleAppProperties = append(staleAppProperties, key)
 		}
-
-		return existentFile, nil
 	}
 
-	// create a new file
-	parentNode := d.rootNode
-	if amountOfParts > 1 {
-		dir, err := d.makeDirectoryByParts(pathParts[:amountOfParts-1])
-		if err != nil {
-			return nil, err
+	// update file
+	var update *drive.File
+	if contents.appProperties != nil || staleAppProperties != nil {
+		update = &drive.File{
+			AppProperties: contents.appProperties,
 		}
-		parentNode = dir
+		removeMapKeys(update, "AppProperties", staleAppProperties)
+	}
 
-		if !parentNode.IsDir() {
-			return nil, fmt.Errorf("unable to create file in `%s': `%s' is not a directory", path.Join(pathParts[:amountOfParts-1]...), parentNode.Name())
+	if revision != "" {
+		if err = d.checkRevision(file, revision); err != nil {
+			return err
 		}
 	}
 
-	file, err := d.srv.Files.Create(
-		&drive.File{
-			Name:     sanitizeName(pathParts[amountOfParts-1]),
-			MimeType: mimeTypeFile,
-			Parents: []string{
-				parentNode.item.Id,
-			},
-		},
-	).Fields(fileInfoFields...).Media(r).Do()
+	var updatedFile *drive.File
+	if d.uploadInSession(contents) {
+		updatedFile, err = d.uploadResumable(file.Path(), http.MethodPatch, "files/"+url.PathEscape(file.item.Id), update, contents)
+	} else {
+		updatedFile, err = d.srv.Files.Update(file.item.Id, update).Fields(fileInfoFields...).Media(contents.reader, d.mediaOptions(contents)...).Do()
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &FileInfo{
-		item:       file,
-		parentPath: path.Join(pathParts[:amountOfParts-1]...),
-	}, nil
+	_, err = d.finishContents(updatedFile, contents)
+	return err
 }
 
-func (d *GDriver) updateFileContents(id string, r io.Reader) error {
-	// update file
-	_, err := d.srv.Files.Update(id, nil).Fields(fileInfoFields...).Media(r).Do()
+// checkRevision returns an ErrRevisionConflict if the head revision of the file is not revision
+func (d *GDriver) checkRevision(file *FileInfo, revision string) error {
+	if d.beforeRevisionCheck != nil {
+		d.beforeRevisionCheck()
+	}
+	current, err := d.srv.Files.Get(file.item.Id).Fields("headRevisionId").Do()
 	if err != nil {
 		return err
 	}
+	if current.HeadRevisionId != revision {
+		return ErrRevisionConflict{Path: file.Path(), Expected: revision, Actual: current.HeadRevisionId}
+	}
 	return nil
 }
 
 // Rename renames a file or directory to a new name in the same folder
-func (d *GDriver) Rename(path string, newName string) (*FileInfo, error) {
+func (d *GDriver) Rename(path string, newName string) (_ *FileInfo, err error) {
+	defer d.audit("Rename", path, newName)(&err)
 	newNameParts := strings.FieldsFunc(newName, isPathSeperator)
 	amountOfParts := len(newNameParts)
 	if amountOfParts <= 0 {
 		return nil, errors.New("new name cannot be empty")
 	}
+	if err = d.validateName(newNameParts[amountOfParts-1]); err != nil {
+		return nil, err
+	}
 	file, err := d.getFile(d.rootNode, path)
 	if err != nil {
 		return nil, err
@@ -347,25 +1575,31 @@ func (d *GDriver) Rename(path string, newName string) (*FileInfo, error) {
 	}
 
 	newFile, err := d.srv.Files.Update(file.item.Id, &drive.File{
-		Name: sanitizeName(newNameParts[amountOfParts-1]),
+		Name: d.pathEscaping.unescape(newNameParts[amountOfParts-1]),
 	}).Fields(fileInfoFields...).Do()
 	return &FileInfo{
-		item:       newFile,
-		parentPath: file.parentPath,
+		item:         newFile,
+		parentPath:   file.parentPath,
+		pathEscaping: d.pathEscaping,
 	}, nil
 }
 
 // Move moves a file or directory to a new path, note that move also renames the target if necessary and creates non existing directories
+// Moving a directory into a shared drive fails with a SharedDriveMoveError, see MoveWithCopyFallback
 //
 // Examples:
 //     Move("Folder1/File1", "Folder2/File2") // File1 in Folder1 will be moved to Folder2/File2
 //     Move("Folder1/File1", "Folder2/File1") // File1 in Folder1 will be moved to Folder2/File1
-func (d *GDriver) Move(oldPath, newPath string) (*FileInfo, error) {
+func (d *GDriver) Move(oldPath, newPath string) (_ *FileInfo, err error) {
+	defer d.audit("Move", oldPath, newPath)(&err)
 	pathParts := strings.FieldsFunc(newPath, isPathSeperator)
 	amountOfParts := len(pathParts)
 	if amountOfParts <= 0 {
 		return nil, errors.New("new path cannot be empty")
 	}
+	if err = d.validateName(pathParts[amountOfParts-1]); err != nil {
+		return nil, err
+	}
 
 	file, err := d.getFile(d.rootNode, oldPath, "files(id,parents)")
 	if err != nil {
@@ -390,22 +1624,195 @@ func (d *GDriver) Move(oldPath, newPath string) (*FileInfo, error) {
 	}
 
 	newFile, err := d.srv.Files.Update(file.item.Id, &drive.File{
-		Name: sanitizeName(pathParts[amountOfParts-1]),
+		Name: d.pathEscaping.unescape(pathParts[amountOfParts-1]),
 	}).
 		AddParents(parentNode.item.Id).
 		RemoveParents(path.Join(file.item.Parents...)).
+		SupportsTeamDrives(true).
 		Fields(fileInfoFields...).Do()
 	if err != nil {
+		if isSharedDriveMoveError(err) {
+			return nil, SharedDriveMoveError{Path: oldPath, NewPath: newPath}
+		}
 		return nil, err
 	}
 	return &FileInfo{
-		item:       newFile,
-		parentPath: path.Join(pathParts[:amountOfParts-1]...),
+		item:         newFile,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
 	}, nil
 }
 
+// MoveWithCopyFallback moves a file or directory like Move,
+// if the directory cannot be moved because the move crosses a shared drive boundary
+// its contents will be copied to the new path and the old directory will be deleted.
+// Note that the copies are owned by the destination drive and get new ids.
+// The returned FileInfo is the new directory, its path is relative to the root directory like the one returned by Move.
+func (d *GDriver) MoveWithCopyFallback(oldPath, newPath string) (*FileInfo, error) {
+	fi, err := d.Move(oldPath, newPath)
+	if _, ok := err.(SharedDriveMoveError); !ok {
+		return fi, err
+	}
+
+	// the new directory is created in the destination drive, the copies are created in it
+	newDir, err := d.MakeDirectory(newPath)
+	if err != nil {
+		return nil, err
+	}
+	oldDirPath := strings.Join(strings.FieldsFunc(oldPath, isPathSeperator), "/")
+	err = d.Walk(oldPath, func(f *FileInfo) error {
+		targetPath := path.Join(newPath, strings.TrimPrefix(strings.TrimPrefix(f.Path(), oldDirPath), "/"))
+		if f.IsDir() {
+			_, err := d.MakeDirectory(targetPath)
+			return err
+		}
+		_, err := d.DuplicateFile(f.Path(), targetPath)
+		return err
+	}, WalkSorted())
+	if err != nil {
+		if cbErr, ok := err.(CallbackError); ok {
+			return nil, cbErr.NestedError
+		}
+		return nil, err
+	}
+
+	if err = d.DeleteDirectory(oldPath); err != nil {
+		return nil, err
+	}
+	return newDir, nil
+}
+
+// isSharedDriveMoveError returns true if the error was caused by moving a directory into a shared drive
+func isSharedDriveMoveError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "teamDrivesFolderMoveInNotSupported", "cannotMoveFolderIntoSharedDrive":
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateFileOptions controls which information DuplicateFileWithOptions copies to the duplicate
+type DuplicateFileOptions struct {
+	// CopyMetadata copies the description and the starred flag
+	CopyMetadata bool
+	// CopyProperties copies the properties and appProperties
+	CopyProperties bool
+	// CopyPermissions recreates the permissions of the file on the duplicate, owner and inherited permissions are skipped
+	CopyPermissions bool
+}
+
+// DuplicateFile creates a copy of a file on the drive, the copy will have the same metadata and properties as the original.
+// It creates non existing directories for the new path.
+//
+// Examples:
+//     DuplicateFile("Folder1/File1", "Folder2/File1") // creates a copy of File1 in Folder2
+func (d *GDriver) DuplicateFile(path, newPath string) (*FileInfo, error) {
+	return d.DuplicateFileWithOptions(path, newPath, DuplicateFileOptions{
+		CopyMetadata:   true,
+		CopyProperties: true,
+	})
+}
+
+// DuplicateFileWithOptions creates a copy of a file on the drive, use opts to control which information will be copied.
+// If permissions could not be recreated (see CopyPermissions) the duplicate is kept and returned together with a PermissionCopyError.
+func (d *GDriver) DuplicateFileWithOptions(filePath, newPath string, opts DuplicateFileOptions) (_ *FileInfo, err error) {
+	defer d.audit("DuplicateFile", filePath, newPath)(&err)
+	pathParts := strings.FieldsFunc(newPath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("new path cannot be empty")
+	}
+
+	file, err := d.getFile(d.rootNode, filePath, "files(id,mimeType,properties,appProperties)")
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: filePath}
+	}
+
+	// the new file must not exist
+	_, err = d.getFileByParts(d.rootNode, pathParts)
+	if err == nil {
+		return nil, FileExistError{Path: newPath}
+	}
+	if !IsNotExist(err) {
+		return nil, err
+	}
+
+	parentNode := d.rootNode
+	if amountOfParts > 1 {
+		dir, err := d.makeDirectoryByParts(pathParts[:amountOfParts-1])
+		if err != nil {
+			return nil, err
+		}
+		parentNode = dir
+
+		if !parentNode.IsDir() {
+			return nil, fmt.Errorf("unable to create file in `%s': `%s' is not a directory", path.Join(pathParts[:amountOfParts-1]...), parentNode.Name())
+		}
+	}
+
+	duplicate := &drive.File{
+		Name: d.pathEscaping.unescape(pathParts[amountOfParts-1]),
+		Parents: []string{
+			parentNode.item.Id,
+		},
+	}
+	if !opts.CopyMetadata {
+		duplicate.ForceSendFields = []string{"Description", "Starred"}
+	}
+
+	newFile, err := d.srv.Files.Copy(file.item.Id, duplicate).SupportsTeamDrives(true).Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.CopyProperties {
+		// the content properties must be kept, otherwise the contents cannot be read anymore
+		var properties, appProperties []string
+		for key := range file.item.Properties {
+			properties = append(properties, key)
+		}
+		for key := range file.item.AppProperties {
+			if !isContentAppProperty(key) {
+				appProperties = append(appProperties, key)
+			}
+		}
+
+		if len(properties) > 0 || len(appProperties) > 0 {
+			update := &drive.File{}
+			removeMapKeys(update, "Properties", properties)
+			removeMapKeys(update, "AppProperties", appProperties)
+			newFile, err = d.srv.Files.Update(newFile.Id, update).SupportsTeamDrives(true).Fields(fileInfoFields...).Do()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fi := &FileInfo{
+		item:         newFile,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
+	}
+	if opts.CopyPermissions {
+		if err = d.copyPermissions(file.item.Id, newFile.Id, fi.Path()); err != nil {
+			return fi, err
+		}
+	}
+	return fi, nil
+}
+
 // Trash trashes a file or directory
-func (d *GDriver) Trash(path string) error {
+func (d *GDriver) Trash(path string) (err error) {
+	defer d.audit("Trash", path, "")(&err)
 	file, err := d.getFile(d.rootNode, path, "files(id)")
 	if err != nil {
 		return err
@@ -414,14 +1821,197 @@ func (d *GDriver) Trash(path string) error {
 	if file == d.rootNode {
 		return errors.New("root cannot be trashed")
 	}
+	return d.trashFile(file)
+}
+
+// SetFileStar stars or unstars a file or directory
+func (d *GDriver) SetFileStar(path string, starred bool) (err error) {
+	defer d.audit("SetFileStar", path, "")(&err)
+	_, err = d.setFileStar(path, starred)
+	return err
+}
+
+// Star stars a file or directory
+func (d *GDriver) Star(path string) error {
+	return d.SetFileStar(path, true)
+}
+
+// Unstar unstars a file or directory
+func (d *GDriver) Unstar(path string) error {
+	return d.SetFileStar(path, false)
+}
+
+// SetFolderColor sets the color of a directory, rgb must be one of the colors of FolderColorPalette
+//
+// Examples:
+//     SetFolderColor("Projects/Website", "#fa573c")
+func (d *GDriver) SetFolderColor(path string, rgb string) (err error) {
+	defer d.audit("SetFolderColor", path, rgb)(&err)
+	palette, err := d.FolderColorPalette()
+	if err != nil {
+		return err
+	}
+	color := ""
+	for _, c := range palette {
+		if strings.EqualFold(c, rgb) {
+			color = c
+			break
+		}
+	}
+	if color == "" {
+		return InvalidFolderColorError{Color: rgb, Palette: palette}
+	}
 
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return FileIsNotDirectoryError{Path: path}
+	}
 	_, err = d.srv.Files.Update(file.item.Id, &drive.File{
-		Trashed: true,
+		FolderColorRgb: color,
 	}).Do()
 	return err
 }
 
+func (d *GDriver) setFileStar(path string, starred bool) (*FileInfo, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+	if file == d.rootNode {
+		return nil, errors.New("root cannot be starred")
+	}
+
+	item, err := d.srv.Files.Update(file.item.Id, &drive.File{
+		Starred:         starred,
+		ForceSendFields: []string{"Starred"},
+	}).Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         item,
+		parentPath:   file.parentPath,
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// UntrashOptions controls where Untrash restores a file whose original parent directory does not exist anymore
+type UntrashOptions struct {
+	// RecreatePath recreates the original parent directories
+	RecreatePath bool
+	// FallbackPath is the directory the file will be restored to if RecreatePath is false
+	FallbackPath string
+}
+
+// Untrash restores a trashed file or directory, path is the path of the file before it was trashed (see ListTrash).
+// If the original parent directory does not exist anymore (or is trashed itself) the file will be restored as specified in opts,
+// if opts are empty a FileNotExistError for the parent directory will be returned.
+// A FileExistError will be returned if the destination already contains a file with the same name.
+func (d *GDriver) Untrash(filePath string, opts UntrashOptions) (_ *FileInfo, err error) {
+	defer d.audit("Untrash", filePath, "")(&err)
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("root cannot be untrashed")
+	}
+	parentParts := pathParts[:amountOfParts-1]
+
+	file, err := d.getTrashedFile(pathParts)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationParts := parentParts
+	parentNode, err := d.getFileByParts(d.rootNode, parentParts, "files(id,mimeType)")
+	if err != nil && !IsNotExist(err) {
+		return nil, err
+	}
+	if err != nil || !containsString(file.Parents, parentNode.item.Id) {
+		// the original parent does not exist anymore
+		switch {
+		case opts.RecreatePath:
+		case opts.FallbackPath != "":
+			destinationParts = strings.FieldsFunc(opts.FallbackPath, isPathSeperator)
+		default:
+			return nil, FileNotExistError{Path: path.Join(parentParts...)}
+		}
+		if parentNode, err = d.makeDirectoryByParts(destinationParts); err != nil {
+			return nil, err
+		}
+		if !parentNode.IsDir() {
+			return nil, FileIsNotDirectoryError{Path: path.Join(destinationParts...)}
+		}
+	}
+
+	// the destination must not contain a file with the same name
+	newParts := append(append([]string{}, destinationParts...), pathParts[amountOfParts-1])
+	if _, err = d.getFileByParts(d.rootNode, newParts); err == nil {
+		return nil, FileExistError{Path: path.Join(newParts...)}
+	} else if !IsNotExist(err) {
+		return nil, err
+	}
+
+	call := d.srv.Files.Update(file.Id, &drive.File{
+		Trashed:         false,
+		ForceSendFields: []string{"Trashed"},
+	})
+	if !containsString(file.Parents, parentNode.item.Id) {
+		call = call.AddParents(parentNode.item.Id).RemoveParents(strings.Join(file.Parents, ","))
+	}
+	item, err := call.Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         item,
+		parentPath:   path.Join(destinationParts...),
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// getTrashedFile finds a trashed file by its original path,
+// if no file matches the path a trashed file with the same name whose parents do not exist anymore will be returned
+func (d *GDriver) getTrashedFile(pathParts []string) (*drive.File, error) {
+	amountOfParts := len(pathParts)
+	parentPath := path.Join(pathParts[:amountOfParts-1]...)
+	query := fmt.Sprintf("trashed = true and %s", d.nameQuery(pathParts[amountOfParts-1]))
+
+	var match *drive.File
+	var orphans []*drive.File
+	err := d.listFiles(query, googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields))), func(file *drive.File) error {
+		if match != nil {
+			return nil
+		}
+		inRoot, filePath, err := d.isInRoot(d.rootNode.item.Id, file, "")
+		if err != nil {
+			return err
+		}
+		if !inRoot {
+			orphans = append(orphans, file)
+			return nil
+		}
+		if filePath == parentPath {
+			match = file
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if match != nil {
+		return match, nil
+	}
+	if len(orphans) == 1 {
+		return orphans[0], nil
+	}
+	return nil, FileNotExistError{Path: path.Join(pathParts...)}
+}
+
 // ListTrash lists the contents of the trash, if you specify directories it will only list the trash contents of the specified directories
+// The listed files provide TrashedTime() and ExplicitlyTrashed(), return SkipAll in fileFunc to stop the listing
 func (d *GDriver) ListTrash(filePath string, fileFunc func(f *FileInfo) error) error {
 	file, err := d.getFile(d.rootNode, filePath, "files(id,name)")
 	if err != nil {
@@ -429,31 +2019,94 @@ func (d *GDriver) ListTrash(filePath string, fileFunc func(f *FileInfo) error) e
 	}
 
 	// no directories specified
-	files, err := d.srv.Files.List().Q("trashed = true").Fields(googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields)))).Do()
+	files, err := d.srv.Files.List().Q("trashed = true").Fields(googleapi.Field(fmt.Sprintf("files(%s,parents,trashedTime,explicitlyTrashed)", googleapi.CombineFields(fileInfoFields)))).Do()
 	if err != nil {
 		return err
 	}
 
+	// the root directory has no path, file.Path() would be the name of the root directory
+	dirPath := strings.Join(strings.FieldsFunc(filePath, isPathSeperator), "/")
 	for i := 0; i < len(files.Files); i++ {
 		// determinate the parent of this file
 
-		inRoot, parentPath, err := isInRoot(d.srv, file.item.Id, files.Files[i], "")
+		inRoot, parentPath, err := d.isInRoot(file.item.Id, files.Files[i], "")
 		if err != nil {
 			return err
 		}
 
 		if inRoot {
 			if err = fileFunc(&FileInfo{
-				item:       files.Files[i],
-				parentPath: path.Join(file.Path(), parentPath),
+				item:         files.Files[i],
+				parentPath:   path.Join(dirPath, parentPath),
+				pathEscaping: d.pathEscaping,
 			}); err != nil {
-				return CallbackError{NestedError: err}
+				return callbackError(err)
 			}
 		}
 	}
 	return nil
 }
 
+// ListTrashDirect lists the trashed files and directories whose parent is the directory path.
+// It is much faster than ListTrash, but it only finds items whose parent is still that directory,
+// trashed descendants of trashed directories will not be listed. Return SkipAll in fileFunc to stop the listing.
+func (d *GDriver) ListTrashDirect(path string, fileFunc func(f *FileInfo) error) error {
+	file, err := d.getFile(d.rootNode, path, "files(id,name,mimeType)")
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return FileIsNotDirectoryError{Path: path}
+	}
+
+	query := fmt.Sprintf("'%s' in parents and trashed = true", file.item.Id)
+	fields := googleapi.Field(fmt.Sprintf("files(%s,trashedTime,explicitlyTrashed)", googleapi.CombineFields(fileInfoFields)))
+	return d.listFiles(query, fields, func(item *drive.File) error {
+		if err := fileFunc(&FileInfo{
+			item:         item,
+			parentPath:   file.Path(),
+			pathEscaping: d.pathEscaping,
+		}); err != nil {
+			return wrapCallbackError(err)
+		}
+		return nil
+	})
+}
+
+// ListOrphanedFiles lists all files (that are not trashed) which are not reachable from the current root directory,
+// e.g. because their parent directory was deleted.
+// Note that the Path() of the listed files is just their name, because they have no path in the root directory.
+// Return SkipAll in fileFunc to stop the listing.
+func (d *GDriver) ListOrphanedFiles(fileFunc func(f *FileInfo) error) error {
+	return d.listFiles("trashed = false", googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields))), func(file *drive.File) error {
+		if file.Id == d.rootNode.item.Id {
+			return nil
+		}
+		inRoot, _, err := d.isInRoot(d.rootNode.item.Id, file, "")
+		if err != nil {
+			return err
+		}
+		if inRoot {
+			return nil
+		}
+		if err = fileFunc(&FileInfo{item: file, pathEscaping: d.pathEscaping}); err != nil {
+			return wrapCallbackError(err)
+		}
+		return nil
+	})
+}
+
+// removeMapKeys marks the keys of the map field (e.g. AppProperties) of file for removal
+func removeMapKeys(file *drive.File, field string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	for _, key := range keys {
+		file.NullFields = append(file.NullFields, field+"."+key)
+	}
+	file.ForceSendFields = append(file.ForceSendFields, field)
+}
+
 func getRootNode(srv *drive.Service) (*FileInfo, error) {
 	root, err := srv.Files.Get("root").Fields(fileInfoFields...).Do()
 	if err != nil {
@@ -466,16 +2119,20 @@ func getRootNode(srv *drive.Service) (*FileInfo, error) {
 }
 
 // isInRoot checks if a file is a descendant of root, if so it will return the parent path of the file
-func isInRoot(srv *drive.Service, rootID string, file *drive.File, basePath string) (bool, string, error) {
+func (d *GDriver) isInRoot(rootID string, file *drive.File, basePath string) (bool, string, error) {
 	for _, parentID := range file.Parents {
 		if parentID == rootID {
 			return true, basePath, nil
 		}
-		parent, err := srv.Files.Get(parentID).Fields("id,name,parents").Do()
+		parent, err := d.srv.Files.Get(parentID).Fields("id,name,parents").Do()
 		if err != nil {
+			if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+				// the parent was deleted
+				continue
+			}
 			return false, "", err
 		}
-		if inRoot, parentPath, err := isInRoot(srv, rootID, parent, path.Join(parent.Name, basePath)); err != nil || inRoot {
+		if inRoot, parentPath, err := d.isInRoot(rootID, parent, path.Join(d.pathEscaping.escape(parent.Name), basePath)); err != nil || inRoot {
 			return inRoot, parentPath, err
 		}
 	}
@@ -498,7 +2155,7 @@ func (d *GDriver) getFileByParts(rootNode *FileInfo, pathParts []string, fields
 	lastPart := amountOfParts - 1
 	var lastFile *drive.File
 	for i := 0; i < amountOfParts; i++ {
-		query := fmt.Sprintf("'%s' in parents and name='%s' and trashed = false", lastID, sanitizeName(pathParts[i]))
+		query := fmt.Sprintf("'%s' in parents and %s and trashed = false", lastID, d.nameQuery(pathParts[i]))
 		// log.Println(query)
 		call := d.srv.Files.List().Q(query)
 
@@ -528,8 +2185,9 @@ func (d *GDriver) getFileByParts(rootNode *FileInfo, pathParts []string, fields
 	}
 
 	return &FileInfo{
-		item:       lastFile,
-		parentPath: path.Join(pathParts[:amountOfParts-1]...),
+		item:         lastFile,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
 	}, nil
 }
 
@@ -541,15 +2199,15 @@ const (
 	O_CREATE OpenFlag = 1 << iota
 )
 
-// Open opens a file in the traditional os.Open way
-func (d *GDriver) Open(path string, flag OpenFlag) (File, error) {
+// Open opens a file in the traditional os.Open way, opts are used when the file is opened for writing (see PutFile)
+func (d *GDriver) Open(path string, flag OpenFlag, opts ...PutOption) (File, error) {
 	// plausibility check
 	if flag&O_RDONLY != 0 && flag&O_WRONLY != 0 {
 		return nil, errors.New("unable to open a file read and write at the same time")
 	}
 
 	// determinate existent status
-	file, err := d.getFile(d.rootNode, path)
+	file, err := d.getFile(d.rootNode, path, listFields...)
 	fileExists := false
 
 	if err == nil {
@@ -595,6 +2253,7 @@ func (d *GDriver) Open(path string, flag OpenFlag) (File, error) {
 			Driver:   d,
 			Path:     path,
 			FileInfo: file,
+			options:  opts,
 		}, nil
 	}
 	return nil, fmt.Errorf("unknown flag: %d", flag)
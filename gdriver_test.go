@@ -13,19 +13,34 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Eun/gdriver/drivetest"
+	"github.com/Eun/gdriver/internal/cassette"
 	"github.com/Eun/gdriver/oauthhelper"
 	"github.com/hjson/hjson-go"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
+	drive "google.golang.org/api/drive/v3"
 	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 )
 
-func setup(t *testing.T) (*GDriver, func()) {
+// cassetteDir holds the interactions recorded with RECORD=1 (see internal/cassette)
+const cassetteDir = "testdata/cassettes"
+
+// setup creates a driver with an empty test directory as root directory.
+// With GOOGLE_TOKEN the tests run against drive (RECORD=1 records the interactions to cassetteDir),
+// without it the recorded interactions of the test are replayed or, if there are none, the drivetest fake is used.
+// RECORD=1 without GOOGLE_TOKEN records the interactions with the fake.
+func setup(t testing.TB) (*GDriver, func()) {
 	env, err := ioutil.ReadFile(".env.json")
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -55,23 +70,63 @@ func setup(t *testing.T) (*GDriver, func()) {
 	var driver *GDriver
 	var token []byte
 
-	token, err = base64.StdEncoding.DecodeString(os.Getenv("GOOGLE_TOKEN"))
-	require.NoError(t, err)
+	closeFake := func() {}
+	// finish is called after the test directory was deleted
+	finish := func() {}
+	cassettePath := cassette.Path(cassetteDir, t.Name())
+	record := os.Getenv("RECORD") == "1"
+	if os.Getenv("GOOGLE_TOKEN") == "" && !record && cassette.Exists(cassettePath) {
+		// no credentials, replay the recorded interactions
+		replayer, err := cassette.Load(cassettePath)
+		require.NoError(t, err)
+		driver, err = NewWithService(context.Background(), []option.ClientOption{option.WithHTTPClient(&http.Client{Transport: replayer})})
+		require.NoError(t, err)
+		finish = func() {
+			require.Equal(t, 0, replayer.Remaining(), "not all recorded interactions were replayed")
+		}
+	} else if os.Getenv("GOOGLE_TOKEN") == "" {
+		// no credentials and no recording, run against the fake
+		fake := drivetest.NewServer()
+		closeFake = fake.Close
+		if record {
+			// record the interactions with the fake as if they were sent to drive
+			rec := cassette.NewRecorder(&fakeEndpointTransport{fake: fake})
+			finish = func() {
+				require.NoError(t, rec.Save(cassettePath))
+			}
+			driver, err = NewWithService(context.Background(), []option.ClientOption{option.WithHTTPClient(&http.Client{Transport: rec})})
+		} else {
+			driver, err = NewWithService(context.Background(), fake.ClientOptions())
+		}
+		require.NoError(t, err)
+	} else {
+		token, err = base64.StdEncoding.DecodeString(os.Getenv("GOOGLE_TOKEN"))
+		require.NoError(t, err)
 
-	helper.Token = new(oauth2.Token)
+		helper.Token = new(oauth2.Token)
 
-	require.NoError(t, json.Unmarshal([]byte(token), helper.Token))
+		require.NoError(t, json.Unmarshal([]byte(token), helper.Token))
 
-	client, err = helper.NewHTTPClient(context.Background())
-	require.NoError(t, err)
+		client, err = helper.NewHTTPClient(context.Background())
+		require.NoError(t, err)
+
+		if record {
+			// record the interactions to replay them without credentials
+			rec := cassette.NewRecorder(client.Transport)
+			client = &http.Client{Transport: rec}
+			finish = func() {
+				require.NoError(t, rec.Save(cassettePath))
+			}
+		}
 
-	driver, err = New(client)
+		driver, err = New(client)
 
-	require.NoError(t, err)
+		require.NoError(t, err)
+	}
 
 	// prepare test directory
 
-	fullPath := sanitizeName(fmt.Sprintf("GDriveTest-%s", t.Name()))
+	fullPath := strings.Replace(fmt.Sprintf("GDriveTest-%s", t.Name()), "/", "-", -1)
 	driver.DeleteDirectory(fullPath)
 	_, err = driver.MakeDirectory(fullPath)
 	require.NoError(t, err)
@@ -83,10 +138,81 @@ func setup(t *testing.T) (*GDriver, func()) {
 		_, err = driver.SetRootDirectory("")
 		require.NoError(t, err)
 		require.NoError(t, driver.DeleteDirectory(fullPath))
+		closeFake()
+		finish()
+	}
+}
+
+// fakeEndpointTransport sends the requests for the default drive endpoint to the fake,
+// so the recorded interactions can be replayed with the default endpoint
+type fakeEndpointTransport struct {
+	fake *drivetest.Server
+}
+
+func (t *fakeEndpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fakeURL, err := url.Parse(t.fake.URL)
+	if err != nil {
+		return nil, err
+	}
+	clone := new(http.Request)
+	*clone = *req
+	u := *req.URL
+	u.Scheme, u.Host = fakeURL.Scheme, fakeURL.Host
+	clone.URL = &u
+	clone.Host = ""
+	resp, err := t.fake.Client().Transport.RoundTrip(clone)
+	if err != nil {
+		return nil, err
+	}
+	// resumable upload sessions point to the fake
+	if location := resp.Header.Get("Location"); location != "" {
+		resp.Header.Set("Location", strings.Replace(location, t.fake.URL, req.URL.Scheme+"://"+req.URL.Host, 1))
 	}
+	return resp, nil
 }
 
 func TestMakeDirectory(t *testing.T) {
+	t.Run("concurrent creation", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		var wg sync.WaitGroup
+		ids := make([]string, 10)
+		errs := make([]error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var fi *FileInfo
+				if i%2 == 0 {
+					fi, errs[i] = driver.MakeDirectory("jobs/it's 2024-01-15")
+				} else {
+					fi, errs[i] = driver.PutFile(fmt.Sprintf("jobs/it's 2024-01-15/File%d", i), bytes.NewBufferString("Hello World"))
+				}
+				if fi != nil && fi.IsDir() {
+					ids[i] = fi.item.Id
+				}
+			}(i)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		for i := 2; i < 10; i += 2 {
+			require.Equal(t, ids[0], ids[i])
+		}
+
+		var dirs int
+		require.NoError(t, driver.ListDirectory("jobs", func(f *FileInfo) error {
+			dirs++
+			return nil
+		}))
+		require.Equal(t, 1, dirs)
+		files, _, err := driver.GetFilesCount("jobs/it's 2024-01-15")
+		require.NoError(t, err)
+		require.Equal(t, 5, files)
+	})
+
 	t.Run("simple creation", func(t *testing.T) {
 		driver, teardown := setup(t)
 		defer teardown()
@@ -161,6 +287,39 @@ func TestMakeDirectory(t *testing.T) {
 	})
 }
 
+func TestGetOrCreateDirectory(t *testing.T) {
+	t.Run("create and get", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		fi, created, err := driver.GetOrCreateDirectory("Folder1/Folder2")
+		require.NoError(t, err)
+		require.True(t, created)
+		require.True(t, fi.IsDir())
+		require.Equal(t, "Folder1/Folder2", fi.Path())
+
+		fi2, created, err := driver.GetOrCreateDirectory("Folder1/Folder2")
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, fi.item.Id, fi2.item.Id)
+
+		// only the parent existed
+		_, created, err = driver.GetOrCreateDirectory("Folder1")
+		require.NoError(t, err)
+		require.False(t, created)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+
+		_, _, err := driver.GetOrCreateDirectory("File1")
+		require.EqualError(t, err, "`File1' is not a directory")
+	})
+}
+
 func TestPutFile(t *testing.T) {
 	t.Run("in root folder", func(t *testing.T) {
 		driver, teardown := setup(t)
@@ -225,6 +384,115 @@ func TestPutFile(t *testing.T) {
 		require.EqualError(t, getError(driver.PutFile("", bytes.NewBufferString("Hello World"))), "path cannot be empty")
 	})
 
+	t.Run("from reader", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		fi, err := driver.PutFileFromReader("File1", strings.NewReader("Hello World"), 5)
+		require.NoError(t, err)
+		require.Equal(t, "File1", fi.Path())
+
+		_, r, err := driver.GetFile("File1")
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello", string(received))
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/report.pdf", "Version 1")
+
+		fi, err := driver.PutFile("Folder1/report.pdf", bytes.NewBufferString("Version 2"), PutConflict(ConflictRename))
+		require.NoError(t, err)
+		require.Equal(t, "Folder1/report (1).pdf", fi.Path())
+		fi, err = driver.PutFile("Folder1/report.pdf", bytes.NewBufferString("Version 3"), PutConflict(ConflictRename))
+		require.NoError(t, err)
+		require.Equal(t, "Folder1/report (2).pdf", fi.Path())
+		fi, err = driver.PutFile("Folder2/report.pdf", bytes.NewBufferString("Version 1"), PutConflict(ConflictRename))
+		require.NoError(t, err)
+		require.Equal(t, "Folder2/report.pdf", fi.Path())
+
+		_, err = driver.PutFile("Folder1/report.pdf", bytes.NewBufferString("Version 4"), PutConflict(ConflictError))
+		require.IsType(t, FileExistError{}, err)
+
+		r := bytes.NewBufferString("Version 4")
+		_, err = driver.PutFile("Folder1/report.pdf", r, PutConflict(ConflictSkip))
+		require.NoError(t, err)
+		require.Equal(t, "Version 4", r.String())
+
+		for name, expected := range map[string]string{
+			"Folder1/report.pdf":     "Version 1",
+			"Folder1/report (1).pdf": "Version 2",
+			"Folder1/report (2).pdf": "Version 3",
+		} {
+			_, r, err := driver.GetFile(name)
+			require.NoError(t, err)
+			received, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, expected, string(received))
+		}
+	})
+
+	t.Run("if revision", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+		fi, err := driver.Stat("File1")
+		require.NoError(t, err)
+		require.NotEmpty(t, fi.HeadRevisionID())
+
+		fi, err = driver.PutFile("File1", bytes.NewBufferString("Hello Universe"), IfRevision(fi.HeadRevisionID()))
+		require.NoError(t, err)
+		fi, err = driver.Stat("File1")
+		require.NoError(t, err)
+
+		// another writer updates the file right before our update
+		other := *driver
+		driver.beforeRevisionCheck = func() {
+			driver.beforeRevisionCheck = nil
+			newFile(t, &other, "File1", "Hello Mars")
+		}
+		_, err = driver.PutFile("File1", bytes.NewBufferString("Hello Venus"), IfRevision(fi.HeadRevisionID()))
+		require.IsType(t, ErrRevisionConflict{}, err)
+
+		f, err := driver.Open("File1", O_WRONLY, IfRevision(fi.HeadRevisionID()))
+		require.NoError(t, err)
+		// the write might fail already
+		f.Write([]byte("Hello Venus")) // nolint: errcheck
+		require.IsType(t, ErrRevisionConflict{}, f.Close())
+
+		_, r, err := driver.GetFile("File1")
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello Mars", string(received))
+	})
+
+	t.Run("ensure file", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		fi1, created, err := driver.EnsureFile("Folder1/File1", bytes.NewBufferString("Hello World"))
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, "Folder1/File1", fi1.Path())
+
+		r := bytes.NewBufferString("Hello Universe")
+		fi2, created, err := driver.EnsureFile("Folder1/File1", r)
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, fi1.item.Id, fi2.item.Id)
+		// the reader was not consumed
+		require.Equal(t, "Hello Universe", r.String())
+
+		_, _, err = driver.EnsureFile("Folder1", bytes.NewBufferString("Hello World"))
+		require.IsType(t, FileIsDirectoryError{}, err)
+	})
+
 	t.Run("overwrite file", func(t *testing.T) {
 		driver, teardown := setup(t)
 		defer teardown()
@@ -281,6 +549,48 @@ func TestGetFile(t *testing.T) {
 	require.EqualError(t, err, "`Folder1' is a directory")
 }
 
+func TestIsNotExist(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder2/File2", "Hello World")
+	require.NoError(t, driver.Trash("Folder2/File2"))
+	require.NoError(t, driver.Delete("Folder2"))
+
+	for name, fn := range map[string]func() error{
+		"Stat":                   func() error { return getError(driver.Stat("Folder1/File2")) },
+		"Stat in missing parent": func() error { return getError(driver.Stat("Folder3/File1")) },
+		"ListDirectory": func() error {
+			return driver.ListDirectory("Folder3", func(*FileInfo) error { return nil })
+		},
+		"Walk":             func() error { return driver.Walk("Folder3", func(*FileInfo) error { return nil }) },
+		"GetFile":          func() error { _, _, err := driver.GetFile("Folder1/File2"); return err },
+		"GetFileHash":      func() error { _, _, err := driver.GetFileHash("Folder1/File2", HashMethodMD5); return err },
+		"GetFileContentAt": func() error { _, err := driver.GetFileContentAt("Folder1/File2", 0, 1); return err },
+		"Delete":           func() error { return driver.Delete("Folder1/File2") },
+		"DeleteDirectory":  func() error { return driver.DeleteDirectory("Folder3") },
+		"Rename":           func() error { return getError(driver.Rename("Folder1/File2", "File3")) },
+		"Move":             func() error { return getError(driver.Move("Folder1/File2", "Folder1/File3")) },
+		"DuplicateFile":    func() error { return getError(driver.DuplicateFile("Folder1/File2", "Folder1/File3")) },
+		"Trash":            func() error { return driver.Trash("Folder1/File2") },
+		"SetFileStar":      func() error { return driver.SetFileStar("Folder1/File2", true) },
+		"Untrash":          func() error { return getError(driver.Untrash("Folder1/File2", UntrashOptions{})) },
+		"Untrash without parent": func() error {
+			return getError(driver.Untrash("Folder2/File2", UntrashOptions{}))
+		},
+		"Open": func() error { _, err := driver.Open("Folder1/File2", O_RDONLY); return err },
+		"wrapped": func() error {
+			_, err := driver.Stat("Folder1/File2")
+			return fmt.Errorf("stat failed: %w", err)
+		},
+	} {
+		err := fn()
+		require.Error(t, err, name)
+		require.True(t, IsNotExist(err), "%s: %v", name, err)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	t.Run("delete file", func(t *testing.T) {
 		driver, teardown := setup(t)
@@ -337,6 +647,87 @@ func TestDeleteDirectory(t *testing.T) {
 	})
 }
 
+func TestFolderCreation(t *testing.T) {
+	t.Run("auto is default", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		require.Equal(t, FolderCreationAuto, driver.folderCreation)
+		_, err := driver.PutFile("Folder1/File1", bytes.NewBufferString("Hello World"))
+		require.NoError(t, err)
+		fi, err := driver.Stat("Folder1")
+		require.NoError(t, err)
+		require.True(t, fi.IsDir())
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+		require.NoError(t, WithFolderCreation(FolderCreationFail)(driver))
+
+		_, err := driver.PutFile("Folder1/Folder2/File1", bytes.NewBufferString("Hello World"))
+		require.True(t, IsNotExist(err))
+		require.EqualError(t, err, "`Folder1' does not exist")
+		require.True(t, IsNotExist(getError(driver.Stat("Folder1"))))
+
+		_, err = driver.Touch("Folder1/File1")
+		require.True(t, IsNotExist(err))
+
+		// existing parents can be used
+		newDirectory(t, driver, "Folder1")
+		_, err = driver.PutFile("Folder1/File1", bytes.NewBufferString("Hello World"))
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		require.EqualError(t, WithFolderCreation(FolderCreationPolicy(5))(&GDriver{}), "unknown folder creation policy FolderCreationPolicy(5)")
+	})
+}
+
+func TestPurgeDirectory(t *testing.T) {
+	purges := map[string]func(driver *GDriver, path string) error{
+		"purge":           (*GDriver).PurgeDirectory,
+		"purge recursive": (*GDriver).PurgeDirectoryRecursive,
+	}
+	for name, purge := range purges {
+		purge := purge
+		t.Run(name, func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+
+			newFile(t, driver, "Folder1/File1", "Hello World")
+			newFile(t, driver, "Folder1/File2", "Hello World")
+			newFile(t, driver, "Folder1/File3", "Hello World")
+			newFile(t, driver, "Folder1/Folder2/File4", "Hello World")
+			newDirectory(t, driver, "Folder1/Folder3")
+
+			require.NoError(t, purge(driver, "Folder1"))
+
+			// Folder1 should still exist
+			fi, err := driver.Stat("Folder1")
+			require.NoError(t, err)
+			require.True(t, fi.IsDir())
+
+			var files []*FileInfo
+			require.NoError(t, driver.ListDirectory("Folder1", func(f *FileInfo) error {
+				files = append(files, f)
+				return nil
+			}))
+			require.Len(t, files, 0)
+		})
+	}
+
+	t.Run("purge file", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+
+		require.EqualError(t, driver.PurgeDirectory("File1"), "`File1' is not a directory")
+		require.NoError(t, getError(driver.Stat("File1")))
+	})
+}
+
 func TestListDirectory(t *testing.T) {
 	t.Run("standart", func(t *testing.T) {
 		driver, teardown := setup(t)
@@ -415,6 +806,44 @@ func TestListDirectory(t *testing.T) {
 	})
 }
 
+func TestTouch(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	fi, err := driver.Touch("Folder1/_SUCCESS")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/_SUCCESS", fi.Path())
+	require.EqualValues(t, 0, fi.Size())
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	before, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	driver.clock = func() time.Time {
+		return before.ModifiedTime().Add(time.Hour)
+	}
+
+	fi, err = driver.Touch("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, before.DriveFile().Id, fi.DriveFile().Id)
+	require.True(t, fi.ModifiedTime().Equal(before.ModifiedTime().Add(time.Hour)))
+
+	_, r, err := driver.GetFile("Folder1/File1")
+	require.NoError(t, err)
+	received, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(received))
+
+	dir, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+	driver.clock = func() time.Time {
+		return dir.ModifiedTime().Add(2 * time.Hour)
+	}
+	fi, err = driver.Touch("Folder1")
+	require.NoError(t, err)
+	require.True(t, fi.IsDir())
+	require.True(t, fi.ModifiedTime().Equal(dir.ModifiedTime().Add(2*time.Hour)))
+}
+
 func TestRename(t *testing.T) {
 	t.Run("rename with simple name", func(t *testing.T) {
 		driver, teardown := setup(t)
@@ -565,6 +994,149 @@ func TestMove(t *testing.T) {
 	})
 }
 
+func TestIsSharedDriveMoveError(t *testing.T) {
+	require.True(t, isSharedDriveMoveError(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "teamDrivesFolderMoveInNotSupported"}},
+	}))
+	require.False(t, isSharedDriveMoveError(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "insufficientFilePermissions"}},
+	}))
+	require.False(t, isSharedDriveMoveError(errors.New("some error")))
+}
+
+func TestMoveWithCopyFallback(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/Folder2/File1", "Hello World")
+
+	// without a shared drive involved this is a plain move
+	fi, err := driver.MoveWithCopyFallback("Folder1", "Folder3/Folder1")
+	require.NoError(t, err)
+	require.Equal(t, "Folder3/Folder1", fi.Path())
+
+	_, err = driver.Stat("Folder3/Folder1/Folder2/File1")
+	require.NoError(t, err)
+	_, err = driver.Stat("Folder1")
+	require.True(t, IsNotExist(err))
+
+	t.Run("into shared drive", func(t *testing.T) {
+		fake := drivetest.NewServer()
+		defer fake.Close()
+
+		// the fake has no shared drives, the move of the directory fails like a move into a shared drive
+		var teamDriveRequests []string
+		transport := &fakeEndpointTransport{fake: fake}
+		driver, err := NewWithService(context.Background(), []option.ClientOption{option.WithHTTPClient(&http.Client{Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPatch && req.URL.Query().Get("addParents") != "" {
+				return &http.Response{
+					StatusCode: http.StatusForbidden,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":403,"errors":[{"reason":"teamDrivesFolderMoveInNotSupported"}]}}`)),
+					Request:    req,
+				}, nil
+			}
+			if req.Method != http.MethodGet && req.URL.Query().Get("supportsTeamDrives") == "true" {
+				teamDriveRequests = append(teamDriveRequests, req.Method+" "+req.URL.Path)
+			}
+			return transport.RoundTrip(req)
+		})})})
+		require.NoError(t, err)
+
+		newFile(t, driver, "Folder1/Folder2/File1", "Hello World")
+		dir, err := driver.Stat("Folder1")
+		require.NoError(t, err)
+		file, err := driver.Stat("Folder1/Folder2/File1")
+		require.NoError(t, err)
+
+		fi, err := driver.MoveWithCopyFallback("Folder1", "Shared/Folder1")
+		require.NoError(t, err)
+		require.Equal(t, "Shared/Folder1", fi.Path())
+		require.True(t, fi.IsDir())
+
+		copied, err := driver.Stat("Shared/Folder1/Folder2/File1")
+		require.NoError(t, err)
+		require.NotEqual(t, file.DriveFile().Id, copied.DriveFile().Id)
+		_, err = driver.Stat("Folder1")
+		require.True(t, IsNotExist(err))
+
+		// the copy, the new directories and the deletion support shared drives
+		require.Contains(t, teamDriveRequests, "POST /drive/v3/files/"+file.DriveFile().Id+"/copy")
+		require.Contains(t, teamDriveRequests, "POST /drive/v3/files")
+		require.Contains(t, teamDriveRequests, "DELETE /drive/v3/files/"+dir.DriveFile().Id)
+	})
+}
+
+func TestSetFolderColor(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	palette, err := driver.FolderColorPalette()
+	require.NoError(t, err)
+	require.NotEmpty(t, palette)
+
+	require.NoError(t, driver.SetFolderColor("Folder1", strings.ToUpper(palette[0])))
+	fi, err := driver.Stat("Folder1", StatFolderColor())
+	require.NoError(t, err)
+	require.Equal(t, palette[0], fi.FolderColor())
+
+	// the color is only requested if it is needed
+	fi, err = driver.Stat("Folder1")
+	require.NoError(t, err)
+	require.Empty(t, fi.FolderColor())
+
+	var listed, walked string
+	require.NoError(t, driver.ListDirectory("", func(f *FileInfo) error {
+		listed = f.FolderColor()
+		return nil
+	}, ListFolderColor()))
+	require.Equal(t, palette[0], listed)
+	require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+		if f.Path() == "Folder1" {
+			walked = f.FolderColor()
+		}
+		return nil
+	}, WalkFolderColor()))
+	require.Equal(t, palette[0], walked)
+
+	require.IsType(t, FileIsNotDirectoryError{}, driver.SetFolderColor("Folder1/File1", palette[0]))
+	require.IsType(t, InvalidFolderColorError{}, driver.SetFolderColor("Folder1", "#123"))
+}
+
+func TestSetFileStar(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+
+	isStarred := func() bool {
+		file, err := driver.srv.Files.Get(fi.item.Id).Fields("starred").Do()
+		require.NoError(t, err)
+		return file.Starred
+	}
+
+	// starring and unstarring twice must not change the outcome
+	for i := 0; i < 2; i++ {
+		require.NoError(t, driver.Star("Folder1/File1"))
+		require.True(t, isStarred())
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, driver.Unstar("Folder1/File1"))
+		require.False(t, isStarred())
+	}
+
+	require.NoError(t, driver.SetFileStar("Folder1/File1", true))
+	require.True(t, isStarred())
+
+	require.True(t, IsNotExist(driver.SetFileStar("Folder1/File2", true)))
+	require.EqualError(t, driver.SetFileStar("", true), "root cannot be starred")
+}
+
 func TestTrash(t *testing.T) {
 	t.Run("trash file", func(t *testing.T) {
 		driver, teardown := setup(t)
@@ -637,6 +1209,26 @@ func TestListTrash(t *testing.T) {
 		require.Equal(t, "Folder2", files[1].Path())
 	})
 
+	t.Run("trash details", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+
+		before := time.Now().Add(-time.Minute)
+		require.NoError(t, driver.Trash("Folder1/File1"))
+
+		var files []*FileInfo
+		require.NoError(t, driver.ListTrash("", func(f *FileInfo) error {
+			files = append(files, f)
+			return nil
+		}))
+
+		require.Len(t, files, 1)
+		require.True(t, files[0].ExplicitlyTrashed())
+		require.True(t, files[0].TrashedTime().After(before))
+	})
+
 	t.Run("of folder", func(t *testing.T) {
 		driver, teardown := setup(t)
 		defer teardown()
@@ -683,17 +1275,100 @@ func TestListTrash(t *testing.T) {
 	})
 }
 
-func TestIsInRoot(t *testing.T) {
-	t.Run("in folder", func(t *testing.T) {
-		driver, teardown := setup(t)
-		defer teardown()
+func TestListTrashDirect(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/File2", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/File3", "Hello World")
+
+	require.NoError(t, driver.Trash("Folder1/File1"))
+	require.NoError(t, driver.Trash("Folder1/Folder2/File3"))
+
+	var files []*FileInfo
+	require.NoError(t, driver.ListTrashDirect("Folder1", func(f *FileInfo) error {
+		files = append(files, f)
+		return nil
+	}))
+	require.Len(t, files, 1)
+	require.Equal(t, "Folder1/File1", files[0].Path())
+	require.True(t, files[0].ExplicitlyTrashed())
+
+	require.Equal(t, FileIsNotDirectoryError{Path: "Folder1/File2"}, driver.ListTrashDirect("Folder1/File2", func(f *FileInfo) error {
+		return nil
+	}))
+}
+
+func TestUntrash(t *testing.T) {
+	t.Run("restore into original parent", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		require.NoError(t, driver.Trash("Folder1/File1"))
+
+		fi, err := driver.Untrash("Folder1/File1", UntrashOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "Folder1/File1", fi.Path())
+		require.NoError(t, getError(driver.Stat("Folder1/File1")))
+	})
+
+	t.Run("recreate path", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		require.NoError(t, driver.Trash("Folder1/File1"))
+		require.NoError(t, driver.Trash("Folder1"))
+
+		_, err := driver.Untrash("Folder1/File1", UntrashOptions{})
+		require.Equal(t, FileNotExistError{Path: "Folder1"}, err)
+
+		fi, err := driver.Untrash("Folder1/File1", UntrashOptions{RecreatePath: true})
+		require.NoError(t, err)
+		require.Equal(t, "Folder1/File1", fi.Path())
+		require.NoError(t, getError(driver.Stat("Folder1/File1")))
+	})
+
+	t.Run("fallback path", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		require.NoError(t, driver.Trash("Folder1/File1"))
+		require.NoError(t, driver.Trash("Folder1"))
+
+		fi, err := driver.Untrash("Folder1/File1", UntrashOptions{FallbackPath: "Restored"})
+		require.NoError(t, err)
+		require.Equal(t, "Restored/File1", fi.Path())
+		require.NoError(t, getError(driver.Stat("Restored/File1")))
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		require.NoError(t, driver.Trash("Folder1/File1"))
+		newFile(t, driver, "Folder1/File1", "Hello Universe")
+
+		_, err := driver.Untrash("Folder1/File1", UntrashOptions{})
+		require.Equal(t, FileExistError{Path: "Folder1/File1"}, err)
+	})
+}
+
+func TestIsInRoot(t *testing.T) {
+	t.Run("in folder", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
 
 		newFile(t, driver, "Folder1/File1", "Hello World")
 
 		fi, err := driver.getFile(driver.rootNode, "Folder1/File1", googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields))))
 		require.NoError(t, err)
 
-		inRoot, parentPath, err := isInRoot(driver.srv, driver.rootNode.item.Id, fi.item, "")
+		inRoot, parentPath, err := driver.isInRoot(driver.rootNode.item.Id, fi.item, "")
 		require.NoError(t, err)
 		require.True(t, inRoot)
 		require.Equal(t, "Folder1", parentPath)
@@ -711,13 +1386,404 @@ func TestIsInRoot(t *testing.T) {
 		fi, err = driver.getFile(driver.rootNode, "Folder1/File1", googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields))))
 		require.NoError(t, err)
 
-		inRoot, parentPath, err := isInRoot(driver.srv, folder2Id, fi.item, "")
+		inRoot, parentPath, err := driver.isInRoot(folder2Id, fi.item, "")
 		require.NoError(t, err)
 		require.False(t, inRoot)
 		require.Equal(t, "", parentPath)
 	})
 }
 
+func TestListOrphanedFiles(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder2/File2", "Hello World")
+
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	orphanID := fi.item.Id
+	folder, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+
+	// deleting Folder1 would delete File1 as well, so detach File1 from Folder1 before deleting it
+	_, err = driver.srv.Files.Update(orphanID, &drive.File{}).RemoveParents(folder.item.Id).Do()
+	require.NoError(t, err)
+	require.NoError(t, driver.srv.Files.Delete(folder.item.Id).Do())
+	defer driver.srv.Files.Delete(orphanID).Do() // nolint: errcheck
+
+	var orphans []*FileInfo
+	require.NoError(t, driver.ListOrphanedFiles(func(f *FileInfo) error {
+		orphans = append(orphans, f)
+		return nil
+	}))
+
+	var found bool
+	for _, f := range orphans {
+		require.NotEqual(t, "File2", f.Name())
+		if f.item.Id == orphanID {
+			found = true
+			require.Equal(t, "File1", f.Path())
+		}
+	}
+	require.True(t, found, "File1 was not listed as orphaned")
+}
+
+func TestGetParentChain(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/Folder2/File1", "Hello World")
+
+	chain, err := driver.GetParentChain("Folder1/Folder2/File1")
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+	require.Equal(t, "Folder1", chain[0].Path())
+	require.Equal(t, "Folder1/Folder2", chain[1].Path())
+	require.Equal(t, "Folder1/Folder2/File1", chain[2].Path())
+	require.True(t, chain[1].IsDir())
+	require.False(t, chain[2].IsDir())
+
+	chain, err = driver.GetParentChain("")
+	require.NoError(t, err)
+	require.Empty(t, chain)
+}
+
+func TestGetFileOwnerEmail(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	about, err := driver.srv.About.Get().Fields("user(emailAddress)").Do()
+	require.NoError(t, err)
+
+	email, err := driver.GetFileOwnerEmail("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, about.User.EmailAddress, email)
+
+	emails, err := driver.GetFileAllOwnerEmails("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, []string{about.User.EmailAddress}, emails)
+
+	_, err = driver.GetFileOwnerEmail("Folder1/File2")
+	require.True(t, IsNotExist(err))
+}
+
+func TestTransferOwnership(t *testing.T) {
+	newOwner := os.Getenv("GOOGLE_TEST_SECONDARY_EMAIL")
+	if newOwner == "" {
+		t.Skip("GOOGLE_TEST_SECONDARY_EMAIL is not set")
+	}
+
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	err := driver.TransferOwnership("", newOwner)
+	require.IsType(t, OwnershipTransferError{}, err)
+
+	require.NoError(t, driver.TransferOwnership("Folder1/File1", newOwner))
+	email, err := driver.GetFileOwnerEmail("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, newOwner, email)
+
+	// we are not the owner anymore
+	err = driver.TransferOwnership("Folder1/File1", newOwner)
+	require.IsType(t, OwnershipTransferError{}, err)
+}
+
+func TestOwnershipTransferErrorReason(t *testing.T) {
+	reason, ok := ownershipTransferErrorReason(&googleapi.Error{
+		Code:   http.StatusBadRequest,
+		Errors: []googleapi.ErrorItem{{Reason: "ownershipChangeAcrossDomainNotPermitted", Message: "not permitted"}},
+	})
+	require.True(t, ok)
+	require.Equal(t, "not permitted", reason)
+
+	_, ok = ownershipTransferErrorReason(&googleapi.Error{
+		Code:   http.StatusNotFound,
+		Errors: []googleapi.ErrorItem{{Reason: "notFound"}},
+	})
+	require.False(t, ok)
+	_, ok = ownershipTransferErrorReason(nil)
+	require.False(t, ok)
+}
+
+func TestGetFullMetadata(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	file, err := driver.GetFullMetadata("Folder1/File1", "capabilities", "permissions", "owners")
+	require.NoError(t, err)
+	require.NotNil(t, file.Capabilities)
+	require.NotEmpty(t, file.Permissions)
+	require.NotEmpty(t, file.Owners)
+	// other fields are not requested
+	require.Empty(t, file.Name)
+
+	file, err = driver.GetFullMetadata("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, "File1", file.Name)
+	require.NotNil(t, file.Capabilities)
+
+	_, err = driver.GetFullMetadata("Folder1/File2")
+	require.True(t, IsNotExist(err))
+}
+
+func TestGetFileParentInfo(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/Folder2/File1", "Hello World")
+
+	parent, err := driver.GetFileParentInfo("Folder1/Folder2/File1")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/Folder2", parent.Path())
+	require.True(t, parent.IsDir())
+
+	parent, err = driver.GetFileParentInfo("Folder1/Folder2")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1", parent.Path())
+
+	parent, err = driver.GetFileParentInfo("Folder1")
+	require.NoError(t, err)
+	require.Equal(t, "", parent.Path())
+
+	_, err = driver.GetFileParentInfo("")
+	require.Equal(t, FileNotExistError{Path: ""}, err)
+	_, err = driver.GetFileParentInfo("Folder1/File2")
+	require.True(t, IsNotExist(err))
+
+	// a file with multiple parents returns the parent of the path, not the first parent
+	newFile(t, driver, "Folder3/File3", "Hello World")
+	folder1, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+	file3, err := driver.Stat("Folder3/File3")
+	require.NoError(t, err)
+	_, err = driver.srv.Files.Update(file3.item.Id, &drive.File{}).AddParents(folder1.item.Id).Do()
+	require.NoError(t, err)
+	parents, err := driver.GetParentIDs("Folder1/File3")
+	require.NoError(t, err)
+	require.Len(t, parents, 2)
+	require.NotEqual(t, folder1.item.Id, parents[0])
+
+	parent, err = driver.GetFileParentInfo("Folder1/File3")
+	require.NoError(t, err)
+	require.Equal(t, "Folder1", parent.Path())
+	require.Equal(t, folder1.item.Id, parent.item.Id)
+	parent, err = driver.GetFileParentInfo("Folder3/File3")
+	require.NoError(t, err)
+	require.Equal(t, "Folder3", parent.Path())
+}
+
+func TestGetFilesByParentID(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/File2", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/File3", "Hello World")
+
+	var expected []string
+	require.NoError(t, driver.ListDirectory("Folder1", func(f *FileInfo) error {
+		expected = append(expected, f.Path())
+		return nil
+	}))
+
+	dir, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+	var paths []string
+	require.NoError(t, driver.GetFilesByParentID(dir.DriveFile().Id, func(f *FileInfo) error {
+		paths = append(paths, f.Path())
+		return nil
+	}))
+	sort.Strings(expected)
+	sort.Strings(paths)
+	require.Equal(t, []string{"Folder1/File1", "Folder1/File2", "Folder1/Folder2"}, paths)
+	require.Equal(t, expected, paths)
+
+	// errors of the callback
+	err = driver.GetFilesByParentID(dir.DriveFile().Id, func(f *FileInfo) error {
+		return errors.New("stop")
+	})
+	require.EqualError(t, err, "callback throwed an error: stop")
+}
+
+func TestGetDrivePath(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/Folder2/File1", "Hello World")
+	fi, err := driver.Stat("Folder1/Folder2/File1")
+	require.NoError(t, err)
+
+	drivePath, err := driver.GetDrivePath(fi.DriveFile().Id)
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/Folder2/File1", drivePath)
+
+	_, err = driver.GetDrivePath("non-existing-id")
+	require.True(t, IsNotExist(err))
+}
+
+func TestGetDownloadLinks(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "File1", "Hello World")
+	_, err := driver.Import(ImportCSVToSheet, "Sheet", bytes.NewBufferString("a,b\n1,2\n"))
+	require.NoError(t, err)
+
+	links, err := driver.GetDownloadLinks("File1")
+	require.NoError(t, err)
+	require.NotEmpty(t, links.WebContentLink)
+	require.NotEmpty(t, links.WebViewLink)
+	require.Empty(t, links.ExportLinks)
+
+	links, err = driver.GetDownloadLinks("Sheet")
+	require.NoError(t, err)
+	require.Empty(t, links.WebContentLink)
+	require.NotEmpty(t, links.WebViewLink)
+	require.NotEmpty(t, links.ExportLinks["text/csv"])
+
+	_, err = driver.GetDownloadLinks("File2")
+	require.True(t, IsNotExist(err))
+}
+
+func TestGetShareableLink(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	fi, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+
+	link, err := driver.GetShareableLink("Folder1/File1")
+	require.NoError(t, err)
+	require.Contains(t, link, "drive.google.com/file/d/"+fi.DriveFile().Id)
+
+	permissions, err := driver.srv.Permissions.List(fi.DriveFile().Id).Fields("permissions(id,type,role)").Do()
+	require.NoError(t, err)
+	var public bool
+	for _, permission := range permissions.Permissions {
+		public = public || (permission.Type == "anyone" && permission.Role == "reader")
+	}
+	require.True(t, public)
+
+	require.NoError(t, driver.RevokeShareableLink("Folder1/File1"))
+	permissions, err = driver.srv.Permissions.List(fi.DriveFile().Id).Fields("permissions(id,type)").Do()
+	require.NoError(t, err)
+	for _, permission := range permissions.Permissions {
+		require.NotEqual(t, "anyone", permission.Type)
+	}
+
+	// revoking twice is fine
+	require.NoError(t, driver.RevokeShareableLink("Folder1/File1"))
+}
+
+func TestRevokeShareableLinkRemovesAllAnyonePermissions(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			fmt.Fprint(w, `{"files":[{"id":"file1"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/file1/permissions" && r.URL.Query().Get("pageToken") == "":
+			fmt.Fprint(w, `{"nextPageToken":"page2","permissions":[{"id":"owner","type":"user"},{"id":"anyoneWithLink","type":"anyone"}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/file1/permissions":
+			fmt.Fprint(w, `{"permissions":[{"id":"domain","type":"domain"},{"id":"anyone","type":"anyone"}]}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/drive/v3/files/file1/permissions/anyoneWithLink":
+			deleted = append(deleted, "anyoneWithLink")
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && r.URL.Path == "/drive/v3/files/file1/permissions/anyone":
+			// already deleted by someone else
+			deleted = append(deleted, "anyone")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":404,"message":"not found"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{srv: srv, rootNode: &FileInfo{item: &drive.File{Id: "root", MimeType: mimeTypeFolder}}}
+
+	require.NoError(t, driver.RevokeShareableLink("File1"))
+	require.Equal(t, []string{"anyoneWithLink", "anyone"}, deleted)
+}
+
+func TestGetParentIDs(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	folder, err := driver.Stat("Folder1")
+	require.NoError(t, err)
+
+	parents, err := driver.GetParentIDs("Folder1/File1")
+	require.NoError(t, err)
+	require.Equal(t, []string{folder.DriveFile().Id}, parents)
+
+	multiple, err := driver.HasMultipleParents("Folder1/File1")
+	require.NoError(t, err)
+	require.False(t, multiple)
+}
+
+func TestGetFileRevisionContent(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "File1", "Hello World")
+	newFile(t, driver, "File1", "Hello Universe")
+	fi, err := driver.Stat("File1")
+	require.NoError(t, err)
+
+	revisions, err := driver.srv.Revisions.List(fi.item.Id).Fields("revisions(id)").Do()
+	require.NoError(t, err)
+	require.True(t, len(revisions.Revisions) >= 2)
+
+	r, err := driver.GetFileRevisionContent("File1", revisions.Revisions[0].Id)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "Hello World", string(data))
+
+	_, err = driver.GetFileRevisionContent("File1", "unknown")
+	require.Equal(t, ErrRevisionNotFound{RevisionID: "unknown"}, err)
+}
+
+func TestGetFileHistory(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "File1", "Hello World")
+	newFile(t, driver, "File1", "Hello Universe")
+	newFile(t, driver, "File1", "Hello Multiverse")
+	fi, err := driver.Stat("File1")
+	require.NoError(t, err)
+
+	history, err := driver.GetFileHistory("File1")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for i, entry := range history {
+		require.Equal(t, i == 2, entry.IsCurrent)
+		require.NotEmpty(t, entry.ModifierEmail)
+		require.False(t, entry.ModifiedTime.IsZero())
+	}
+	require.Equal(t, fi.HeadRevisionID(), history[2].RevisionID)
+	require.EqualValues(t, len("Hello Multiverse"), history[2].SizeBytes)
+
+	_, err = driver.GetFileHistory("File2")
+	require.True(t, IsNotExist(err))
+}
+
 func TestGetHash(t *testing.T) {
 	driver, teardown := setup(t)
 	defer teardown()
@@ -736,12 +1802,130 @@ func TestGetHash(t *testing.T) {
 	require.EqualValues(t, hash1[:], hash2)
 }
 
-func newFile(t *testing.T, driver *GDriver, path, contents string) {
+func TestGetFileContentAt(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	contents := make([]byte, 1024)
+	_, err := rand.Read(contents)
+	require.NoError(t, err)
+	_, err = driver.PutFile("File1", bytes.NewReader(contents))
+	require.NoError(t, err)
+
+	received, err := driver.GetFileContentAt("File1", 0, 4)
+	require.NoError(t, err)
+	require.Equal(t, contents[:4], received)
+
+	received, err = driver.GetFileContentAt("File1", 512, 4)
+	require.NoError(t, err)
+	require.Equal(t, contents[512:516], received)
+
+	// only the available bytes are returned
+	received, err = driver.GetFileContentAt("File1", 1020, 8)
+	require.NoError(t, err)
+	require.Equal(t, contents[1020:], received)
+
+	received, err = driver.GetFileContentAt("File1", 2048, 4)
+	require.NoError(t, err)
+	require.Empty(t, received)
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	md5Hex := func(s string) string {
+		hash := md5.Sum([]byte(s))
+		return hex.EncodeToString(hash[:])
+	}
+
+	newFile(t, driver, "File1", "Hello World")
+	_, err := driver.CompareAndSwap("File1", md5Hex("Hello World"), bytes.NewBufferString("Hello Universe"))
+	require.NoError(t, err)
+
+	_, err = driver.CompareAndSwap("File1", md5Hex("Hello World"), bytes.NewBufferString("Hello Venus"))
+	require.Equal(t, CASConflictError{Path: "File1", Expected: md5Hex("Hello World"), Actual: md5Hex("Hello Universe")}, err)
+
+	// another writer updates the file between the comparison and the upload
+	other := *driver
+	driver.beforeRevisionCheck = func() {
+		driver.beforeRevisionCheck = nil
+		newFile(t, &other, "File1", "Hello Mars")
+	}
+	_, err = driver.CompareAndSwap("File1", md5Hex("Hello Universe"), bytes.NewBufferString("Hello Venus"))
+	require.Equal(t, CASConflictError{Path: "File1", Expected: md5Hex("Hello Universe"), Actual: md5Hex("Hello Mars")}, err)
+
+	_, r, err := driver.GetFile("File1")
+	require.NoError(t, err)
+	received, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "Hello Mars", string(received))
+
+	t.Run("no revision", func(t *testing.T) {
+		// google native files have neither a md5 checksum nor a head revision
+		driver, stub, teardown := newListStub(t)
+		defer teardown()
+		stub.add("root", "1", "Document1", false)
+
+		_, err := driver.CompareAndSwap("Document1", "", bytes.NewBufferString("Hello World"))
+		require.Equal(t, NoRevisionError{Path: "Document1"}, err)
+	})
+}
+
+func TestPutFileIfChanged(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	md5Hex := func(s string) string {
+		hash := md5.Sum([]byte(s))
+		return hex.EncodeToString(hash[:])
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		fi, uploaded, err := driver.PutFileIfChanged("File1", strings.NewReader("Hello World"), md5Hex("Hello World"))
+		require.NoError(t, err)
+		require.True(t, uploaded)
+		require.Equal(t, "File1", fi.Name())
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		before, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		fi, uploaded, err := driver.PutFileIfChanged("File1", strings.NewReader("Hello World"), md5Hex("Hello World"))
+		require.NoError(t, err)
+		require.False(t, uploaded)
+		require.Equal(t, "File1", fi.Name())
+		require.Equal(t, int64(11), fi.Size())
+		after, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		require.Equal(t, before.BytesUploaded, after.BytesUploaded)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		_, uploaded, err := driver.PutFileIfChanged("File1", strings.NewReader("Hello Universe"), md5Hex("Hello Universe"))
+		require.NoError(t, err)
+		require.True(t, uploaded)
+
+		_, r, err := driver.GetFile("File1")
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello Universe", string(received))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		newDirectory(t, driver, "Folder1")
+		_, _, err := driver.PutFileIfChanged("Folder1", strings.NewReader("Hello World"), md5Hex("Hello World"))
+		require.Equal(t, FileIsDirectoryError{Path: "Folder1"}, err)
+	})
+}
+
+func newFile(t testing.TB, driver *GDriver, path, contents string) {
 	_, err := driver.PutFile(path, bytes.NewBufferString(contents))
 	require.NoError(t, err)
 }
 
-func newDirectory(t *testing.T, driver *GDriver, path string) {
+func newDirectory(t testing.TB, driver *GDriver, path string) {
 	_, err := driver.MakeDirectory(path)
 	require.NoError(t, err)
 }
@@ -785,6 +1969,122 @@ func TestOpen(t *testing.T) {
 			require.NoError(t, err)
 			require.EqualValues(t, buf[:], data)
 		})
+		t.Run("write to", func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+
+			buf := make([]byte, 5*1024*1024)
+			_, err := rand.Read(buf)
+			require.NoError(t, err)
+			_, err = driver.PutFile("Folder1/File1", bytes.NewReader(buf))
+			require.NoError(t, err)
+
+			f, err := driver.Open("Folder1/File1", O_RDONLY)
+			require.NoError(t, err)
+			defer f.Close()
+			writerTo, ok := f.(io.WriterTo)
+			require.True(t, ok)
+			var written bytes.Buffer
+			n, err := writerTo.WriteTo(&written)
+			require.NoError(t, err)
+			require.EqualValues(t, len(buf), n)
+
+			// compare with a download that uses Read
+			f, err = driver.Open("Folder1/File1", O_RDONLY)
+			require.NoError(t, err)
+			defer f.Close()
+			data, err := ioutil.ReadAll(f)
+			require.NoError(t, err)
+			require.Equal(t, data, written.Bytes())
+			require.Equal(t, buf, data)
+		})
+
+		t.Run("copy from reader", func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+
+			buf := make([]byte, 5*1024*1024)
+			_, err := rand.Read(buf)
+			require.NoError(t, err)
+
+			f, err := driver.Open("Folder1/File1", O_WRONLY|O_CREATE)
+			require.NoError(t, err)
+			// a plain reader, so io.Copy has to use ReadFrom
+			n, err := io.Copy(f, struct{ io.Reader }{bytes.NewReader(buf)})
+			require.NoError(t, err)
+			require.EqualValues(t, len(buf), n)
+			require.NoError(t, f.Close())
+
+			_, data, err := driver.GetFile("Folder1/File1")
+			require.NoError(t, err)
+			defer data.Close()
+			received, err := ioutil.ReadAll(data)
+			require.NoError(t, err)
+			require.Equal(t, buf, received)
+		})
+
+		t.Run("read from after write", func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+
+			f, err := driver.Open("Folder1/File1", O_WRONLY|O_CREATE)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("Hello "))
+			require.NoError(t, err)
+			n, err := io.Copy(f, struct{ io.Reader }{strings.NewReader("World")})
+			require.NoError(t, err)
+			require.EqualValues(t, 5, n)
+			require.NoError(t, f.Close())
+
+			_, data, err := driver.GetFile("Folder1/File1")
+			require.NoError(t, err)
+			defer data.Close()
+			received, err := ioutil.ReadAll(data)
+			require.NoError(t, err)
+			require.Equal(t, "Hello World", string(received))
+		})
+
+		t.Run("read from", func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+
+			buf := make([]byte, 5*1024*1024)
+			_, err := rand.Read(buf)
+			require.NoError(t, err)
+			src, err := ioutil.TempFile("", "gdriver")
+			require.NoError(t, err)
+			defer os.Remove(src.Name())
+			defer src.Close()
+			_, err = src.Write(buf)
+			require.NoError(t, err)
+			_, err = src.Seek(0, io.SeekStart)
+			require.NoError(t, err)
+
+			var totals []int64
+			require.NoError(t, WithTransferProgress(func(path string, transferred, total int64) {
+				totals = append(totals, total)
+			})(driver))
+
+			f, err := driver.Open("Folder1/File1", O_WRONLY|O_CREATE)
+			require.NoError(t, err)
+			readerFrom, ok := f.(io.ReaderFrom)
+			require.True(t, ok)
+			n, err := readerFrom.ReadFrom(src)
+			require.NoError(t, err)
+			require.EqualValues(t, len(buf), n)
+			require.NoError(t, f.Close())
+			require.NotEmpty(t, totals)
+			require.EqualValues(t, len(buf), totals[0])
+
+			// writing after ReadFrom is not possible
+			_, err = f.Write([]byte("Hello World"))
+			require.Error(t, err)
+
+			_, hash, err := driver.GetFileHash("Folder1/File1", HashMethodMD5)
+			require.NoError(t, err)
+			expected := md5.Sum(buf)
+			require.Equal(t, hex.EncodeToString(expected[:]), string(hash))
+		})
 		t.Run("non-existing file", func(t *testing.T) {
 			driver, teardown := setup(t)
 			defer teardown()
@@ -849,3 +2149,202 @@ func TestOpen(t *testing.T) {
 		})
 	})
 }
+
+func TestGoogleTypes(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	require.NoError(t, WithConvertToGoogleFormat(true)(driver))
+	require.NoError(t, WithMimeTypeMapping(map[string]string{
+		"text/plain": mimeTypeGoogleDocument,
+		"text/csv":   mimeTypeGoogleSpreadsheet,
+	})(driver))
+
+	newFile(t, driver, "Document.txt", "Hello World")
+	newFile(t, driver, "Spreadsheet.csv", "Hello,World")
+	newFile(t, driver, "File1", "Hello World")
+
+	// presentations and forms cannot be converted from plain text, create them directly
+	for name, mimeType := range map[string]string{
+		"Presentation": mimeTypeGooglePresentation,
+		"Form":         mimeTypeGoogleForm,
+	} {
+		_, err := driver.srv.Files.Create(&drive.File{
+			Name:     name,
+			MimeType: mimeType,
+			Parents:  []string{driver.rootNode.item.Id},
+		}).Do()
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		path   string
+		doc    bool
+		sheet  bool
+		slide  bool
+		form   bool
+		native bool
+	}{
+		{path: "Document.txt", doc: true, native: true},
+		{path: "Spreadsheet.csv", sheet: true, native: true},
+		{path: "Presentation", slide: true, native: true},
+		{path: "Form", form: true, native: true},
+		{path: "File1"},
+	}
+
+	for _, test := range tests {
+		fi, err := driver.Stat(test.path)
+		require.NoError(t, err)
+		require.Equal(t, test.doc, fi.IsGoogleDoc(), test.path)
+		require.Equal(t, test.sheet, fi.IsGoogleSheet(), test.path)
+		require.Equal(t, test.slide, fi.IsGoogleSlide(), test.path)
+		require.Equal(t, test.form, fi.IsGoogleForm(), test.path)
+		require.Equal(t, test.native, fi.IsGoogleNative(), test.path)
+		require.False(t, fi.IsDir(), test.path)
+	}
+}
+
+func TestChecksumMismatchError(t *testing.T) {
+	err := ChecksumMismatchError{
+		Path:     "Folder1/File1",
+		FileID:   "abc",
+		Expected: []byte("b10a8db164e0754105b7a99be72e3fe5"),
+		Actual:   []byte("d41d8cd98f00b204e9800998ecf8427e"),
+		Method:   HashMethodMD5,
+	}
+	require.EqualError(t, err, "MD5 checksum mismatch for `Folder1/File1' (id abc): expected b10a8db164e0754105b7a99be72e3fe5, got d41d8cd98f00b204e9800998ecf8427e")
+	require.True(t, IsChecksumMismatch(err))
+	require.False(t, IsChecksumMismatch(FileNotExistError{Path: "Folder1/File1"}))
+
+	var mismatch ChecksumMismatchError
+	require.True(t, errors.As(fmt.Errorf("upload failed: %w", err), &mismatch))
+	require.Equal(t, "abc", mismatch.FileID)
+}
+
+func TestDuplicateFile(t *testing.T) {
+	setDescription := func(t *testing.T, driver *GDriver, path, description string) {
+		fi, err := driver.Stat(path)
+		require.NoError(t, err)
+		_, err = driver.srv.Files.Update(fi.item.Id, &drive.File{
+			Description: description,
+			Properties:  map[string]string{"Key": "Value"},
+		}).Do()
+		require.NoError(t, err)
+	}
+	getMetadata := func(t *testing.T, driver *GDriver, path string) *drive.File {
+		fi, err := driver.getFile(driver.rootNode, path, "files(id,description,properties)")
+		require.NoError(t, err)
+		return fi.item
+	}
+
+	t.Run("with metadata", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		setDescription(t, driver, "Folder1/File1", "Greeting")
+
+		fi, err := driver.DuplicateFile("Folder1/File1", "Folder2/File2")
+		require.NoError(t, err)
+		require.Equal(t, "Folder2/File2", fi.Path())
+
+		item := getMetadata(t, driver, "Folder2/File2")
+		require.Equal(t, "Greeting", item.Description)
+		require.Equal(t, "Value", item.Properties["Key"])
+
+		// Compare file contents
+		_, r, err := driver.GetFile("Folder2/File2")
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "Hello World", string(received))
+
+		// original still exists?
+		require.NoError(t, getError(driver.Stat("Folder1/File1")))
+	})
+
+	t.Run("without metadata", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+		setDescription(t, driver, "File1", "Greeting")
+
+		_, err := driver.DuplicateFileWithOptions("File1", "File2", DuplicateFileOptions{})
+		require.NoError(t, err)
+
+		item := getMetadata(t, driver, "File2")
+		require.Empty(t, item.Description)
+		require.Empty(t, item.Properties)
+	})
+
+	t.Run("existing target", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+		newFile(t, driver, "File2", "Hello World")
+
+		require.EqualError(t, getError(driver.DuplicateFile("File1", "File2")), "`File2' already exists")
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newDirectory(t, driver, "Folder1")
+
+		require.EqualError(t, getError(driver.DuplicateFile("Folder1", "Folder2")), "`Folder1' is a directory")
+	})
+}
+
+func TestGetFilesCount(t *testing.T) {
+	t.Run("tree", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		newFile(t, driver, "Folder1/Folder2/File2", "Hello World")
+		newFile(t, driver, "File3", "Hello World")
+
+		files, dirs, err := driver.GetFilesCount("")
+		require.NoError(t, err)
+		require.Equal(t, 3, files)
+		require.Equal(t, 2, dirs)
+
+		files, dirs, err = driver.GetFilesCount("Folder1")
+		require.NoError(t, err)
+		require.Equal(t, 2, files)
+		require.Equal(t, 1, dirs)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+
+		_, _, err := driver.GetFilesCount("File1")
+		require.EqualError(t, err, "`File1' is not a directory")
+	})
+}
+
+func BenchmarkGetFilesCount(b *testing.B) {
+	driver, teardown := setup(b)
+	defer teardown()
+
+	// 10 directories with 99 files each = 1000 nodes
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 99; j++ {
+			newFile(b, driver, fmt.Sprintf("Folder%d/File%d", i, j), "Hello World")
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		files, dirs, err := driver.GetFilesCount("")
+		require.NoError(b, err)
+		require.Equal(b, 990, files)
+		require.Equal(b, 10, dirs)
+	}
+}
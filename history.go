@@ -0,0 +1,78 @@
+package gdriver
+
+import (
+	"fmt"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// HistoryEntry describes one revision of a file, see GetFileHistory
+type HistoryEntry struct {
+	RevisionID   string
+	ModifiedTime time.Time
+	// SizeBytes is the size of the revision, it is 0 for google native files
+	SizeBytes int64
+	// ModifierEmail is the email address of the user that created the revision, it is empty if it is not known
+	ModifierEmail string
+	// IsCurrent is true for the revision that holds the current contents of the file
+	IsCurrent bool
+}
+
+// GetFileHistory returns the revisions of a file, the oldest revision first.
+// Note that drive may merge or delete old revisions.
+func (d *GDriver) GetFileHistory(path string) ([]*HistoryEntry, error) {
+	file, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: path}
+	}
+
+	var history []*HistoryEntry
+	var pageToken string
+	for {
+		call := d.srv.Revisions.List(file.item.Id).Fields("nextPageToken", "revisions(id,modifiedTime,size,lastModifyingUser(emailAddress))")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		list, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+		for _, revision := range list.Revisions {
+			entry, err := historyEntry(revision)
+			if err != nil {
+				return nil, err
+			}
+			entry.IsCurrent = revision.Id == file.item.HeadRevisionId
+			history = append(history, entry)
+		}
+		if pageToken = list.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+
+	// google native files have no head revision, their latest revision is the current one
+	if file.item.HeadRevisionId == "" && len(history) > 0 {
+		history[len(history)-1].IsCurrent = true
+	}
+	return history, nil
+}
+
+func historyEntry(revision *drive.Revision) (*HistoryEntry, error) {
+	modifiedTime, err := time.Parse(time.RFC3339, revision.ModifiedTime)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ModifiedTime of revision `%s' (`%s'): %v", revision.Id, revision.ModifiedTime, err)
+	}
+	entry := &HistoryEntry{
+		RevisionID:   revision.Id,
+		ModifiedTime: modifiedTime,
+		SizeBytes:    revision.Size,
+	}
+	if revision.LastModifyingUser != nil {
+		entry.ModifierEmail = revision.LastModifyingUser.EmailAddress
+	}
+	return entry, nil
+}
@@ -0,0 +1,140 @@
+// Package cassette records the http interactions of tests with the drive api and replays them.
+//
+// A Recorder captures the requests and responses of a live session, Save scrubs them (drive ids, page tokens,
+// upload session ids and user information are replaced by stable placeholders, headers other than the content
+// describing ones are dropped) and writes them as json. A Replayer serves the recorded responses again,
+// requests are matched by their method and url, so the order of concurrent requests does not matter.
+//
+// Examples:
+//     rec := cassette.NewRecorder(client.Transport)
+//     client.Transport = rec
+//     ... run the test ...
+//     err := rec.Save("testdata/cassettes/TestPutFile.json")
+//
+//     replayer, err := cassette.Load("testdata/cassettes/TestPutFile.json")
+//     client := &http.Client{Transport: replayer}
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Cassette holds the recorded interactions
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response that was received for it
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   Body   `json:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is a request or response body, it is stored as string if it is valid utf8 and base64 encoded otherwise
+type Body []byte
+
+type encodedBody struct {
+	Text   string `json:"text,omitempty"`
+	Base64 []byte `json:"base64,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (b Body) MarshalJSON() ([]byte, error) {
+	if len(b) == 0 {
+		return []byte("null"), nil
+	}
+	if utf8.Valid(b) {
+		return json.Marshal(encodedBody{Text: string(b)})
+	}
+	return json.Marshal(encodedBody{Base64: b})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var body encodedBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		return err
+	}
+	if body.Base64 != nil {
+		*b = body.Base64
+	} else {
+		*b = Body(body.Text)
+	}
+	return nil
+}
+
+// key returns the value requests are matched by
+func (r *Request) key() string {
+	return r.Method + " " + normalizeURL(r.URL)
+}
+
+// normalizeURL sorts the query parameters of u
+func normalizeURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.RawQuery = parsed.Query().Encode()
+	return parsed.String()
+}
+
+// Path returns the path of the cassette for a test in dir, subtests are stored in their own file
+func Path(dir, testName string) string {
+	return filepath.Join(dir, strings.Replace(testName, "/", "_", -1)+".json")
+}
+
+// Exists returns true if there is a cassette at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Load loads the cassette at path for replaying
+func Load(path string) (*Replayer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return NewReplayer(&c), nil
+}
+
+func (c *Cassette) save(path string) error {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	// urls are easier to read without escaped ampersands
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	if err := e.Encode(c); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,114 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fileID    = "1A2b3C4d5E6f7G8h9I0j"
+	folderID  = "0AbCdEfGhIjKlMnOpQrS"
+	email     = "jane.doe@gmail.com"
+	pageToken = "~!!~AI9FV7TokenValue"
+	uploadID  = "ADPycdsUploadSession"
+)
+
+func newServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("Set-Cookie", "session=secret")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files" && r.URL.Query().Get("pageToken") == "":
+			fmt.Fprintf(w, `{"nextPageToken":%q,"files":[{"id":%q,"name":"File1","parents":[%q],"owners":[{"emailAddress":%q,"displayName":"Jane Doe"}]}]}`,
+				pageToken, fileID, folderID, email)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			fmt.Fprintf(w, `{"files":[{"id":%q,"name":"Folder1"}]}`, folderID)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/"+fileID && r.URL.Query().Get("alt") == "media":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0xff, 0xfe, 0x00, 0x01}) // nolint: errcheck
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/drive/v3/files":
+			w.Header().Set("Location", "http://"+r.Host+"/upload/drive/v3/files?uploadType=resumable&upload_id="+uploadID)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, []byte) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp, body
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := Path(dir, "TestRecordAndReplay/subtest")
+	require.Equal(t, filepath.Join(dir, "TestRecordAndReplay_subtest.json"), path)
+	require.False(t, Exists(path))
+
+	rec := NewRecorder(nil)
+	client := &http.Client{Transport: rec}
+	_, list := get(t, client, ts.URL+"/drive/v3/files?q=trashed+%3D+false&fields=files(id)")
+	require.Contains(t, string(list), fileID)
+	get(t, client, ts.URL+"/drive/v3/files?pageToken="+pageToken)
+	_, media := get(t, client, ts.URL+"/drive/v3/files/"+fileID+"?alt=media")
+	resp, err := client.Post(ts.URL+"/upload/drive/v3/files?uploadType=resumable", "application/json",
+		strings.NewReader(fmt.Sprintf(`{"name":"File2","parents":[%q]}`, folderID)))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, rec.Save(path))
+	require.True(t, Exists(path))
+
+	// the cassette contains no secrets
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{fileID, folderID, email, pageToken, uploadID, "Jane Doe", "session=secret"} {
+		require.NotContains(t, string(data), secret)
+	}
+
+	replayer, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, replayer.Remaining())
+	client = &http.Client{Transport: replayer}
+
+	// the order of the query parameters does not matter
+	_, replayedList := get(t, client, ts.URL+"/drive/v3/files?fields=files(id)&q=trashed+%3D+false")
+	require.Contains(t, string(replayedList), `"id":"id000001"`)
+	require.Contains(t, string(replayedList), `"parents":["id000002"]`)
+	require.Contains(t, string(replayedList), `"emailAddress":"user1@example.com"`)
+	require.Contains(t, string(replayedList), `"displayName":"User"`)
+	require.Contains(t, string(replayedList), `"nextPageToken":"token000001"`)
+
+	// the placeholders are used in the urls as well
+	_, replayedMedia := get(t, client, ts.URL+"/drive/v3/files/id000001?alt=media")
+	require.Equal(t, media, replayedMedia)
+	get(t, client, ts.URL+"/drive/v3/files?pageToken=token000001")
+
+	resp, err = client.Post(ts.URL+"/upload/drive/v3/files?uploadType=resumable", "application/json", bytes.NewReader(nil))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, ts.URL+"/upload/drive/v3/files?uploadType=resumable&upload_id=upload000001", resp.Header.Get("Location"))
+	require.Empty(t, resp.Header.Get("Set-Cookie"))
+	require.Equal(t, 0, replayer.Remaining())
+
+	// every interaction is replayed once
+	_, err = client.Get(ts.URL + "/drive/v3/files/id000001?alt=media")
+	require.Error(t, err)
+}
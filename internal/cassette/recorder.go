@@ -0,0 +1,75 @@
+package cassette
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Recorder is a http.RoundTripper that records all interactions that pass through it
+type Recorder struct {
+	base         http.RoundTripper
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder creates a Recorder that sends the requests with base (http.DefaultTransport if nil)
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+		},
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close() // nolint: errcheck
+		if err != nil {
+			return nil, err
+		}
+		// a RoundTripper must not modify the request
+		clone := new(http.Request)
+		*clone = *req
+		clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req = clone
+		interaction.Request.Body = body
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close() // nolint: errcheck
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	interaction.Response = Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Save scrubs the recorded interactions (see the package documentation) and writes them to path
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &Cassette{Interactions: r.interactions}
+	return sanitize(c).save(path)
+}
@@ -0,0 +1,72 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Replayer is a http.RoundTripper that answers requests with recorded responses,
+// every interaction is used once, identical requests get the responses in the order they were recorded
+type Replayer struct {
+	mu      sync.Mutex
+	pending map[string][]*Interaction
+}
+
+// NewReplayer creates a Replayer for the interactions of c
+func NewReplayer(c *Cassette) *Replayer {
+	r := &Replayer{pending: make(map[string][]*Interaction)}
+	for _, interaction := range c.Interactions {
+		key := interaction.Request.key()
+		r.pending[key] = append(r.pending[key], interaction)
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close() // nolint: errcheck
+	}
+	request := Request{Method: req.Method, URL: req.URL.String()}
+	key := request.key()
+
+	r.mu.Lock()
+	interactions := r.pending[key]
+	if len(interactions) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("cassette: no recorded response for %s", key)
+	}
+	interaction := interactions[0]
+	r.pending[key] = interactions[1:]
+	r.mu.Unlock()
+
+	header := http.Header{}
+	for name, values := range interaction.Response.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the amount of recorded interactions that were not replayed
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for _, interactions := range r.pending {
+		n += len(interactions)
+	}
+	return n
+}
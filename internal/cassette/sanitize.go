@@ -0,0 +1,199 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// secretKeys are the json keys whose values are replaced everywhere in the cassette (urls, bodies and headers),
+// the value is the prefix of the placeholder
+var secretKeys = map[string]string{
+	"id":                "id",
+	"parents":           "id",
+	"fileId":            "id",
+	"driveId":           "id",
+	"teamDriveId":       "id",
+	"permissionId":      "permission",
+	"headRevisionId":    "revision",
+	"nextPageToken":     "token",
+	"startPageToken":    "token",
+	"newStartPageToken": "token",
+	"emailAddress":      "email",
+}
+
+// userKeys are the json keys whose values are replaced in the json bodies only, they are too ambiguous to replace them everywhere
+var userKeys = map[string]string{
+	"displayName": "User",
+	"photoLink":   "",
+}
+
+// keptHeaders are the response headers that are stored, all other headers are dropped
+var keptHeaders = []string{"Content-Type", "Content-Range", "Location", "Range", "X-Http-Status-Code-Override"}
+
+// minSecretLength is the minimum length of a secret, shorter values (e.g. small page tokens) are kept
+const minSecretLength = 8
+
+// placeholders maps secrets to stable placeholders
+type placeholders struct {
+	values map[string]string
+	counts map[string]int
+}
+
+func (p *placeholders) add(prefix, value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	if _, ok := p.values[value]; ok {
+		return
+	}
+	p.counts[prefix]++
+	if prefix == "email" {
+		p.values[value] = fmt.Sprintf("user%d@example.com", p.counts[prefix])
+	} else {
+		p.values[value] = fmt.Sprintf("%s%06d", prefix, p.counts[prefix])
+	}
+}
+
+// collect adds the values of the secret keys of the json value v
+func (p *placeholders) collect(v interface{}, prefix string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// sorted to get the same placeholders for the same recording
+		sort.Strings(keys)
+		for _, key := range keys {
+			p.collect(v[key], secretKeys[key])
+		}
+	case []interface{}:
+		for _, e := range v {
+			p.collect(e, prefix)
+		}
+	case string:
+		if prefix != "" {
+			p.add(prefix, v)
+		}
+	}
+}
+
+// replacer returns a replacer that replaces all secrets, including their url encoded form
+func (p *placeholders) replacer() *strings.Replacer {
+	secrets := make([]string, 0, len(p.values))
+	for secret := range p.values {
+		secrets = append(secrets, secret)
+	}
+	// longer secrets first, the replacer prefers the first matching argument
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	var pairs []string
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, p.values[secret])
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			pairs = append(pairs, escaped, url.QueryEscape(p.values[secret]))
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// decodeJSON decodes body if it is json
+func decodeJSON(body []byte) (interface{}, bool) {
+	if len(body) == 0 || !utf8.Valid(body) {
+		return nil, false
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// scrubUsers replaces the values of the user keys in the json value v, it returns true if something was replaced
+func scrubUsers(v interface{}) bool {
+	var changed bool
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if replacement, ok := userKeys[key]; ok {
+				if _, isString := value.(string); isString {
+					v[key] = replacement
+					changed = true
+					continue
+				}
+			}
+			changed = scrubUsers(value) || changed
+		}
+	case []interface{}:
+		for _, e := range v {
+			changed = scrubUsers(e) || changed
+		}
+	}
+	return changed
+}
+
+// sanitize returns a copy of c without secrets (see the package documentation)
+func sanitize(c *Cassette) *Cassette {
+	p := &placeholders{values: make(map[string]string), counts: make(map[string]int)}
+	for _, interaction := range c.Interactions {
+		for _, body := range [][]byte{interaction.Request.Body, interaction.Response.Body} {
+			if v, ok := decodeJSON(body); ok {
+				p.collect(v, "")
+			}
+		}
+		// resumable upload sessions are identified by the upload_id
+		if location, err := url.Parse(interaction.Response.Header.Get("Location")); err == nil {
+			if id := location.Query().Get("upload_id"); id != "" {
+				p.add("upload", id)
+			}
+		}
+	}
+	replacer := p.replacer()
+
+	replaceBody := func(body []byte) []byte {
+		if !utf8.Valid(body) {
+			return body
+		}
+		if v, ok := decodeJSON(body); ok && scrubUsers(v) {
+			if data, err := json.Marshal(v); err == nil {
+				body = data
+			}
+		}
+		return []byte(replacer.Replace(string(body)))
+	}
+
+	sanitized := &Cassette{Interactions: make([]*Interaction, len(c.Interactions))}
+	for i, interaction := range c.Interactions {
+		header := http.Header{}
+		for _, name := range keptHeaders {
+			for _, value := range interaction.Response.Header[name] {
+				header.Add(name, replacer.Replace(value))
+			}
+		}
+		sanitized.Interactions[i] = &Interaction{
+			Request: Request{
+				Method: interaction.Request.Method,
+				URL:    replacer.Replace(interaction.Request.URL),
+				Body:   replaceBody(interaction.Request.Body),
+			},
+			Response: Response{
+				StatusCode: interaction.Response.StatusCode,
+				Header:     header,
+				Body:       replaceBody(interaction.Response.Body),
+			},
+		}
+	}
+	return sanitized
+}
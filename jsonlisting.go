@@ -0,0 +1,42 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// listingEntry is one line of WriteDirectoryListing
+type listingEntry struct {
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	MimeType     string `json:"mimeType"`
+	ModifiedTime string `json:"modifiedTime"`
+	DriveID      string `json:"driveId"`
+}
+
+// WriteDirectoryListing writes the contents of the directory drivePath as JSON lines to w,
+// every line is an object with the keys path, size, mimeType, modifiedTime and driveId.
+// If recursive is true all descendants will be written (see Walk).
+func (d *GDriver) WriteDirectoryListing(drivePath string, w io.Writer, recursive bool) error {
+	encoder := json.NewEncoder(w)
+	write := func(f *FileInfo) error {
+		return encoder.Encode(listingEntry{
+			Path:         f.Path(),
+			Size:         f.Size(),
+			MimeType:     f.item.MimeType,
+			ModifiedTime: f.item.ModifiedTime,
+			DriveID:      f.item.Id,
+		})
+	}
+
+	var err error
+	if recursive {
+		err = d.Walk(drivePath, write, walkFields(listFields[0]))
+	} else {
+		err = d.listDirectory(drivePath, listFields[0], listFilter{}, write)
+	}
+	if cbErr, ok := err.(CallbackError); ok {
+		return cbErr.NestedError
+	}
+	return err
+}
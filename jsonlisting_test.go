@@ -0,0 +1,56 @@
+package gdriver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDirectoryListing(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/File2", "Hello Universe")
+
+	readListing := func(t *testing.T, recursive bool) map[string]listingEntry {
+		var buf bytes.Buffer
+		require.NoError(t, driver.WriteDirectoryListing("Folder1", &buf, recursive))
+
+		entries := make(map[string]listingEntry)
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var entry listingEntry
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+			entries[entry.Path] = entry
+		}
+		require.NoError(t, scanner.Err())
+		return entries
+	}
+
+	t.Run("flat", func(t *testing.T) {
+		entries := readListing(t, false)
+		require.Len(t, entries, 2)
+		require.Contains(t, entries, "Folder1/File1")
+		require.Contains(t, entries, "Folder1/Folder2")
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		entries := readListing(t, true)
+		require.Len(t, entries, 3)
+		for path, entry := range entries {
+			fi, err := driver.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, listingEntry{
+				Path:         fi.Path(),
+				Size:         fi.Size(),
+				MimeType:     fi.item.MimeType,
+				ModifiedTime: fi.item.ModifiedTime,
+				DriveID:      fi.item.Id,
+			}, entry)
+		}
+	})
+}
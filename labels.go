@@ -0,0 +1,182 @@
+package gdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// LabelInfo is a drive label that was applied to a file (see GetLabels)
+type LabelInfo struct {
+	id     string
+	fields map[string]string
+}
+
+// LabelID returns the id of the label
+func (l *LabelInfo) LabelID() string {
+	return l.id
+}
+
+// FieldValues returns the values of the label fields by their field id,
+// values of fields with multiple values (e.g. multiple selections) are joined with a comma
+func (l *LabelInfo) FieldValues() map[string]string {
+	values := make(map[string]string, len(l.fields))
+	for id, value := range l.fields {
+		values[id] = value
+	}
+	return values
+}
+
+// the label types are not part of the drive library yet
+
+type driveLabel struct {
+	ID     string                     `json:"id"`
+	Fields map[string]driveLabelField `json:"fields"`
+}
+
+type driveLabelField struct {
+	Text       []string         `json:"text"`
+	Selection  []string         `json:"selection"`
+	Integer    []json.Number    `json:"integer"`
+	DateString []string         `json:"dateString"`
+	User       []driveLabelUser `json:"user"`
+}
+
+type driveLabelUser struct {
+	EmailAddress string `json:"emailAddress"`
+}
+
+// value returns the values of the field as a string
+func (f driveLabelField) value() string {
+	values := append(append(append([]string{}, f.Text...), f.Selection...), f.DateString...)
+	for _, n := range f.Integer {
+		values = append(values, n.String())
+	}
+	for _, user := range f.User {
+		values = append(values, user.EmailAddress)
+	}
+	return strings.Join(values, ",")
+}
+
+type labelModification struct {
+	LabelID            string                   `json:"labelId"`
+	RemoveLabel        bool                     `json:"removeLabel,omitempty"`
+	FieldModifications []labelFieldModification `json:"fieldModifications,omitempty"`
+}
+
+type labelFieldModification struct {
+	FieldID       string   `json:"fieldId"`
+	SetTextValues []string `json:"setTextValues"`
+}
+
+// ApplyLabel applies the label labelID to a file or directory and sets the text fields of the label to fields (keyed by their field id).
+// Fields of other types (e.g. selections) cannot be set with ApplyLabel.
+//
+// Examples:
+//
+//	ApplyLabel("Reports/report.pdf", "labelId", map[string]string{"fieldId": "Confidential"})
+func (d *GDriver) ApplyLabel(path, labelID string, fields map[string]string) (err error) {
+	defer d.audit("ApplyLabel", path, labelID)(&err)
+	modification := labelModification{LabelID: labelID}
+	ids := make([]string, 0, len(fields))
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		modification.FieldModifications = append(modification.FieldModifications, labelFieldModification{
+			FieldID:       id,
+			SetTextValues: []string{fields[id]},
+		})
+	}
+	return d.modifyLabels(path, modification)
+}
+
+// RemoveLabel removes the label labelID from a file or directory
+func (d *GDriver) RemoveLabel(path, labelID string) (err error) {
+	defer d.audit("RemoveLabel", path, labelID)(&err)
+	return d.modifyLabels(path, labelModification{LabelID: labelID, RemoveLabel: true})
+}
+
+// modifyLabels sends the modification to files.modifyLabels
+func (d *GDriver) modifyLabels(path string, modification labelModification) error {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return err
+	}
+	body := new(bytes.Buffer)
+	err = json.NewEncoder(body).Encode(map[string]interface{}{
+		"labelModifications": []labelModification{modification},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, googleapi.ResolveRelative(d.srv.BasePath, "files/"+url.PathEscape(file.item.Id)+"/modifyLabels"), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	return d.sendLabelRequest(req, nil)
+}
+
+// GetLabels returns the labels that were applied to a file or directory
+func (d *GDriver) GetLabels(path string) ([]*LabelInfo, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []*LabelInfo
+	var pageToken string
+	for {
+		params := url.Values{}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		req, err := http.NewRequest(http.MethodGet, googleapi.ResolveRelative(d.srv.BasePath, "files/"+url.PathEscape(file.item.Id)+"/listLabels")+"?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Labels        []driveLabel `json:"labels"`
+			NextPageToken string       `json:"nextPageToken"`
+		}
+		if err = d.sendLabelRequest(req, &list); err != nil {
+			return nil, err
+		}
+		for _, label := range list.Labels {
+			info := &LabelInfo{
+				id:     label.ID,
+				fields: make(map[string]string, len(label.Fields)),
+			}
+			for id, field := range label.Fields {
+				info.fields[id] = field.value()
+			}
+			labels = append(labels, info)
+		}
+		if pageToken = list.NextPageToken; pageToken == "" {
+			return labels, nil
+		}
+	}
+}
+
+// sendLabelRequest sends a request of the labels api and decodes the response into v (if not nil)
+func (d *GDriver) sendLabelRequest(req *http.Request, v interface{}) error {
+	response, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(response)
+	if err = googleapi.CheckResponse(response); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
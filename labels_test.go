@@ -0,0 +1,103 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestLabelRequests(t *testing.T) {
+	var modifications []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files":
+			fmt.Fprint(w, `{"files":[{"id":"file1","name":"File1","mimeType":"text/plain"}]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/file1/modifyLabels":
+			var body struct {
+				LabelModifications []map[string]interface{} `json:"labelModifications"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			modifications = append(modifications, body.LabelModifications...)
+			fmt.Fprint(w, `{"modifiedLabels":[]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/file1/listLabels":
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"nextPageToken":"page2","labels":[
+					{"id":"label1","fields":{"text":{"valueType":"text","text":["Confidential"]},"count":{"valueType":"integer","integer":["42"]}}}
+				]}`)
+				return
+			}
+			fmt.Fprint(w, `{"labels":[
+				{"id":"label2","fields":{"choices":{"valueType":"selection","selection":["a","b"]},"owner":{"valueType":"user","user":[{"emailAddress":"owner@example.com"}]}}}
+			]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{
+		client:   ts.Client(),
+		srv:      srv,
+		rootNode: &FileInfo{item: &drive.File{Id: "root", MimeType: mimeTypeFolder}},
+	}
+
+	require.NoError(t, driver.ApplyLabel("File1", "label1", map[string]string{"text": "Confidential"}))
+	require.NoError(t, driver.RemoveLabel("File1", "label1"))
+	require.Equal(t, []map[string]interface{}{
+		{
+			"labelId": "label1",
+			"fieldModifications": []interface{}{
+				map[string]interface{}{"fieldId": "text", "setTextValues": []interface{}{"Confidential"}},
+			},
+		},
+		{"labelId": "label1", "removeLabel": true},
+	}, modifications)
+
+	labels, err := driver.GetLabels("File1")
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	require.Equal(t, "label1", labels[0].LabelID())
+	require.Equal(t, map[string]string{"text": "Confidential", "count": "42"}, labels[0].FieldValues())
+	require.Equal(t, "label2", labels[1].LabelID())
+	require.Equal(t, map[string]string{"choices": "a,b", "owner": "owner@example.com"}, labels[1].FieldValues())
+}
+
+func TestLabels(t *testing.T) {
+	labelID := os.Getenv("GOOGLE_TEST_LABEL_ID")
+	if labelID == "" {
+		t.Skip("GOOGLE_TEST_LABEL_ID is not set")
+	}
+
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	labels, err := driver.GetLabels("Folder1/File1")
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	require.NoError(t, driver.ApplyLabel("Folder1/File1", labelID, nil))
+	labels, err = driver.GetLabels("Folder1/File1")
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	require.Equal(t, labelID, labels[0].LabelID())
+
+	require.NoError(t, driver.RemoveLabel("Folder1/File1", labelID))
+	labels, err = driver.GetLabels("Folder1/File1")
+	require.NoError(t, err)
+	require.Empty(t, labels)
+
+	_, err = driver.GetLabels("Folder1/File2")
+	require.True(t, IsNotExist(err))
+}
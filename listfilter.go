@@ -0,0 +1,230 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// driveTimeFormat is the format of timestamps in drive queries, drive expects them in UTC
+const driveTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// listFilter restricts listings to files that were modified in a range or have a size in a range,
+// bounds that are not set are not applied.
+// The modified range is sent to drive as part of the query. Drive cannot query the size of files,
+// the size range is applied to the listed files instead.
+type listFilter struct {
+	modifiedAfter  time.Time
+	modifiedBefore time.Time
+	minSize        int64
+	hasMinSize     bool
+	maxSize        int64
+	hasMaxSize     bool
+	// includeGoogleNative includes files without a size (google native files and directories) if a size range is set
+	includeGoogleNative bool
+}
+
+// validate returns an error if a range is inverted
+func (f listFilter) validate() error {
+	if !f.modifiedAfter.IsZero() && !f.modifiedBefore.IsZero() && f.modifiedAfter.After(f.modifiedBefore) {
+		return fmt.Errorf("invalid modified range: %s is after %s", f.modifiedAfter.Format(time.RFC3339), f.modifiedBefore.Format(time.RFC3339))
+	}
+	if (f.hasMinSize && f.minSize < 0) || (f.hasMaxSize && f.maxSize < 0) {
+		return errors.New("size cannot be negative")
+	}
+	if f.hasMinSize && f.hasMaxSize && f.minSize > f.maxSize {
+		return fmt.Errorf("invalid size range: %d is larger than %d", f.minSize, f.maxSize)
+	}
+	return nil
+}
+
+// clause returns the query clause for the modified range, it is empty if no bound is set
+func (f listFilter) clause() string {
+	var clauses []string
+	if !f.modifiedAfter.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("modifiedTime > '%s'", f.modifiedAfter.UTC().Format(driveTimeFormat)))
+	}
+	if !f.modifiedBefore.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("modifiedTime < '%s'", f.modifiedBefore.UTC().Format(driveTimeFormat)))
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// sizeFiltered returns true if a size range is set
+func (f listFilter) sizeFiltered() bool {
+	return f.hasMinSize || f.hasMaxSize
+}
+
+// includesSize returns true if the file matches the size range
+func (f listFilter) includesSize(file *FileInfo) bool {
+	if !f.sizeFiltered() {
+		return true
+	}
+	if file.IsGoogleNative() {
+		return f.includeGoogleNative
+	}
+	return (!f.hasMinSize || file.Size() >= f.minSize) && (!f.hasMaxSize || file.Size() <= f.maxSize)
+}
+
+// includes returns true if the file matches the modified and the size range
+func (f listFilter) includes(file *FileInfo) bool {
+	modified := file.ModifiedTime()
+	return (f.modifiedAfter.IsZero() || modified.After(f.modifiedAfter)) &&
+		(f.modifiedBefore.IsZero() || modified.Before(f.modifiedBefore)) &&
+		f.includesSize(file)
+}
+
+// fields adds the fields the filter needs to fields (of the form files(...))
+func (f listFilter) fields(fields googleapi.Field) googleapi.Field {
+	var needed []string
+	if !f.modifiedAfter.IsZero() || !f.modifiedBefore.IsZero() {
+		needed = append(needed, "modifiedTime")
+	}
+	if f.sizeFiltered() {
+		needed = append(needed, "size")
+	}
+	for _, field := range needed {
+		inner := strings.TrimSuffix(strings.TrimPrefix(string(fields), "files("), ")")
+		if !strings.Contains(","+inner+",", ","+field+",") {
+			fields = googleapi.Field(strings.TrimSuffix(string(fields), ")") + "," + field + ")")
+		}
+	}
+	return fields
+}
+
+type listOptions struct {
+	optionalFields
+	filter listFilter
+}
+
+// ListOption can be used to pass optional options to ListDirectory
+type ListOption func(options *listOptions)
+
+// ListFolderColor requests the colors of the directories, see FileInfo.FolderColor
+func ListFolderColor() ListOption {
+	return func(options *listOptions) {
+		options.folderColor = true
+	}
+}
+
+// ListCapabilities requests the capabilities of the files, see FileInfo.Capabilities
+func ListCapabilities() ListOption {
+	return func(options *listOptions) {
+		options.capabilities = true
+	}
+}
+
+// ModifiedAfter only lists files and directories that were modified after t
+//
+// Examples:
+//     ListDirectory("Reports", fn, ModifiedAfter(time.Now().Add(-24 * time.Hour)))
+func ModifiedAfter(t time.Time) ListOption {
+	return func(options *listOptions) {
+		options.filter.modifiedAfter = t
+	}
+}
+
+// ModifiedBefore only lists files and directories that were modified before t
+func ModifiedBefore(t time.Time) ListOption {
+	return func(options *listOptions) {
+		options.filter.modifiedBefore = t
+	}
+}
+
+// MinSize only lists files with at least n bytes.
+// Google native files and directories have no size, they are not listed unless IncludeGoogleNative is used.
+func MinSize(n int64) ListOption {
+	return func(options *listOptions) {
+		options.filter.minSize, options.filter.hasMinSize = n, true
+	}
+}
+
+// MaxSize only lists files with at most n bytes.
+// Google native files and directories have no size, they are not listed unless IncludeGoogleNative is used.
+func MaxSize(n int64) ListOption {
+	return func(options *listOptions) {
+		options.filter.maxSize, options.filter.hasMaxSize = n, true
+	}
+}
+
+// IncludeGoogleNative lists google native files and directories even if MinSize or MaxSize is used
+func IncludeGoogleNative() ListOption {
+	return func(options *listOptions) {
+		options.filter.includeGoogleNative = true
+	}
+}
+
+// WalkModifiedAfter only visits files and directories that were modified after t,
+// the walk still descends into directories that were not modified in the range
+func WalkModifiedAfter(t time.Time) WalkOption {
+	return func(options *walkOptions) {
+		options.filter.modifiedAfter = t
+	}
+}
+
+// WalkModifiedBefore only visits files and directories that were modified before t,
+// the walk still descends into directories that were not modified in the range
+func WalkModifiedBefore(t time.Time) WalkOption {
+	return func(options *walkOptions) {
+		options.filter.modifiedBefore = t
+	}
+}
+
+// WalkMinSize only visits files with at least n bytes (see MinSize), the walk still descends into all directories
+func WalkMinSize(n int64) WalkOption {
+	return func(options *walkOptions) {
+		options.filter.minSize, options.filter.hasMinSize = n, true
+	}
+}
+
+// WalkMaxSize only visits files with at most n bytes (see MaxSize), the walk still descends into all directories
+func WalkMaxSize(n int64) WalkOption {
+	return func(options *walkOptions) {
+		options.filter.maxSize, options.filter.hasMaxSize = n, true
+	}
+}
+
+// WalkIncludeGoogleNative visits google native files and directories even if WalkMinSize or WalkMaxSize is used
+func WalkIncludeGoogleNative() WalkOption {
+	return func(options *walkOptions) {
+		options.filter.includeGoogleNative = true
+	}
+}
+
+// QueryModifiedAfter only reports files that were modified after t
+func QueryModifiedAfter(t time.Time) QueryOption {
+	return func(options *queryOptions) {
+		options.filter.modifiedAfter = t
+	}
+}
+
+// QueryModifiedBefore only reports files that were modified before t
+func QueryModifiedBefore(t time.Time) QueryOption {
+	return func(options *queryOptions) {
+		options.filter.modifiedBefore = t
+	}
+}
+
+// QueryMinSize only reports files with at least n bytes (see MinSize)
+func QueryMinSize(n int64) QueryOption {
+	return func(options *queryOptions) {
+		options.filter.minSize, options.filter.hasMinSize = n, true
+	}
+}
+
+// QueryMaxSize only reports files with at most n bytes (see MaxSize)
+func QueryMaxSize(n int64) QueryOption {
+	return func(options *queryOptions) {
+		options.filter.maxSize, options.filter.hasMaxSize = n, true
+	}
+}
+
+// QueryIncludeGoogleNative reports google native files and directories even if QueryMinSize or QueryMaxSize is used
+func QueryIncludeGoogleNative() QueryOption {
+	return func(options *queryOptions) {
+		options.filter.includeGoogleNative = true
+	}
+}
@@ -0,0 +1,153 @@
+package gdriver
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+func TestListFilter(t *testing.T) {
+	f := listFilter{
+		modifiedAfter:  time.Date(2019, 1, 1, 14, 0, 0, 0, time.FixedZone("CET", 3600)),
+		modifiedBefore: time.Date(2019, 1, 2, 0, 0, 0, 500*int(time.Millisecond), time.UTC),
+	}
+	require.NoError(t, f.validate())
+	require.Equal(t, "modifiedTime > '2019-01-01T13:00:00.000Z' and modifiedTime < '2019-01-02T00:00:00.500Z'", f.clause())
+	require.Equal(t, "modifiedTime < '2019-01-02T00:00:00.500Z'", listFilter{modifiedBefore: f.modifiedBefore}.clause())
+	require.Empty(t, listFilter{}.clause())
+	require.Equal(t, googleapi.Field("files(id,name,modifiedTime)"), f.fields("files(id,name)"))
+	require.Equal(t, googleapi.Field("files(id,modifiedTime,name)"), f.fields("files(id,modifiedTime,name)"))
+
+	f.modifiedAfter, f.modifiedBefore = f.modifiedBefore, f.modifiedAfter
+	require.Error(t, f.validate())
+
+	require.Error(t, listFilter{minSize: 20, hasMinSize: true, maxSize: 10, hasMaxSize: true}.validate())
+	require.Error(t, listFilter{maxSize: -1, hasMaxSize: true}.validate())
+	require.NoError(t, listFilter{minSize: 10, hasMinSize: true, maxSize: 10, hasMaxSize: true}.validate())
+	require.Equal(t, googleapi.Field("files(id,size)"), listFilter{maxSize: 10, hasMaxSize: true}.fields("files(id)"))
+}
+
+func TestListModified(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newDirectory(t, driver, "Folder1")
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	time.Sleep(10 * time.Millisecond)
+	newFile(t, driver, "Folder1/File2", "Hello World")
+	time.Sleep(10 * time.Millisecond)
+	newFile(t, driver, "Folder1/File3", "Hello World")
+
+	modified := func(path string) time.Time {
+		fi, err := driver.Stat(path)
+		require.NoError(t, err)
+		return fi.ModifiedTime()
+	}
+	first, last := modified("Folder1/File1"), modified("Folder1/File3")
+
+	t.Run("ListDirectory", func(t *testing.T) {
+		list := func(opts ...ListOption) []string {
+			var names []string
+			require.NoError(t, driver.ListDirectory("Folder1", func(f *FileInfo) error {
+				names = append(names, f.Name())
+				return nil
+			}, opts...))
+			sort.Strings(names)
+			return names
+		}
+		require.Equal(t, []string{"File2", "File3"}, list(ModifiedAfter(first)))
+		require.Equal(t, []string{"File1", "File2"}, list(ModifiedBefore(last)))
+		require.Equal(t, []string{"File2"}, list(ModifiedAfter(first), ModifiedBefore(last)))
+		// the bounds are converted to utc
+		require.Equal(t, []string{"File2", "File3"}, list(ModifiedAfter(first.In(time.FixedZone("", -5*3600)))))
+
+		err := driver.ListDirectory("Folder1", func(*FileInfo) error { return nil }, ModifiedAfter(last), ModifiedBefore(first))
+		require.Error(t, err)
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		// the directory was modified before the files, it is not visited but the walk descends into it
+		var paths []string
+		require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkModifiedAfter(first), WalkSorted()))
+		require.Equal(t, []string{"Folder1/File2", "Folder1/File3"}, paths)
+
+		err := driver.Walk("", func(*FileInfo) error { return nil }, WalkModifiedAfter(last), WalkModifiedBefore(first))
+		require.Error(t, err)
+	})
+
+	t.Run("ListByQuery", func(t *testing.T) {
+		var names []string
+		require.NoError(t, driver.ListByQuery("name contains 'File'", func(f *FileInfo) error {
+			names = append(names, f.Name())
+			return nil
+		}, QueryModifiedBefore(last)))
+		sort.Strings(names)
+		require.Equal(t, []string{"File1", "File2"}, names)
+
+		err := driver.ListByQuery("name contains 'File'", func(*FileInfo) error { return nil }, QueryModifiedAfter(last), QueryModifiedBefore(first))
+		require.Error(t, err)
+	})
+}
+
+func TestListSize(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello")
+	newFile(t, driver, "Folder1/File2", "Hello World")
+	newFile(t, driver, "Folder1/File3", "Hello World, Hello Universe")
+	newDirectory(t, driver, "Folder1/Folder2")
+	newFile(t, driver, "Folder1/Folder2/File4", "Hello Universe")
+
+	t.Run("ListDirectory", func(t *testing.T) {
+		list := func(opts ...ListOption) []string {
+			var names []string
+			require.NoError(t, driver.ListDirectory("Folder1", func(f *FileInfo) error {
+				names = append(names, f.Name())
+				return nil
+			}, opts...))
+			sort.Strings(names)
+			return names
+		}
+		require.Equal(t, []string{"File2", "File3"}, list(MinSize(11)))
+		require.Equal(t, []string{"File1", "File2"}, list(MaxSize(11)))
+		require.Equal(t, []string{"File2"}, list(MinSize(6), MaxSize(26)))
+		// directories have no size
+		require.Equal(t, []string{"File1", "Folder2"}, list(MaxSize(10), IncludeGoogleNative()))
+
+		err := driver.ListDirectory("Folder1", func(*FileInfo) error { return nil }, MinSize(20), MaxSize(10))
+		require.Error(t, err)
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		var paths []string
+		require.NoError(t, driver.Walk("Folder1", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkMinSize(12), WalkSorted()))
+		require.Equal(t, []string{"Folder1/File3", "Folder1/Folder2/File4"}, paths)
+
+		paths = nil
+		require.NoError(t, driver.Walk("Folder1", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkMinSize(12), WalkIncludeGoogleNative(), WalkSorted(), WalkMinimalFields()))
+		require.Equal(t, []string{"Folder1/File3", "Folder1/Folder2", "Folder1/Folder2/File4"}, paths)
+	})
+
+	t.Run("ListByQuery", func(t *testing.T) {
+		var names []string
+		require.NoError(t, driver.ListByQuery("name contains 'File'", func(f *FileInfo) error {
+			names = append(names, f.Name())
+			return nil
+		}, QueryMaxSize(11)))
+		sort.Strings(names)
+		require.Equal(t, []string{"File1", "File2"}, names)
+	})
+}
@@ -0,0 +1,232 @@
+package gdriver
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+)
+
+// FileDigest describes the contents of a file in a manifest (see GenerateManifest)
+type FileDigest struct {
+	// Size is the amount of bytes of the contents
+	Size int64
+	// MD5 is the hex encoded md5 checksum of the contents, it is empty for google native files
+	MD5 string
+	// GoogleNative is true for google native files (e.g. google docs), they have no checksum
+	GoogleNative bool
+}
+
+// VerifyReport holds the differences found by VerifyAgainstLocal, all paths are relative slash separated paths
+type VerifyReport struct {
+	// Missing holds the files that exist on the drive but not locally
+	Missing []string
+	// Extra holds the files that exist locally but not on the drive
+	Extra []string
+	// Mismatched holds the files whose size or checksum differ
+	Mismatched []string
+	// GoogleNative holds the google native files, they cannot be verified
+	GoogleNative []string
+}
+
+// Matches returns true if no differences were found, google native files are ignored
+func (r *VerifyReport) Matches() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Mismatched) == 0
+}
+
+// GenerateManifest returns the digests of all files in the directory path (and its sub directories), keyed by their relative path.
+// Files that are stored encrypted or compressed are downloaded to get the digest of their original contents.
+func (d *GDriver) GenerateManifest(path string) (map[string]FileDigest, error) {
+	dirPath := strings.Join(strings.FieldsFunc(path, isPathSeperator), "/")
+	manifest := make(map[string]FileDigest)
+	err := d.Walk(path, func(f *FileInfo) error {
+		if f.IsDir() {
+			return nil
+		}
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(f.Path(), dirPath), "/")
+		if f.IsGoogleNative() {
+			manifest[relativePath] = FileDigest{GoogleNative: true}
+			return nil
+		}
+		if !isEncodedFile(f.item) {
+			manifest[relativePath] = FileDigest{Size: f.item.Size, MD5: f.item.Md5Checksum}
+			return nil
+		}
+
+		digest, err := d.decodedDigest(f)
+		if err != nil {
+			return err
+		}
+		manifest[relativePath] = digest
+		return nil
+	}, walkFields(md5ListFields()))
+	if err != nil {
+		if cbErr, ok := err.(CallbackError); ok {
+			return nil, cbErr.NestedError
+		}
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// GetSHA256TreeOptions can be used to configure GetSHA256Tree
+type GetSHA256TreeOptions struct {
+	// Concurrency is the amount of files that are downloaded at the same time, defaults to 1
+	Concurrency int
+}
+
+// GetSHA256Tree downloads all files in the directory path (and its sub directories)
+// and returns the hex encoded sha256 checksums of their (decoded) contents, keyed by their relative path.
+// Google native files are skipped, they cannot be downloaded.
+func (d *GDriver) GetSHA256Tree(path string, opts GetSHA256TreeOptions) (map[string]string, error) {
+	dirPath := strings.Join(strings.FieldsFunc(path, isPathSeperator), "/")
+	var files []*FileInfo
+	err := d.Walk(path, func(f *FileInfo) error {
+		if !f.IsDir() && !f.IsGoogleNative() {
+			files = append(files, f)
+		}
+		return nil
+	}, walkFields(listFields[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	tree := make(map[string]string, len(files))
+	var mu sync.Mutex
+	var firstErr error
+	runBatch(len(files), concurrency, func(index int) {
+		f := files[index]
+		sum, err := d.sha256Sum(f)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to hash `%s': %v", f.Path(), err)
+			}
+			return
+		}
+		tree[strings.TrimPrefix(strings.TrimPrefix(f.Path(), dirPath), "/")] = sum
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return tree, nil
+}
+
+// sha256Sum downloads the file and returns the hex encoded sha256 checksum of the decoded contents
+func (d *GDriver) sha256Sum(f *FileInfo) (string, error) {
+	body, err := d.downloadDecoded(f)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// decodedDigest downloads the file and returns the digest of the decoded contents
+func (d *GDriver) decodedDigest(f *FileInfo) (FileDigest, error) {
+	body, err := d.downloadDecoded(f)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	defer body.Close()
+	return readerDigest(body)
+}
+
+// VerifyAgainstLocal compares the directory path with the local directory localDir,
+// the md5 checksums of the local files are only calculated if their size matches.
+func (d *GDriver) VerifyAgainstLocal(path, localDir string) (*VerifyReport, error) {
+	manifest, err := d.GenerateManifest(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{}
+	seen := make(map[string]struct{}, len(manifest))
+	err = filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(localDir, localPath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		remote, ok := manifest[relativePath]
+		if !ok {
+			report.Extra = append(report.Extra, relativePath)
+			return nil
+		}
+		seen[relativePath] = struct{}{}
+		if remote.GoogleNative {
+			return nil
+		}
+		if remote.Size != info.Size() {
+			report.Mismatched = append(report.Mismatched, relativePath)
+			return nil
+		}
+		local, err := fileDigest(localPath)
+		if err != nil {
+			return err
+		}
+		if local.MD5 != remote.MD5 {
+			report.Mismatched = append(report.Mismatched, relativePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for relativePath, remote := range manifest {
+		if remote.GoogleNative {
+			report.GoogleNative = append(report.GoogleNative, relativePath)
+			continue
+		}
+		if _, ok := seen[relativePath]; !ok {
+			report.Missing = append(report.Missing, relativePath)
+		}
+	}
+	sort.Strings(report.Missing)
+	sort.Strings(report.GoogleNative)
+	return report, nil
+}
+
+// fileDigest returns the digest of a local file
+func fileDigest(name string) (FileDigest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	defer f.Close()
+	return readerDigest(f)
+}
+
+// readerDigest reads r until io.EOF and returns the digest of the contents
+func readerDigest(r io.Reader) (FileDigest, error) {
+	hash := md5.New()
+	n, err := io.Copy(hash, r)
+	if err != nil {
+		return FileDigest{}, err
+	}
+	return FileDigest{Size: n, MD5: hex.EncodeToString(hash.Sum(nil))}, nil
+}
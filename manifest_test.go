@@ -0,0 +1,104 @@
+package gdriver
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	digest := func(contents string) string {
+		sum := md5.Sum([]byte(contents))
+		return hex.EncodeToString(sum[:])
+	}
+	addFile := func(parentID, id, name, contents string) {
+		stub.add(parentID, id, name, false)
+		stub.files[id].Size = int64(len(contents))
+		stub.files[id].Md5Checksum = digest(contents)
+	}
+
+	stub.add("root", "1", "Backup", true)
+	addFile("1", "2", "File1", "Hello World")
+	addFile("1", "3", "File2", "Hello Universe")
+	addFile("1", "4", "File3", "Hello Mars")
+	stub.add("1", "5", "Folder1", true)
+	addFile("5", "6", "File4", "Hello Venus")
+	stub.add("5", "7", "Document", false)
+	stub.files["7"].MimeType = mimeTypeGoogleDocument
+
+	manifest, err := driver.GenerateManifest("Backup")
+	require.NoError(t, err)
+	require.Equal(t, map[string]FileDigest{
+		"File1":            {Size: 11, MD5: digest("Hello World")},
+		"File2":            {Size: 14, MD5: digest("Hello Universe")},
+		"File3":            {Size: 10, MD5: digest("Hello Mars")},
+		"Folder1/File4":    {Size: 11, MD5: digest("Hello Venus")},
+		"Folder1/Document": {GoogleNative: true},
+	}, manifest)
+	// the stub ignores the fields, make sure the checksums were requested
+	require.Contains(t, stub.fields, string(md5ListFields()))
+
+	localDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(localDir)
+	require.NoError(t, os.Mkdir(filepath.Join(localDir, "Folder1"), 0700))
+	for name, contents := range map[string]string{
+		"File1":         "Hello World",
+		"File2":         "Hello Universe!",
+		"Folder1/File4": "Hello Pluto",
+		"Folder1/File5": "Hello Saturn",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(localDir, filepath.FromSlash(name)), []byte(contents), 0600))
+	}
+
+	report, err := driver.VerifyAgainstLocal("Backup", localDir)
+	require.NoError(t, err)
+	require.False(t, report.Matches())
+	require.Equal(t, []string{"File3"}, report.Missing)
+	require.Equal(t, []string{"Folder1/File5"}, report.Extra)
+	require.Equal(t, []string{"File2", "Folder1/File4"}, report.Mismatched)
+	require.Equal(t, []string{"Folder1/Document"}, report.GoogleNative)
+}
+
+func TestGetSHA256Tree(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Backup/File1", "Hello World")
+	newFile(t, driver, "Backup/File2", "Hello Universe")
+	newFile(t, driver, "Backup/Folder1/File3", "Hello Mars")
+
+	digest := func(contents string) string {
+		sum := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(sum[:])
+	}
+
+	tree, err := driver.GetSHA256Tree("Backup", GetSHA256TreeOptions{Concurrency: 2})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"File1":         digest("Hello World"),
+		"File2":         digest("Hello Universe"),
+		"Folder1/File3": digest("Hello Mars"),
+	}, tree)
+
+	newFile(t, driver, "Backup/File2", "Hello Venus")
+	changed, err := driver.GetSHA256Tree("Backup", GetSHA256TreeOptions{})
+	require.NoError(t, err)
+	require.Len(t, changed, len(tree))
+	for path, sum := range changed {
+		if path == "File2" {
+			require.Equal(t, digest("Hello Venus"), sum)
+			continue
+		}
+		require.Equal(t, tree[path], sum, path)
+	}
+}
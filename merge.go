@@ -0,0 +1,213 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// ConflictPolicy controls how MoveMerge and PutFile handle files that already exist in the destination
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps the existing file and leaves the source file in the source directory
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite deletes the existing file and moves the source file
+	ConflictOverwrite
+	// ConflictRename moves the source file with a suffix, e.g. "File1 (1).txt"
+	ConflictRename
+	// ConflictError fails with a FileExistError
+	ConflictError
+)
+
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictSkip:
+		return "skip"
+	case ConflictOverwrite:
+		return "overwrite"
+	case ConflictRename:
+		return "rename"
+	case ConflictError:
+		return "error"
+	default:
+		return fmt.Sprintf("ConflictPolicy(%d)", int(p))
+	}
+}
+
+// MergeReport describes what MoveMerge did, all paths are destination paths except for Skipped
+type MergeReport struct {
+	// Merged holds the files and directories that were moved without a conflict
+	Merged []string
+	// Skipped holds the source paths of the files that were left in the source directory (see ConflictSkip)
+	Skipped []string
+	// Overwritten holds the files that were replaced (see ConflictOverwrite)
+	Overwritten []string
+	// Renamed holds the new paths of the files that were moved with a suffix (see ConflictRename)
+	Renamed []string
+}
+
+// MoveMerge moves the directory src to dst like Move, if dst already exists the children of src will be moved into dst.
+// Directories that exist in both are merged recursively, conflict controls what happens with files that exist in both.
+// Source directories that are empty afterwards will be deleted.
+//
+// Examples:
+//     MoveMerge("2023/reports", "archive/reports", ConflictRename)
+func (d *GDriver) MoveMerge(src, dst string, conflict ConflictPolicy) (_ *MergeReport, err error) {
+	defer d.audit("MoveMerge", src, dst)(&err)
+	switch conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictError:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %s", conflict)
+	}
+
+	srcDir, err := d.getFile(d.rootNode, src, listFields...)
+	if err != nil {
+		return nil, err
+	}
+	if srcDir == d.rootNode {
+		return nil, errors.New("root cannot be moved")
+	}
+	if !srcDir.IsDir() {
+		return nil, FileIsNotDirectoryError{Path: src}
+	}
+
+	report := &MergeReport{}
+	dstDir, err := d.getFile(d.rootNode, dst, listFields...)
+	if IsNotExist(err) {
+		fi, err := d.Move(src, dst)
+		if err != nil {
+			return nil, err
+		}
+		report.Merged = append(report.Merged, fi.Path())
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dstDir == d.rootNode {
+		return nil, errors.New("new path cannot be empty")
+	}
+	if !dstDir.IsDir() {
+		return nil, FileIsNotDirectoryError{Path: dst}
+	}
+	if dstDir.item.Id == srcDir.item.Id {
+		return nil, fmt.Errorf("`%s' and `%s' are the same directory", src, dst)
+	}
+
+	if _, err = d.mergeDirectory(srcDir, dstDir, conflict, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// mergeDirectory moves the children of srcDir into dstDir and deletes srcDir if it is empty afterwards,
+// it returns true if srcDir was deleted
+func (d *GDriver) mergeDirectory(srcDir, dstDir *FileInfo, conflict ConflictPolicy, report *MergeReport) (bool, error) {
+	srcChildren, err := d.listChildren(srcDir)
+	if err != nil {
+		return false, err
+	}
+	dstChildren, err := d.listChildren(dstDir)
+	if err != nil {
+		return false, err
+	}
+	existing := make(map[string]*FileInfo, len(dstChildren))
+	for _, child := range dstChildren {
+		existing[child.item.Name] = child
+	}
+
+	empty := true
+	for _, child := range srcChildren {
+		target, ok := existing[child.item.Name]
+		if !ok {
+			moved, err := d.moveInto(child, srcDir, dstDir, child.item.Name)
+			if err != nil {
+				return false, err
+			}
+			existing[moved.item.Name] = moved
+			report.Merged = append(report.Merged, moved.Path())
+			continue
+		}
+
+		if child.IsDir() && target.IsDir() {
+			deleted, err := d.mergeDirectory(child, target, conflict, report)
+			if err != nil {
+				return false, err
+			}
+			empty = empty && deleted
+			continue
+		}
+
+		switch conflict {
+		case ConflictError:
+			return false, FileExistError{Path: target.Path()}
+		case ConflictSkip:
+			empty = false
+			report.Skipped = append(report.Skipped, child.Path())
+		case ConflictOverwrite:
+			if err = d.srv.Files.Delete(target.item.Id).Do(); err != nil {
+				return false, err
+			}
+			moved, err := d.moveInto(child, srcDir, dstDir, child.item.Name)
+			if err != nil {
+				return false, err
+			}
+			existing[moved.item.Name] = moved
+			report.Overwritten = append(report.Overwritten, moved.Path())
+		case ConflictRename:
+			moved, err := d.moveInto(child, srcDir, dstDir, conflictName(child.item.Name, child.IsDir(), existing))
+			if err != nil {
+				return false, err
+			}
+			existing[moved.item.Name] = moved
+			report.Renamed = append(report.Renamed, moved.Path())
+		}
+	}
+
+	if !empty {
+		return false, nil
+	}
+	if err = d.srv.Files.Delete(srcDir.item.Id).Do(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// moveInto moves file from the directory parent to the directory newParent and names it name
+func (d *GDriver) moveInto(file, parent, newParent *FileInfo, name string) (*FileInfo, error) {
+	item, err := d.srv.Files.Update(file.item.Id, &drive.File{
+		Name: name,
+	}).
+		AddParents(newParent.item.Id).
+		RemoveParents(parent.item.Id).
+		SupportsTeamDrives(true).
+		Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         item,
+		parentPath:   newParent.Path(),
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// conflictName returns the first name with a suffix (e.g. "File1 (1).txt") that is not taken,
+// the suffix of directories is added after the extension
+func conflictName(name string, dir bool, taken map[string]*FileInfo) string {
+	base, ext := name, ""
+	if !dir {
+		ext = path.Ext(base)
+		base = strings.TrimSuffix(base, ext)
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, ok := taken[name]; !ok {
+			return name
+		}
+	}
+}
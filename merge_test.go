@@ -0,0 +1,87 @@
+package gdriver
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveMerge(t *testing.T) {
+	prepare := func(t *testing.T) (*GDriver, func()) {
+		driver, teardown := setup(t)
+		newFile(t, driver, "2023/reports/File1.txt", "new 1")
+		newFile(t, driver, "2023/reports/Q1/File2.txt", "new 2")
+		newFile(t, driver, "2023/reports/File3.txt", "new 3")
+		newFile(t, driver, "archive/reports/File1.txt", "old 1")
+		newFile(t, driver, "archive/reports/Q1/File4.txt", "old 4")
+		return driver, teardown
+	}
+	requireContents := func(t *testing.T, driver *GDriver, path, expected string) {
+		_, r, err := driver.GetFile(path)
+		require.NoError(t, err)
+		defer r.Close()
+		contents, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(contents))
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		driver, teardown := prepare(t)
+		defer teardown()
+
+		report, err := driver.MoveMerge("2023/reports", "archive/reports", ConflictSkip)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"archive/reports/File3.txt", "archive/reports/Q1/File2.txt"}, report.Merged)
+		require.Equal(t, []string{"2023/reports/File1.txt"}, report.Skipped)
+		requireContents(t, driver, "archive/reports/File1.txt", "old 1")
+		requireContents(t, driver, "archive/reports/Q1/File4.txt", "old 4")
+
+		// the source still contains the skipped file
+		requireContents(t, driver, "2023/reports/File1.txt", "new 1")
+		require.True(t, IsNotExist(getError(driver.Stat("2023/reports/Q1"))))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		driver, teardown := prepare(t)
+		defer teardown()
+
+		report, err := driver.MoveMerge("2023/reports", "archive/reports", ConflictOverwrite)
+		require.NoError(t, err)
+		require.Equal(t, []string{"archive/reports/File1.txt"}, report.Overwritten)
+		requireContents(t, driver, "archive/reports/File1.txt", "new 1")
+		requireContents(t, driver, "archive/reports/Q1/File2.txt", "new 2")
+		require.True(t, IsNotExist(getError(driver.Stat("2023/reports"))))
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		driver, teardown := prepare(t)
+		defer teardown()
+
+		report, err := driver.MoveMerge("2023/reports", "archive/reports", ConflictRename)
+		require.NoError(t, err)
+		require.Equal(t, []string{"archive/reports/File1 (1).txt"}, report.Renamed)
+		requireContents(t, driver, "archive/reports/File1.txt", "old 1")
+		requireContents(t, driver, "archive/reports/File1 (1).txt", "new 1")
+		require.True(t, IsNotExist(getError(driver.Stat("2023/reports"))))
+	})
+
+	t.Run("non existing destination", func(t *testing.T) {
+		driver, teardown := prepare(t)
+		defer teardown()
+
+		report, err := driver.MoveMerge("2023/reports", "archive/2023", ConflictSkip)
+		require.NoError(t, err)
+		require.Equal(t, []string{"archive/2023"}, report.Merged)
+		requireContents(t, driver, "archive/2023/Q1/File2.txt", "new 2")
+	})
+}
+
+func TestConflictName(t *testing.T) {
+	taken := map[string]*FileInfo{
+		"File1.txt":     nil,
+		"File1 (1).txt": nil,
+	}
+	require.Equal(t, "File1 (2).txt", conflictName("File1.txt", false, taken))
+	require.Equal(t, "Folder.d (1)", conflictName("Folder.d", true, taken))
+}
@@ -0,0 +1,133 @@
+package gdriver
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// metadataCache holds the FileInfos that were fetched by BackfillMetadataCache, keyed by their path
+type metadataCache struct {
+	mu    sync.Mutex
+	files map[string]*FileInfo
+	// generation is increased by every clear
+	generation uint64
+}
+
+// cacheKey normalizes a path for the metadata cache
+func cacheKey(path string) string {
+	return strings.Join(strings.FieldsFunc(path, isPathSeperator), "/")
+}
+
+// get returns a copy of the cached FileInfo for the path, c may be nil
+func (c *metadataCache) get(path string) (*FileInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fi, ok := c.files[cacheKey(path)]
+	if !ok {
+		return nil, false
+	}
+	// callers must not be able to change the cached entry
+	return fi.Clone(), true
+}
+
+// currentGeneration returns the generation that must be passed to fill, c may be nil
+func (c *metadataCache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// fill adds the files if the cache was not cleared since generation was retrieved, c may be nil
+func (c *metadataCache) fill(files []*FileInfo, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if c.files == nil {
+		c.files = make(map[string]*FileInfo, len(files))
+	}
+	for _, fi := range files {
+		c.files[cacheKey(fi.Path())] = fi
+	}
+}
+
+// clear removes all entries, c may be nil
+func (c *metadataCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = nil
+	c.generation++
+}
+
+// BackfillMetadataCache fetches the metadata of the directory path and all its descendants (one listing per directory)
+// and caches it, so that following Stat calls for those files need no request.
+// The cache is cleared by every request of the driver that changes something (e.g. PutFile or Delete)
+// and by SetRootDirectory, changes made by other clients are not noticed, use ClearMetadataCache to drop the cache.
+func (d *GDriver) BackfillMetadataCache(path string) error {
+	generation := d.metadataCache.currentGeneration()
+	dir, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return err
+	}
+	if !dir.IsDir() {
+		return FileIsNotDirectoryError{Path: path}
+	}
+
+	files := []*FileInfo{dir}
+	if err = d.Walk(path, func(info *FileInfo) error {
+		files = append(files, info)
+		return nil
+	}, walkFields(listFields[0])); err != nil {
+		return err
+	}
+
+	// the files are dropped if something was changed during the walk
+	d.metadataCache.fill(files, generation)
+	return nil
+}
+
+// ClearMetadataCache drops the metadata cached by BackfillMetadataCache
+func (d *GDriver) ClearMetadataCache() {
+	d.metadataCache.clear()
+}
+
+// cacheInvalidationTransport clears the metadata cache of the driver on every request that can change files
+type cacheInvalidationTransport struct {
+	base   http.RoundTripper
+	driver *GDriver
+}
+
+func (t *cacheInvalidationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		t.driver.metadataCache.clear()
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// withCacheInvalidationTransport returns a copy of client that clears the metadata cache of driver on changes
+func withCacheInvalidationTransport(client *http.Client, driver *GDriver) *http.Client {
+	c := *client
+	c.Transport = &cacheInvalidationTransport{
+		base:   client.Transport,
+		driver: driver,
+	}
+	return &c
+}
@@ -0,0 +1,67 @@
+package gdriver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillMetadataCache(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	paths := []string{
+		"Folder1",
+		"Folder1/File1",
+		"Folder1/File2",
+		"Folder1/Folder2",
+		"Folder1/Folder2/File3",
+	}
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/File2", "Hello Universe")
+	newFile(t, driver, "Folder1/Folder2/File3", "Hello Galaxy")
+
+	apiCalls := func() int64 {
+		metrics, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		return metrics.APICalls
+	}
+
+	require.NoError(t, driver.BackfillMetadataCache(""))
+	before := apiCalls()
+	for _, p := range paths {
+		fi, err := driver.Stat(p)
+		require.NoError(t, err)
+		require.Equal(t, p, fi.Path())
+	}
+	fi, err := driver.Stat("/Folder1/File1/")
+	require.NoError(t, err)
+	require.EqualValues(t, 11, fi.Size())
+	require.Equal(t, before, apiCalls())
+
+	// changes clear the cache
+	_, err = driver.PutFile("Folder1/File1", bytes.NewBufferString("Hello"))
+	require.NoError(t, err)
+	fi, err = driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+	require.EqualValues(t, 5, fi.Size())
+
+	require.NoError(t, driver.BackfillMetadataCache("Folder1/Folder2"))
+	before = apiCalls()
+	_, err = driver.Stat("Folder1/Folder2/File3")
+	require.NoError(t, err)
+	require.Equal(t, before, apiCalls())
+	// files outside of the backfilled directory are not cached
+	_, err = driver.Stat("Folder1/File2")
+	require.NoError(t, err)
+	require.True(t, apiCalls() > before)
+
+	driver.ClearMetadataCache()
+	before = apiCalls()
+	_, err = driver.Stat("Folder1/Folder2/File3")
+	require.NoError(t, err)
+	require.True(t, apiCalls() > before)
+
+	require.IsType(t, FileIsNotDirectoryError{}, driver.BackfillMetadataCache("Folder1/File2"))
+}
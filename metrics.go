@@ -0,0 +1,126 @@
+package gdriver
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// DriveMetrics holds counters of the requests a GDriver made since it was created, see GetDriveMetrics
+type DriveMetrics struct {
+	// APICalls is the amount of requests sent to drive (including retries and upload chunks)
+	APICalls int64
+	// BytesUploaded is the amount of bytes sent in request bodies
+	BytesUploaded int64
+	// BytesDownloaded is the amount of bytes received in response bodies
+	BytesDownloaded int64
+	// CacheHits is the amount of requests that were avoided by cached export formats, directories or metadata
+	CacheHits int64
+	// ClientErrors is the amount of responses with a 4xx status code (except rate limits)
+	ClientErrors int64
+	// ServerErrors is the amount of responses with a 5xx status code
+	ServerErrors int64
+	// RateLimitErrors is the amount of responses that reported an exceeded rate limit
+	RateLimitErrors int64
+	// NetworkErrors is the amount of requests that failed without a response
+	NetworkErrors int64
+}
+
+// GetDriveMetrics returns a snapshot of the counters since the GDriver was created
+func (d *GDriver) GetDriveMetrics() (*DriveMetrics, error) {
+	m := d.metrics
+	if m == nil {
+		return &DriveMetrics{}, nil
+	}
+	return &DriveMetrics{
+		APICalls:        atomic.LoadInt64(&m.APICalls),
+		BytesUploaded:   atomic.LoadInt64(&m.BytesUploaded),
+		BytesDownloaded: atomic.LoadInt64(&m.BytesDownloaded),
+		CacheHits:       atomic.LoadInt64(&m.CacheHits),
+		ClientErrors:    atomic.LoadInt64(&m.ClientErrors),
+		ServerErrors:    atomic.LoadInt64(&m.ServerErrors),
+		RateLimitErrors: atomic.LoadInt64(&m.RateLimitErrors),
+		NetworkErrors:   atomic.LoadInt64(&m.NetworkErrors),
+	}, nil
+}
+
+// cacheHit counts a request that was answered by a cache, m may be nil
+func (m *DriveMetrics) cacheHit() {
+	if m != nil {
+		atomic.AddInt64(&m.CacheHits, 1)
+	}
+}
+
+// metricsTransport counts the requests, transferred bytes and errors of a driver
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *DriveMetrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&t.metrics.APICalls, 1)
+
+	if req.Body != nil {
+		// a RoundTripper must not modify the request
+		r := new(http.Request)
+		*r = *req
+		r.Body = &countingReadCloser{ReadCloser: req.Body, counter: &t.metrics.BytesUploaded}
+		req = r
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	response, err := base.RoundTrip(req)
+	if err != nil {
+		atomic.AddInt64(&t.metrics.NetworkErrors, 1)
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		atomic.AddInt64(&t.metrics.RateLimitErrors, 1)
+	case response.StatusCode == http.StatusForbidden && isRateLimitResponse(response):
+		atomic.AddInt64(&t.metrics.RateLimitErrors, 1)
+	case response.StatusCode >= 500:
+		atomic.AddInt64(&t.metrics.ServerErrors, 1)
+	case response.StatusCode >= 400:
+		atomic.AddInt64(&t.metrics.ClientErrors, 1)
+	}
+	response.Body = &countingReadCloser{ReadCloser: response.Body, counter: &t.metrics.BytesDownloaded}
+	return response, nil
+}
+
+// isRateLimitResponse returns true if the body of the error response reports a rate limit,
+// the body is restored so it can be read again
+func isRateLimitResponse(response *http.Response) bool {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return err == nil && (bytes.Contains(body, []byte(`"rateLimitExceeded"`)) || bytes.Contains(body, []byte(`"userRateLimitExceeded"`)))
+}
+
+// countingReadCloser adds the amount of read bytes to counter
+type countingReadCloser struct {
+	io.ReadCloser
+	counter *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.counter, int64(n))
+	return n, err
+}
+
+// withMetricsTransport returns a copy of client that counts the requests in metrics
+func withMetricsTransport(client *http.Client, metrics *DriveMetrics) *http.Client {
+	c := *client
+	c.Transport = &metricsTransport{
+		base:    client.Transport,
+		metrics: metrics,
+	}
+	return &c
+}
@@ -0,0 +1,95 @@
+package gdriver
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// transportFunc answers requests with a function
+type transportFunc func(req *http.Request) (*http.Response, error)
+
+func (fn transportFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestGetDriveMetrics(t *testing.T) {
+	respond := func(req *http.Request, code int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: code,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+	}
+	root := `{"id":"root","mimeType":"` + mimeTypeFolder + `"}`
+	driver, err := New(&http.Client{Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil {
+			ioutil.ReadAll(req.Body) // nolint: errcheck
+		}
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/about"):
+			return respond(req, http.StatusOK, `{"exportFormats":{}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/files/root"):
+			return respond(req, http.StatusOK, root), nil
+		case strings.HasSuffix(req.URL.Path, "/files/server-error"):
+			return respond(req, http.StatusInternalServerError, `{"error":{"code":500}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/files/too-many"):
+			return respond(req, http.StatusTooManyRequests, `{"error":{"code":429}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/files/rate-limit"):
+			return respond(req, http.StatusForbidden, `{"error":{"code":403,"errors":[{"reason":"userRateLimitExceeded"}]}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/files/forbidden"):
+			return respond(req, http.StatusForbidden, `{"error":{"code":403,"errors":[{"reason":"insufficientFilePermissions"}]}}`), nil
+		case strings.HasSuffix(req.URL.Path, "/files/network"):
+			return nil, errors.New("connection reset")
+		case req.Method == http.MethodPost && req.URL.Query().Get("uploadType") == "resumable":
+			response := respond(req, http.StatusOK, `{}`)
+			response.Header.Set("Location", "https://www.googleapis.com/upload/drive/v3/files?upload_id=1")
+			return response, nil
+		case req.Method == http.MethodPut:
+			return respond(req, http.StatusOK, `{"id":"file1","name":"File1","mimeType":"`+mimeTypeFile+`"}`), nil
+		default:
+			return respond(req, http.StatusOK, `{"files":[]}`), nil
+		}
+	})})
+	require.NoError(t, err)
+
+	metrics, err := driver.GetDriveMetrics()
+	require.NoError(t, err)
+	require.Equal(t, &DriveMetrics{APICalls: 1, BytesDownloaded: int64(len(root))}, metrics)
+
+	_, err = driver.PutFile("File1", bytes.NewBufferString("Hello World"))
+	require.NoError(t, err)
+	metrics, err = driver.GetDriveMetrics()
+	require.NoError(t, err)
+	// lookup of the existing file, the upload session and the upload
+	require.EqualValues(t, 4, metrics.APICalls)
+	require.True(t, metrics.BytesUploaded > int64(len("Hello World")))
+
+	_, err = driver.ExportFormats()
+	require.NoError(t, err)
+	_, err = driver.ExportFormats()
+	require.NoError(t, err)
+
+	for _, id := range []string{"server-error", "too-many", "rate-limit", "forbidden", "network"} {
+		_, err = driver.srv.Files.Get(id).Do()
+		require.Error(t, err)
+	}
+	// the body of the rate limit response must still be readable
+	_, err = driver.srv.Files.Get("rate-limit").Do()
+	require.True(t, isRateLimitError(err))
+
+	metrics, err = driver.GetDriveMetrics()
+	require.NoError(t, err)
+	require.EqualValues(t, 11, metrics.APICalls)
+	require.EqualValues(t, 1, metrics.CacheHits)
+	require.EqualValues(t, 1, metrics.ServerErrors)
+	require.EqualValues(t, 3, metrics.RateLimitErrors)
+	require.EqualValues(t, 1, metrics.ClientErrors)
+	require.EqualValues(t, 1, metrics.NetworkErrors)
+}
@@ -0,0 +1,29 @@
+package gdriver
+
+import "google.golang.org/api/googleapi"
+
+// minimalListFields are the fields that are requested by listings if WithMinimalFields is used
+const minimalListFields googleapi.Field = "files(id,name,mimeType)"
+
+// WithMinimalFields only requests the id, name and mime type of files in Stat, ListDirectory, ReadDirN and Walk,
+// this shrinks the responses of large directories. The accessors of FileInfo return zero values for the data that was not requested
+// (e.g. Size and ModifiedTime). Operations that need more information (e.g. ListDirectorySorted) still request it.
+func WithMinimalFields() Option {
+	return func(driver *GDriver) error {
+		driver.minimalFields = true
+		return nil
+	}
+}
+
+// WalkMinimalFields only requests the id, name and mime type of the files for this walk (see WithMinimalFields)
+func WalkMinimalFields() WalkOption {
+	return walkFields(minimalListFields)
+}
+
+// defaultListFields returns the fields that listings request
+func (d *GDriver) defaultListFields() googleapi.Field {
+	if d.minimalFields {
+		return minimalListFields
+	}
+	return listFields[0]
+}
@@ -0,0 +1,121 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+var fieldsStubFiles = regexp.MustCompile(`files\(([^)]*)\)`)
+
+// newFieldsStub returns a driver whose root directory contains n files,
+// the server only responds with the requested fields and counts the bytes of the responses in payload
+func newFieldsStub(t testing.TB, n int) (*GDriver, *int64, func()) {
+	var payload int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := fieldsStubFiles.FindStringSubmatch(r.URL.Query().Get("fields"))
+		require.NotNil(t, match, "fields must have the form files(...)")
+		requested := make(map[string]bool)
+		for _, field := range strings.Split(match[1], ",") {
+			requested[field] = true
+		}
+
+		files := make([]map[string]interface{}, n)
+		for i := range files {
+			file := map[string]interface{}{
+				"id":             strconv.Itoa(i),
+				"name":           fmt.Sprintf("File%d", i),
+				"mimeType":       "text/plain",
+				"createdTime":    "2019-01-01T00:00:00.000Z",
+				"modifiedTime":   "2019-01-02T00:00:00.000Z",
+				"size":           "1024",
+				"headRevisionId": "0B7xTz3pGlhl9",
+				"appProperties":  map[string]string{},
+			}
+			for key := range file {
+				if !requested[key] {
+					delete(file, key)
+				}
+			}
+			files[i] = file
+		}
+		body, err := json.Marshal(map[string]interface{}{"files": files})
+		require.NoError(t, err)
+		atomic.AddInt64(&payload, int64(len(body)))
+		w.Write(body) // nolint: errcheck
+	}))
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	return &GDriver{
+		client:   ts.Client(),
+		srv:      srv,
+		rootNode: &FileInfo{item: &drive.File{Id: "root", MimeType: mimeTypeFolder}},
+	}, &payload, ts.Close
+}
+
+func TestWithMinimalFields(t *testing.T) {
+	listPayload := func(t *testing.T, opts ...Option) (int64, []*FileInfo) {
+		driver, payload, teardown := newFieldsStub(t, 100)
+		defer teardown()
+		for _, opt := range opts {
+			require.NoError(t, opt(driver))
+		}
+		var files []*FileInfo
+		require.NoError(t, driver.ListDirectory("", func(f *FileInfo) error {
+			files = append(files, f)
+			return nil
+		}))
+		return atomic.LoadInt64(payload), files
+	}
+
+	fullPayload, files := listPayload(t)
+	require.Len(t, files, 100)
+	require.EqualValues(t, 1024, files[0].Size())
+	require.False(t, files[0].ModifiedTime().IsZero())
+
+	minimalPayload, files := listPayload(t, WithMinimalFields())
+	require.Len(t, files, 100)
+	require.Equal(t, "File0", files[0].Name())
+	require.False(t, files[0].IsDir())
+	// the accessors return zero values for the missing data
+	require.Zero(t, files[0].Size())
+	require.True(t, files[0].ModifiedTime().IsZero())
+	require.True(t, files[0].CreationTime().IsZero())
+
+	require.True(t, minimalPayload*2 < fullPayload, "expected the minimal payload (%d bytes) to be less than half of the full payload (%d bytes)", minimalPayload, fullPayload)
+}
+
+func benchmarkListFields(b *testing.B, opts ...Option) {
+	driver, payload, teardown := newFieldsStub(b, 1000)
+	defer teardown()
+	for _, opt := range opts {
+		require.NoError(b, opt(driver))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, driver.ListDirectory("", func(f *FileInfo) error {
+			return nil
+		}))
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(payload))/float64(b.N), "payload-bytes/op")
+}
+
+func BenchmarkListDirectoryFullFields(b *testing.B) {
+	benchmarkListFields(b)
+}
+
+func BenchmarkListDirectoryMinimalFields(b *testing.B) {
+	benchmarkListFields(b, WithMinimalFields())
+}
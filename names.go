@@ -0,0 +1,52 @@
+package gdriver
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameValidator checks a file or directory name before it is sent to drive,
+// errors that are not an InvalidNameError will be wrapped in one
+type NameValidator func(name string) error
+
+// WithNameValidator replaces the validation of the names used by PutFile, MakeDirectory, Rename and Move (see ValidateName).
+// The validator is only called with the names that are created or set: new directories and files and the new names of Rename and Move,
+// existing files and directories of the path are not validated. New names are validated before they are sent to drive.
+func WithNameValidator(fn NameValidator) Option {
+	return func(driver *GDriver) error {
+		driver.nameValidator = fn
+		return nil
+	}
+}
+
+// ValidateName is the default NameValidator,
+// it rejects empty names, names consisting only of whitespace and names containing path separators or control characters
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return InvalidNameError{Name: name, Reason: "name cannot be empty"}
+	}
+	for _, r := range name {
+		if isPathSeperator(r) {
+			return InvalidNameError{Name: name, Reason: "name cannot contain path separators"}
+		}
+		if unicode.IsControl(r) {
+			return InvalidNameError{Name: name, Reason: "name cannot contain control characters"}
+		}
+	}
+	return nil
+}
+
+// validateName validates a part of a path that will be created or set using the configured NameValidator
+func (d *GDriver) validateName(part string) error {
+	validator := d.nameValidator
+	if validator == nil {
+		validator = ValidateName
+	}
+	if err := validator(part); err != nil {
+		if _, ok := err.(InvalidNameError); ok {
+			return err
+		}
+		return InvalidNameError{Name: part, Reason: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package gdriver
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateName(t *testing.T) {
+	require.NoError(t, ValidateName("File1.txt"))
+	require.NoError(t, ValidateName(" File 1 "))
+	for _, name := range []string{"", "   ", "\t", "Folder/File", "Folder\\File", "File\x00", "File\n"} {
+		require.IsType(t, InvalidNameError{}, ValidateName(name), "name %q", name)
+	}
+}
+
+func TestNameValidation(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	var err error
+	_, err = driver.PutFile("Folder1/ /File1", bytes.NewBufferString("Hello World"))
+	require.IsType(t, InvalidNameError{}, err)
+	_, err = driver.MakeDirectory("Folder1/Fol\x01der2")
+	require.IsType(t, InvalidNameError{}, err)
+	_, err = driver.Rename("Folder1/File1", "File\t2")
+	require.IsType(t, InvalidNameError{}, err)
+	_, err = driver.Move("Folder1/File1", " /File2")
+	require.IsType(t, InvalidNameError{}, err)
+
+	// nothing was created
+	_, err = driver.Stat("Folder1/ ")
+	require.True(t, IsNotExist(err))
+
+	t.Run("custom validator", func(t *testing.T) {
+		require.NoError(t, WithNameValidator(func(name string) error {
+			if strings.HasPrefix(name, "~") {
+				return errors.New("temporary files are not allowed")
+			}
+			return nil
+		})(driver))
+
+		_, err := driver.PutFile("Folder1/~File1", bytes.NewBufferString("Hello World"))
+		require.Equal(t, InvalidNameError{Name: "~File1", Reason: "temporary files are not allowed"}, err)
+	})
+
+	t.Run("existing names are not validated", func(t *testing.T) {
+		require.NoError(t, WithNameValidator(func(name string) error {
+			if name == "Folder1" || name == "File1" {
+				return errors.New("reserved name")
+			}
+			return nil
+		})(driver))
+
+		// existing parents and files can still be used
+		_, err := driver.PutFile("Folder1/File1", bytes.NewBufferString("Hello Universe"))
+		require.NoError(t, err)
+		_, err = driver.MakeDirectory("Folder1/Folder2")
+		require.NoError(t, err)
+		_, err = driver.Move("Folder1/File1", "Folder1/Folder2/File2")
+		require.NoError(t, err)
+
+		// but they cannot be created
+		_, err = driver.MakeDirectory("Folder2/Folder1")
+		require.Equal(t, InvalidNameError{Name: "Folder1", Reason: "reserved name"}, err)
+		_, err = driver.PutFile("Folder1/Folder2/File1", bytes.NewBufferString("Hello World"))
+		require.Equal(t, InvalidNameError{Name: "File1", Reason: "reserved name"}, err)
+		_, err = driver.Rename("Folder1/Folder2/File2", "File1")
+		require.Equal(t, InvalidNameError{Name: "File1", Reason: "reserved name"}, err)
+	})
+}
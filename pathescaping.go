@@ -0,0 +1,78 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PathEscaping defines how path separators that are part of a file or directory name are escaped in paths.
+// Drive allows names like "a/b.txt", FileInfo.Path escapes the separators of such names so the paths
+// can be passed back to Stat, GetFile and all other functions that take a path.
+type PathEscaping struct {
+	// Slash replaces the "/" in names
+	Slash string
+	// Backslash replaces the "\" in names
+	Backslash string
+}
+
+var (
+	// UnicodePathEscaping escapes path separators with similar looking unicode characters ("a/b.txt" becomes "a⁄b.txt"),
+	// it is the default
+	UnicodePathEscaping = PathEscaping{Slash: "⁄", Backslash: "⧵"}
+	// PercentPathEscaping escapes path separators like urls ("a/b.txt" becomes "a%2Fb.txt")
+	PercentPathEscaping = PathEscaping{Slash: "%2F", Backslash: "%5C"}
+)
+
+// WithPathEscaping sets how path separators in names are escaped in paths (default is UnicodePathEscaping).
+// Names that contain the escape sequence itself are ambiguous, path resolution matches both forms of such names.
+//
+// Examples:
+//     WithPathEscaping(PercentPathEscaping)
+func WithPathEscaping(escaping PathEscaping) Option {
+	return func(driver *GDriver) error {
+		if escaping.Slash == "" || escaping.Backslash == "" || escaping.Slash == escaping.Backslash {
+			return errors.New("path escaping needs two different escape sequences")
+		}
+		if strings.IndexFunc(escaping.Slash+escaping.Backslash, isPathSeperator) >= 0 {
+			return errors.New("path escaping sequences cannot contain path separators")
+		}
+		driver.pathEscaping = escaping
+		return nil
+	}
+}
+
+// orDefault returns UnicodePathEscaping if the escaping was not set
+func (e PathEscaping) orDefault() PathEscaping {
+	if e.Slash == "" {
+		return UnicodePathEscaping
+	}
+	return e
+}
+
+// escape escapes the path separators in a name
+func (e PathEscaping) escape(name string) string {
+	e = e.orDefault()
+	return strings.NewReplacer("/", e.Slash, "\\", e.Backslash).Replace(name)
+}
+
+// unescape returns the name for an escaped part of a path
+func (e PathEscaping) unescape(part string) string {
+	e = e.orDefault()
+	return strings.NewReplacer(e.Slash, "/", e.Backslash, "\\").Replace(part)
+}
+
+// escapeQueryValue escapes a value for a string literal in a drive query
+func escapeQueryValue(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s)
+}
+
+// nameQuery returns the query term that matches the names of the files for the escaped part of a path
+func (d *GDriver) nameQuery(part string) string {
+	name := d.pathEscaping.unescape(part)
+	if name == part {
+		return fmt.Sprintf("name='%s'", escapeQueryValue(name))
+	}
+	// the name could also contain the escape sequence itself
+	return fmt.Sprintf("(name='%s' or name='%s')", escapeQueryValue(name), escapeQueryValue(part))
+}
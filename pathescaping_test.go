@@ -0,0 +1,97 @@
+package gdriver
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestPathEscaping(t *testing.T) {
+	require.Equal(t, "a⁄b⧵c.txt", UnicodePathEscaping.escape(`a/b\c.txt`))
+	require.Equal(t, `a/b\c.txt`, UnicodePathEscaping.unescape("a⁄b⧵c.txt"))
+	require.Equal(t, "a%2Fb%5Cc.txt", PercentPathEscaping.escape(`a/b\c.txt`))
+	require.Equal(t, "a⁄b.txt", PathEscaping{}.escape("a/b.txt"))
+	require.Equal(t, `name='it\'s'`, (&GDriver{}).nameQuery("it's"))
+	require.Equal(t, `(name='a/b' or name='a⁄b')`, (&GDriver{}).nameQuery("a⁄b"))
+
+	require.Error(t, WithPathEscaping(PathEscaping{Slash: "-"})(&GDriver{}))
+	require.Error(t, WithPathEscaping(PathEscaping{Slash: "-", Backslash: "-"})(&GDriver{}))
+	require.Error(t, WithPathEscaping(PathEscaping{Slash: "-", Backslash: "/"})(&GDriver{}))
+}
+
+func TestNamesWithPathSeparators(t *testing.T) {
+	for name, escaping := range map[string]PathEscaping{
+		"Unicode": UnicodePathEscaping,
+		"Percent": PercentPathEscaping,
+	} {
+		t.Run(name, func(t *testing.T) {
+			driver, teardown := setup(t)
+			defer teardown()
+			require.NoError(t, WithPathEscaping(escaping)(driver))
+
+			folder, err := driver.MakeDirectory("Folder1")
+			require.NoError(t, err)
+			for _, name := range []string{"a/b.txt", `c\d.txt`, "it's.txt"} {
+				_, err = driver.srv.Files.Create(&drive.File{Name: name, Parents: []string{folder.item.Id}}).Do()
+				require.NoError(t, err)
+			}
+			dir, err := driver.srv.Files.Create(&drive.File{
+				Name:     "e/f",
+				MimeType: mimeTypeFolder,
+				Parents:  []string{folder.item.Id},
+			}).Do()
+			require.NoError(t, err)
+			_, err = driver.srv.Files.Create(&drive.File{Name: "g/h.txt", Parents: []string{dir.Id}}).Do()
+			require.NoError(t, err)
+
+			var paths []string
+			require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+				paths = append(paths, f.Path())
+				fi, err := driver.Stat(f.Path())
+				require.NoError(t, err, f.Path())
+				require.Equal(t, f.item.Id, fi.item.Id, f.Path())
+				require.Equal(t, f.Name(), fi.Name())
+				return nil
+			}))
+			sort.Strings(paths)
+			require.Equal(t, []string{
+				"Folder1",
+				"Folder1/" + escaping.escape("a/b.txt"),
+				"Folder1/" + escaping.escape(`c\d.txt`),
+				"Folder1/" + escaping.escape("e/f"),
+				"Folder1/" + escaping.escape("e/f") + "/" + escaping.escape("g/h.txt"),
+				"Folder1/it's.txt",
+			}, paths)
+
+			// escaped names are unescaped when files are created
+			fi, err := driver.PutFile("Folder1/"+escaping.escape("x/y.txt"), strings.NewReader("Hello World"))
+			require.NoError(t, err)
+			require.Equal(t, "x/y.txt", fi.Name())
+			require.Equal(t, "Folder1/"+escaping.escape("x/y.txt"), fi.Path())
+		})
+	}
+}
+
+func TestDirectoryNamesWithQuotes(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	dir, err := driver.MakeDirectory("Folder1/it's")
+	require.NoError(t, err)
+	require.Equal(t, "it's", dir.Name())
+	require.Equal(t, "Folder1/it's", dir.Path())
+	// the existing directory is found instead of creating a second one
+	existing, err := driver.MakeDirectory("Folder1/it's")
+	require.NoError(t, err)
+	require.Equal(t, dir.item.Id, existing.item.Id)
+
+	fi, err := driver.PutFile("O'Brien/File1", strings.NewReader("Hello World"))
+	require.NoError(t, err)
+	require.Equal(t, "O'Brien/File1", fi.Path())
+	parent, err := driver.Stat("O'Brien")
+	require.NoError(t, err)
+	require.True(t, parent.IsDir())
+}
@@ -0,0 +1,62 @@
+package gdriver
+
+import (
+	drive "google.golang.org/api/drive/v3"
+)
+
+const permissionFields = "nextPageToken,permissions(id,type,role,emailAddress,domain,allowFileDiscovery,teamDrivePermissionDetails(inherited))"
+
+// copyPermissions recreates the permissions of the file srcID on the file dstID (the copy at dstPath),
+// owner and inherited permissions are skipped. Permissions that could not be created are returned as a PermissionCopyError.
+func (d *GDriver) copyPermissions(srcID, dstID, dstPath string) error {
+	var permissions []*drive.Permission
+	var pageToken string
+	for {
+		call := d.srv.Permissions.List(srcID).SupportsTeamDrives(true).Fields(permissionFields)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		list, err := call.Do()
+		if err != nil {
+			return err
+		}
+		permissions = append(permissions, list.Permissions...)
+		if pageToken = list.NextPageToken; pageToken == "" {
+			break
+		}
+	}
+
+	failed := make(map[string]error)
+	for _, permission := range permissions {
+		if permission.Role == "owner" || isInheritedPermission(permission) {
+			continue
+		}
+		_, err := d.srv.Permissions.Create(dstID, &drive.Permission{
+			Type:               permission.Type,
+			Role:               permission.Role,
+			EmailAddress:       permission.EmailAddress,
+			Domain:             permission.Domain,
+			AllowFileDiscovery: permission.AllowFileDiscovery,
+		}).SendNotificationEmail(false).SupportsTeamDrives(true).Fields("id").Do()
+		if err != nil {
+			failed[permission.Id] = err
+		}
+	}
+	if len(failed) > 0 {
+		return PermissionCopyError{Path: dstPath, Errors: failed}
+	}
+	return nil
+}
+
+// isInheritedPermission returns true if the permission is only inherited from a parent (only reported in shared drives)
+func isInheritedPermission(permission *drive.Permission) bool {
+	if len(permission.TeamDrivePermissionDetails) == 0 {
+		return false
+	}
+	for _, details := range permission.TeamDrivePermissionDetails {
+		if !details.Inherited {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestCopyPermissions(t *testing.T) {
+	var created []*drive.Permission
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/files/src/permissions":
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"nextPageToken":"page2","permissions":[
+					{"id":"owner","type":"user","role":"owner","emailAddress":"owner@example.com"},
+					{"id":"writer","type":"user","role":"writer","emailAddress":"writer@example.com"}
+				]}`)
+				return
+			}
+			fmt.Fprint(w, `{"permissions":[
+				{"id":"anyoneWithLink","type":"anyone","role":"reader"},
+				{"id":"inherited","type":"group","role":"reader","emailAddress":"team@example.com","teamDrivePermissionDetails":[{"inherited":true}]},
+				{"id":"domain","type":"domain","role":"commenter","domain":"example.com"}
+			]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/files/dst/permissions":
+			require.Equal(t, "false", r.URL.Query().Get("sendNotificationEmail"))
+			var permission drive.Permission
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&permission))
+			if permission.Type == "domain" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"error":{"code":403,"message":"domain sharing is not allowed"}}`)
+				return
+			}
+			created = append(created, &permission)
+			fmt.Fprint(w, `{"id":"new"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{srv: srv}
+
+	err = driver.copyPermissions("src", "dst", "Folder1/File1")
+	require.IsType(t, PermissionCopyError{}, err)
+	copyErr := err.(PermissionCopyError)
+	require.Equal(t, "Folder1/File1", copyErr.Path)
+	require.Len(t, copyErr.Errors, 1)
+	require.Contains(t, copyErr.Errors, "domain")
+	require.EqualError(t, err, "unable to copy 1 permission(s) to `Folder1/File1': googleapi: Error 403: domain sharing is not allowed")
+
+	// owner and inherited permissions are skipped
+	require.Equal(t, []*drive.Permission{
+		{Type: "user", Role: "writer", EmailAddress: "writer@example.com"},
+		{Type: "anyone", Role: "reader"},
+	}, created)
+}
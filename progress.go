@@ -0,0 +1,62 @@
+package gdriver
+
+import "sync"
+
+// ProgressReporter receives the progress of operations on multiple files (e.g. PutFilesWithOptions, RecursiveCopy or TransferDirectory).
+// The methods are never called concurrently, but they might be called from different goroutines.
+type ProgressReporter interface {
+	// ItemStarted will be called before an item is processed
+	ItemStarted(path string)
+	// ItemFinished will be called after an item was processed, bytes is the size of the item and err is the error that occurred (if any)
+	ItemFinished(path string, bytes int64, err error)
+	// Totals will be called after every finished item
+	Totals(done, total int)
+}
+
+// batchReport serializes the progress reports of an operation
+type batchReport struct {
+	mu       sync.Mutex
+	done     int
+	total    int
+	progress func(done, total int)
+	reporter ProgressReporter
+}
+
+// newBatchReport creates a report for total items, progress and reporter can be nil
+func newBatchReport(total int, progress func(done, total int), reporter ProgressReporter) *batchReport {
+	return &batchReport{
+		total:    total,
+		progress: progress,
+		reporter: reporter,
+	}
+}
+
+func (r *batchReport) started(path string) {
+	if r.reporter == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reporter.ItemStarted(path)
+}
+
+func (r *batchReport) finished(path string, bytes int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done++
+	if r.reporter != nil {
+		r.reporter.ItemFinished(path, bytes, err)
+		r.reporter.Totals(r.done, r.total)
+	}
+	if r.progress != nil {
+		r.progress(r.done, r.total)
+	}
+}
+
+// fileSize returns the size of fi, or 0 if fi is nil
+func fileSize(fi *FileInfo) int64 {
+	if fi == nil {
+		return 0
+	}
+	return fi.Size()
+}
@@ -0,0 +1,77 @@
+package gdriver
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingReporter records the events and fails if it is called concurrently
+type recordingReporter struct {
+	t        *testing.T
+	active   int32
+	started  []string
+	finished []string
+	errors   int
+	done     int
+	total    int
+}
+
+func (r *recordingReporter) enter() func() {
+	if !atomic.CompareAndSwapInt32(&r.active, 0, 1) {
+		r.t.Error("reporter was called concurrently")
+	}
+	// give other goroutines the chance to call concurrently
+	time.Sleep(time.Millisecond)
+	return func() {
+		atomic.StoreInt32(&r.active, 0)
+	}
+}
+
+func (r *recordingReporter) ItemStarted(path string) {
+	defer r.enter()()
+	r.started = append(r.started, path)
+}
+
+func (r *recordingReporter) ItemFinished(path string, bytes int64, err error) {
+	defer r.enter()()
+	r.finished = append(r.finished, path)
+	if err != nil {
+		r.errors++
+	}
+}
+
+func (r *recordingReporter) Totals(done, total int) {
+	defer r.enter()()
+	r.done = done
+	r.total = total
+}
+
+func TestProgressReporter(t *testing.T) {
+	// items without a reader fail before any request is sent
+	driver := &GDriver{}
+	var items []PutItem
+	for i := 0; i < 20; i++ {
+		items = append(items, PutItem{Path: fmt.Sprintf("File%d", i)})
+	}
+
+	reporter := &recordingReporter{t: t}
+	var progressCalls int
+	result := driver.PutFilesWithOptions(items, BatchOptions{
+		Concurrency: 4,
+		Progress: func(done, total int) {
+			progressCalls++
+		},
+		Reporter: reporter,
+	})
+	require.Len(t, result.Failed(), len(items))
+	require.Len(t, reporter.started, len(items))
+	require.Len(t, reporter.finished, len(items))
+	require.Equal(t, len(items), reporter.errors)
+	require.Equal(t, len(items), reporter.done)
+	require.Equal(t, len(items), reporter.total)
+	require.Equal(t, len(items), progressCalls)
+}
@@ -0,0 +1,131 @@
+package gdriver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// PushChannel is a notification channel that was created with CreatePushChannel,
+// drive sends a request to the webhook of the channel whenever the watched file changes until the channel expires or is stopped
+type PushChannel struct {
+	driver  *GDriver
+	fileID  string
+	address string
+	channel *drive.Channel
+}
+
+// ID returns the id of the channel, drive sends it in the X-Goog-Channel-ID header of the notifications
+func (c *PushChannel) ID() string {
+	return c.channel.Id
+}
+
+// ResourceID returns the opaque id of the watched file, drive sends it in the X-Goog-Resource-ID header of the notifications
+func (c *PushChannel) ResourceID() string {
+	return c.channel.ResourceId
+}
+
+// Expiry returns the time when drive stops sending notifications for the channel
+func (c *PushChannel) Expiry() time.Time {
+	return time.Unix(0, c.channel.Expiration*int64(time.Millisecond))
+}
+
+// Stop stops the notifications for the channel
+func (c *PushChannel) Stop() error {
+	return c.driver.srv.Channels.Stop(&drive.Channel{
+		Id:         c.channel.Id,
+		ResourceId: c.channel.ResourceId,
+	}).Do()
+}
+
+// Renew stops the channel and creates a new channel with the id channelID for the same file and webhook (see CreatePushChannel),
+// channel ids cannot be reused. Notifications for changes between stopping and creating the channel are lost.
+func (c *PushChannel) Renew(channelID string, ttl time.Duration) (*PushChannel, error) {
+	if err := c.Stop(); err != nil {
+		return nil, err
+	}
+	return c.driver.watchFile(c.fileID, channelID, c.address, ttl)
+}
+
+// KeepAlive renews the channel margin before it expires (see Renew) until ctx is done, then the current channel is stopped
+// and ctx.Err() is returned. The ids of the new channels are created by nextID, onRenew is called (if not nil) with every new channel.
+// If a renewal fails the error is returned, the previous channel is already stopped.
+//
+// Examples:
+//     channel.KeepAlive(ctx, time.Hour, 5*time.Minute, newChannelID, func(c *PushChannel) { webhook.Expect(c.ID()) })
+func (c *PushChannel) KeepAlive(ctx context.Context, ttl, margin time.Duration, nextID func() string, onRenew func(*PushChannel)) error {
+	if ttl <= margin {
+		return errors.New("ttl must be greater than margin")
+	}
+	current := c
+	for {
+		timer := time.NewTimer(current.Expiry().Sub(c.driver.now()) - margin)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err := current.Stop(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		next, err := current.Renew(nextID(), ttl)
+		if err != nil {
+			return err
+		}
+		current = next
+		if onRenew != nil {
+			onRenew(current)
+		}
+	}
+}
+
+// CreatePushChannel asks drive to send notifications about changes of the file or directory path to webhookURL,
+// channelID must be unique (e.g. a uuid). Notifications are sent until the channel is stopped or ttl elapsed,
+// if ttl is 0 drive uses its default lifetime (one hour), drive limits the lifetime to one day.
+// Drive only sends notifications to https urls of domains that were verified for the project.
+// Notifications about directories only report changes of the directory itself, not of its contents.
+//
+// Examples:
+//     CreatePushChannel("Folder1/File1", "01234567-89ab-cdef-0123-456789abcdef", "https://example.com/notifications", time.Hour)
+func (d *GDriver) CreatePushChannel(path, channelID, webhookURL string, ttl time.Duration) (*PushChannel, error) {
+	file, err := d.getFile(d.rootNode, path, "files(id)")
+	if err != nil {
+		return nil, err
+	}
+	return d.watchFile(file.item.Id, channelID, webhookURL, ttl)
+}
+
+// watchFile creates a notification channel for the file with the drive id fileID
+func (d *GDriver) watchFile(fileID, channelID, webhookURL string, ttl time.Duration) (*PushChannel, error) {
+	if channelID == "" {
+		return nil, errors.New("channel id cannot be empty")
+	}
+	if webhookURL == "" {
+		return nil, errors.New("webhook url cannot be empty")
+	}
+	if ttl < 0 {
+		return nil, errors.New("ttl cannot be negative")
+	}
+	channel := &drive.Channel{
+		Id:      channelID,
+		Type:    "web_hook",
+		Address: webhookURL,
+	}
+	if ttl > 0 {
+		channel.Expiration = d.now().Add(ttl).UnixNano() / int64(time.Millisecond)
+	}
+	created, err := d.srv.Files.Watch(fileID, channel).Do()
+	if err != nil {
+		return nil, err
+	}
+	return &PushChannel{
+		driver:  d,
+		fileID:  fileID,
+		address: webhookURL,
+		channel: created,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package gdriver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+func TestPushChannel(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	const webhook = "https://example.com/notifications"
+
+	t.Run("create and stop", func(t *testing.T) {
+		before := time.Now()
+		channel, err := driver.CreatePushChannel("Folder1/File1", "channel-1", webhook, time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, "channel-1", channel.ID())
+		require.NotEmpty(t, channel.ResourceID())
+		require.WithinDuration(t, before.Add(time.Hour), channel.Expiry(), time.Minute)
+
+		// channel ids must be unique
+		_, err = driver.CreatePushChannel("Folder1/File1", "channel-1", webhook, time.Hour)
+		require.Error(t, err)
+
+		require.NoError(t, channel.Stop())
+		err = channel.Stop()
+		require.Error(t, err)
+		require.Equal(t, 404, err.(*googleapi.Error).Code)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := driver.CreatePushChannel("Folder1/File2", "channel-2", webhook, time.Hour)
+		require.True(t, IsNotExist(err))
+		_, err = driver.CreatePushChannel("Folder1/File1", "", webhook, time.Hour)
+		require.Error(t, err)
+		_, err = driver.CreatePushChannel("Folder1/File1", "channel-2", "", time.Hour)
+		require.Error(t, err)
+	})
+
+	t.Run("renew", func(t *testing.T) {
+		channel, err := driver.CreatePushChannel("Folder1/File1", "channel-3", webhook, time.Hour)
+		require.NoError(t, err)
+		renewed, err := channel.Renew("channel-4", 2*time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, "channel-4", renewed.ID())
+		require.Equal(t, channel.ResourceID(), renewed.ResourceID())
+		require.True(t, renewed.Expiry().After(channel.Expiry()))
+
+		// the old channel was stopped
+		require.Error(t, channel.Stop())
+		require.NoError(t, renewed.Stop())
+	})
+
+	t.Run("keep alive", func(t *testing.T) {
+		channel, err := driver.CreatePushChannel("Folder1/File1", "channel-5", webhook, 300*time.Millisecond)
+		require.NoError(t, err)
+
+		var ids []string
+		var current *PushChannel
+		n := 5
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		err = channel.KeepAlive(ctx, 300*time.Millisecond, 250*time.Millisecond, func() string {
+			n++
+			return fmt.Sprintf("channel-%d", n)
+		}, func(c *PushChannel) {
+			ids = append(ids, c.ID())
+			current = c
+			if len(ids) == 2 {
+				cancel()
+			}
+		})
+		require.Equal(t, context.Canceled, err)
+		require.Equal(t, []string{"channel-6", "channel-7"}, ids)
+
+		// all channels were stopped
+		require.Error(t, channel.Stop())
+		require.Error(t, current.Stop())
+	})
+}
@@ -0,0 +1,206 @@
+package gdriver
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+type queryOptions struct {
+	raw    bool
+	filter listFilter
+}
+
+// QueryOption can be used to pass optional options to ListByQuery
+type QueryOption func(options *queryOptions)
+
+// QueryRaw sends the query as it is, trashed files and files outside of the root directory will be reported too.
+// Files that are not in the root directory have no parent path (see ListOrphanedFiles).
+func QueryRaw() QueryOption {
+	return func(options *queryOptions) {
+		options.raw = true
+	}
+}
+
+// ListByQuery calls fn for every file that matches the drive query rawQuery, return SkipAll in fn to stop the listing.
+// Only files in the root directory that are not trashed are reported, use QueryRaw to disable these constraints.
+//
+// Examples:
+//     ListByQuery("visibility = 'anyoneWithLink'", fn)
+func (d *GDriver) ListByQuery(rawQuery string, fn func(*FileInfo) error, opts ...QueryOption) error {
+	var options queryOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := options.filter.validate(); err != nil {
+		return err
+	}
+	query := rawQuery
+	if !options.raw {
+		query = fmt.Sprintf("(%s) and trashed = false", rawQuery)
+	}
+	if clause := options.filter.clause(); clause != "" {
+		query = fmt.Sprintf("(%s) and %s", query, clause)
+	}
+
+	ancestors := newAncestry(d.srv, d.rootNode.item.Id, d.metrics, d.pathEscaping)
+	fields := options.filter.fields(googleapi.Field(fmt.Sprintf("files(%s,parents)", googleapi.CombineFields(fileInfoFields))))
+	var pageToken string
+	for {
+		call := d.srv.Files.List().Q(query).Fields(fields, "nextPageToken")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		list, err := call.Do()
+		if err != nil {
+			return QueryError{Query: query, NestedError: err}
+		}
+		if list == nil {
+			return fmt.Errorf("no file information present (for query `%s')", query)
+		}
+
+		for _, file := range list.Files {
+			if file.Id == d.rootNode.item.Id {
+				continue
+			}
+			inRoot, parentPath, err := ancestors.resolve(file)
+			if err != nil {
+				return err
+			}
+			if !inRoot && !options.raw {
+				continue
+			}
+			fi := &FileInfo{item: file, parentPath: parentPath, pathEscaping: d.pathEscaping}
+			if !options.filter.includesSize(fi) {
+				continue
+			}
+			if err = fn(fi); err != nil {
+				return callbackError(err)
+			}
+		}
+
+		if pageToken = list.NextPageToken; pageToken == "" {
+			return nil
+		}
+	}
+}
+
+// GetDrivePath returns the path of the file or directory with the drive id driveID,
+// a FileNotExistError will be returned if it is not in the root directory
+func (d *GDriver) GetDrivePath(driveID string) (string, error) {
+	paths, err := d.GetDrivePaths([]string{driveID})
+	if err != nil {
+		return "", err
+	}
+	return paths[driveID], nil
+}
+
+// GetDrivePaths returns the paths of multiple drive ids (see GetDrivePath), keyed by their id.
+// Parent directories that are shared by the files are only fetched once.
+func (d *GDriver) GetDrivePaths(driveIDs []string) (map[string]string, error) {
+	ancestors := newAncestry(d.srv, d.rootNode.item.Id, d.metrics, d.pathEscaping)
+	paths := make(map[string]string, len(driveIDs))
+	for _, id := range driveIDs {
+		file, err := ancestors.dir(id)
+		if err != nil {
+			return nil, err
+		}
+		if !file.inRoot {
+			return nil, FileNotExistError{Path: id}
+		}
+		paths[id] = file.path
+	}
+	return paths, nil
+}
+
+// GetFilesByParentID calls fn for every child of the directory with the drive id parentDriveID (see FileInfo.DriveFile),
+// return SkipAll in fn to stop the listing. The path of the directory is resolved only once,
+// a FileNotExistError will be returned if it is not in the root directory.
+func (d *GDriver) GetFilesByParentID(parentDriveID string, fn func(*FileInfo) error) error {
+	parentPath, err := d.GetDrivePath(parentDriveID)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("'%s' in parents and trashed = false", escapeQueryValue(parentDriveID))
+	return d.listFiles(query, listFields[0], func(f *drive.File) error {
+		if err := fn(&FileInfo{item: f, parentPath: parentPath, pathEscaping: d.pathEscaping}); err != nil {
+			return wrapCallbackError(err)
+		}
+		return nil
+	})
+}
+
+// ancestor is the resolved path of a file or directory
+type ancestor struct {
+	inRoot bool
+	path   string
+}
+
+// ancestry resolves the paths of files relative to the root directory,
+// the paths of the parent directories are cached so every directory is fetched only once
+type ancestry struct {
+	srv          *drive.Service
+	rootID       string
+	dirs         map[string]ancestor
+	metrics      *DriveMetrics
+	pathEscaping PathEscaping
+}
+
+func newAncestry(srv *drive.Service, rootID string, metrics *DriveMetrics, pathEscaping PathEscaping) *ancestry {
+	return &ancestry{
+		srv:          srv,
+		rootID:       rootID,
+		dirs:         map[string]ancestor{rootID: {inRoot: true}},
+		metrics:      metrics,
+		pathEscaping: pathEscaping,
+	}
+}
+
+// resolve returns true and the parent path if the file is in the root directory
+func (a *ancestry) resolve(file *drive.File) (bool, string, error) {
+	for _, parentID := range file.Parents {
+		dir, err := a.dir(parentID)
+		if err != nil {
+			return false, "", err
+		}
+		if dir.inRoot {
+			return true, dir.path, nil
+		}
+	}
+	return false, "", nil
+}
+
+// dir returns the resolved path of the file or directory id, trashed files are not in the root directory
+func (a *ancestry) dir(id string) (ancestor, error) {
+	if dir, ok := a.dirs[id]; ok {
+		if id != a.rootID {
+			a.metrics.cacheHit()
+		}
+		return dir, nil
+	}
+
+	var dir ancestor
+	file, err := a.srv.Files.Get(id).Fields("id,name,parents,trashed").Do()
+	if err != nil {
+		apiErr, ok := err.(*googleapi.Error)
+		if !ok || apiErr.Code != http.StatusNotFound {
+			return dir, err
+		}
+		// the directory was deleted or is not accessible
+	} else if !file.Trashed {
+		inRoot, parentPath, err := a.resolve(file)
+		if err != nil {
+			return dir, err
+		}
+		if inRoot {
+			dir = ancestor{inRoot: true, path: path.Join(parentPath, a.pathEscaping.escape(file.Name))}
+		}
+	}
+	a.dirs[id] = dir
+	return dir, nil
+}
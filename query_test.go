@@ -0,0 +1,92 @@
+package gdriver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+func TestListByQuery(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Folder1", true)
+	stub.add("1", "2", "Folder2", true)
+	stub.add("2", "3", "File1", false)
+	stub.add("2", "4", "File2", false)
+	stub.add("1", "5", "File3", false)
+	stub.add("other", "6", "File4", false)
+
+	const query = "visibility = 'anyoneWithLink'"
+	shared := []*drive.File{stub.files["3"], stub.files["4"], stub.files["5"], stub.files["6"]}
+	stub.queries["("+query+") and trashed = false"] = shared
+	stub.queries[query] = shared
+
+	t.Run("in root", func(t *testing.T) {
+		stub.getRequests = 0
+		var paths []string
+		require.NoError(t, driver.ListByQuery(query, func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}))
+		require.Equal(t, []string{"Folder1/Folder2/File1", "Folder1/Folder2/File2", "Folder1/File3"}, paths)
+		// Folder1, Folder2 and other are fetched once
+		require.EqualValues(t, 3, stub.getRequests)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		var paths []string
+		require.NoError(t, driver.ListByQuery(query, func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, QueryRaw()))
+		require.Equal(t, []string{"Folder1/Folder2/File1", "Folder1/Folder2/File2", "Folder1/File3", "File4"}, paths)
+	})
+
+	t.Run("skip all", func(t *testing.T) {
+		var calls int
+		require.NoError(t, driver.ListByQuery(query, func(f *FileInfo) error {
+			calls++
+			return SkipAll
+		}))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("malformed query", func(t *testing.T) {
+		err := driver.ListByQuery("visibility ==", func(f *FileInfo) error {
+			return nil
+		})
+		require.IsType(t, QueryError{}, err)
+		require.Equal(t, "(visibility ==) and trashed = false", err.(QueryError).Query)
+		require.Equal(t, http.StatusBadRequest, err.(QueryError).NestedError.(*googleapi.Error).Code)
+	})
+}
+
+func TestGetDrivePaths(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Folder1", true)
+	stub.add("1", "2", "Folder2", true)
+	stub.add("2", "3", "File1", false)
+	stub.add("2", "4", "File2", false)
+	stub.add("other", "5", "File3", false)
+
+	paths, err := driver.GetDrivePaths([]string{"3", "4", "root"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"3":    "Folder1/Folder2/File1",
+		"4":    "Folder1/Folder2/File2",
+		"root": "",
+	}, paths)
+	// File1, File2, Folder2 and Folder1 are fetched once
+	require.EqualValues(t, 4, stub.getRequests)
+
+	_, err = driver.GetDrivePath("5")
+	require.True(t, IsNotExist(err))
+	_, err = driver.GetDrivePath("6")
+	require.True(t, IsNotExist(err))
+}
@@ -0,0 +1,257 @@
+package gdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// uploadChunkSize is the size of the chunks that will be uploaded in a resumable session,
+// it must be a multiple of 256 KiB
+const uploadChunkSize = 8 * 1024 * 1024
+
+// UploadSessionFunc will be called with the path and the session URI as soon as a resumable upload session was created,
+// store the session URI to be able to continue an interrupted upload with ResumeUpload
+type UploadSessionFunc func(path, sessionURI string)
+
+// WithUploadSessionCallback uploads all files using resumable upload sessions and reports their session URI to fn.
+//
+// Note that the contents are uploaded after they were encoded by WithEncryption or WithCompression,
+// so ResumeUpload can only continue uploads of files that were uploaded without those options.
+func WithUploadSessionCallback(fn UploadSessionFunc) Option {
+	return func(driver *GDriver) error {
+		driver.uploadSessionFunc = fn
+		return nil
+	}
+}
+
+// ResumeUpload continues an interrupted resumable upload session,
+// r must provide the same contents of size bytes that were used for the interrupted upload.
+// The session status is queried and the upload continues at the offset the server confirmed,
+// offsetHint is only used if the status of the session could not be determinated.
+func (d *GDriver) ResumeUpload(sessionURI string, r io.ReaderAt, size int64, offsetHint int64) (_ *FileInfo, err error) {
+	defer d.audit("ResumeUpload", sessionURI, "")(&err)
+	if size < 0 {
+		return nil, errors.New("size cannot be negative")
+	}
+	if offsetHint < 0 || offsetHint > size {
+		return nil, fmt.Errorf("offset hint %d is out of range", offsetHint)
+	}
+
+	offset, file, err := d.queryUploadSession(sessionURI, size)
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); !ok || apiErr.Code < 500 {
+			return nil, err
+		}
+		offset = offsetHint
+	}
+
+	if file == nil {
+		file, err = d.uploadToSession(sessionURI, io.NewSectionReader(r, offset, size-offset), offset, size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.newFileInfoInRoot(file)
+}
+
+// queryUploadSession returns the offset the server confirmed for the session,
+// if the upload is already complete the uploaded file will be returned
+func (d *GDriver) queryUploadSession(sessionURI string, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequest(http.MethodPut, sessionURI, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	return d.sendUploadRequest(req)
+}
+
+// createUploadSession starts a resumable upload session for the file and returns the session URI
+// method and urlPath must describe the files.create or files.update call
+// size is the size of the contents, or -1 if it is unknown
+func (d *GDriver) createUploadSession(method, urlPath string, file *drive.File, contentType string, size int64) (string, error) {
+	metadata := new(bytes.Buffer)
+	if file != nil {
+		if err := json.NewEncoder(metadata).Encode(file); err != nil {
+			return "", err
+		}
+	}
+
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("fields", googleapi.CombineFields(append(fileInfoFields, "parents")))
+	req, err := http.NewRequest(method, d.uploadURL(urlPath)+"?"+params.Encode(), metadata)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	if contentType != "" {
+		req.Header.Set("X-Upload-Content-Type", contentType)
+	}
+	if size >= 0 {
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	response, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer googleapi.CloseBody(response)
+	if err = googleapi.CheckResponse(response); err != nil {
+		return "", err
+	}
+	sessionURI := response.Header.Get("Location")
+	if sessionURI == "" {
+		return "", errors.New("no upload session was created")
+	}
+	return sessionURI, nil
+}
+
+// uploadToSession uploads the contents of r to the session starting at offset,
+// if size is negative the size is unknown and r will be read until io.EOF
+func (d *GDriver) uploadToSession(sessionURI string, r io.Reader, offset, size int64) (*drive.File, error) {
+	chunk := make([]byte, uploadChunkSize)
+	// pending holds the bytes of the chunk that have not been confirmed by the server yet
+	var pending []byte
+	for {
+		if len(pending) == 0 {
+			n, err := io.ReadFull(r, chunk)
+			switch err {
+			case nil:
+			case io.EOF, io.ErrUnexpectedEOF:
+				size = offset + int64(n)
+			default:
+				return nil, err
+			}
+			pending = chunk[:n]
+		}
+
+		req, err := http.NewRequest(http.MethodPut, sessionURI, bytes.NewReader(pending))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(pending))
+		total := "*"
+		if size >= 0 {
+			total = strconv.FormatInt(size, 10)
+		}
+		if len(pending) == 0 {
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes */%s", total))
+		} else {
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", offset, offset+int64(len(pending))-1, total))
+		}
+
+		confirmed, file, err := d.sendUploadRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			return file, nil
+		}
+
+		// the server might have persisted less than we sent
+		if confirmed < offset || confirmed > offset+int64(len(pending)) {
+			return nil, fmt.Errorf("upload session confirmed unexpected offset %d", confirmed)
+		}
+		pending = pending[confirmed-offset:]
+		offset = confirmed
+		if len(pending) == 0 && size >= 0 && offset >= size {
+			return nil, errors.New("upload session did not complete")
+		}
+	}
+}
+
+// sendUploadRequest sends a request to an upload session,
+// it returns the confirmed offset for incomplete uploads or the file for complete uploads
+func (d *GDriver) sendUploadRequest(req *http.Request) (int64, *drive.File, error) {
+	response, err := d.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer googleapi.CloseBody(response)
+
+	// 308 Resume Incomplete
+	if response.StatusCode == 308 {
+		confirmed, err := parseUploadRange(response.Header.Get("Range"))
+		return confirmed, nil, err
+	}
+
+	if err = googleapi.CheckResponse(response); err != nil {
+		return 0, nil, err
+	}
+	var file drive.File
+	if err = json.NewDecoder(response.Body).Decode(&file); err != nil {
+		return 0, nil, err
+	}
+	return 0, &file, nil
+}
+
+// parseUploadRange parses the Range header of an upload session and returns the next offset
+func parseUploadRange(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.LastIndexByte(s, '-')
+	if !strings.HasPrefix(s, "bytes=") || i < 0 {
+		return 0, fmt.Errorf("invalid range `%s'", s)
+	}
+	end, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range `%s': %v", s, err)
+	}
+	return end + 1, nil
+}
+
+// uploadURL returns the upload url for the api path
+func (d *GDriver) uploadURL(urlPath string) string {
+	return strings.Replace(googleapi.ResolveRelative(d.srv.BasePath, urlPath), "/drive/v3/", "/upload/drive/v3/", 1)
+}
+
+// uploadResumable uploads the contents using a resumable session and reports the session to the UploadSessionFunc (if set)
+func (d *GDriver) uploadResumable(filePath, method, urlPath string, file *drive.File, contents *encodedContents) (*drive.File, error) {
+	sessionURI, err := d.createUploadSession(method, urlPath, file, contents.contentType, contents.size)
+	if err != nil {
+		return nil, err
+	}
+	if d.uploadSessionFunc != nil {
+		d.uploadSessionFunc(filePath, sessionURI)
+	}
+	uploaded, err := d.uploadToSession(sessionURI, contents.reader, 0, contents.size)
+	if _, ok := err.(ErrUploadTooLarge); ok {
+		d.cancelUploadSession(sessionURI)
+	}
+	return uploaded, err
+}
+
+// newFileInfoInRoot creates the FileInfo for a file with an unknown path
+func (d *GDriver) newFileInfoInRoot(file *drive.File) (*FileInfo, error) {
+	if len(file.Parents) == 0 {
+		var err error
+		file, err = d.srv.Files.Get(file.Id).Fields(append(fileInfoFields, "parents")...).Do()
+		if err != nil {
+			return nil, err
+		}
+	}
+	inRoot, parentPath, err := d.isInRoot(d.rootNode.item.Id, file, "")
+	if err != nil {
+		return nil, err
+	}
+	if !inRoot {
+		return nil, fmt.Errorf("`%s' is not in the root directory", file.Name)
+	}
+	return &FileInfo{
+		item:         file,
+		parentPath:   parentPath,
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
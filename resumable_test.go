@@ -0,0 +1,250 @@
+package gdriver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+// uploadSessionStub implements the server side of a resumable upload session
+type uploadSessionStub struct {
+	mu       sync.Mutex
+	received []byte
+	// failStatus will be returned for status queries if set
+	failStatus int
+	sessionURI string
+	// uploadLength is the announced size of the upload
+	uploadLength string
+	// totals holds the total sizes of all Content-Range headers
+	totals []string
+}
+
+func (s *uploadSessionStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/upload/drive/v3/files" && r.Method == http.MethodPost:
+		if r.URL.Query().Get("uploadType") != "resumable" {
+			http.Error(w, "invalid upload type", http.StatusBadRequest)
+			return
+		}
+		s.uploadLength = r.Header.Get("X-Upload-Content-Length")
+		w.Header().Set("Location", s.sessionURI)
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == "/session" && r.Method == http.MethodPut:
+		contentRange := strings.TrimPrefix(r.Header.Get("Content-Range"), "bytes ")
+		parts := strings.SplitN(contentRange, "/", 2)
+		if len(parts) != 2 {
+			http.Error(w, "invalid content range", http.StatusBadRequest)
+			return
+		}
+		s.totals = append(s.totals, parts[1])
+		if parts[0] == "*" && s.failStatus != 0 {
+			w.WriteHeader(s.failStatus)
+			return
+		}
+		if parts[0] != "*" {
+			var start, end int
+			if _, err := fmt.Sscanf(parts[0], "%d-%d", &start, &end); err != nil || start > len(s.received) {
+				http.Error(w, "invalid content range", http.StatusBadRequest)
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil || len(body) != end-start+1 {
+				http.Error(w, "invalid body", http.StatusBadRequest)
+				return
+			}
+			s.received = append(s.received[:start], body...)
+		}
+		if parts[1] != "*" {
+			total, err := strconv.Atoi(parts[1])
+			if err != nil {
+				http.Error(w, "invalid content range", http.StatusBadRequest)
+				return
+			}
+			if total == len(s.received) {
+				fmt.Fprintf(w, `{"id":"1","name":"File1","size":"%d","parents":["root"]}`, total)
+				return
+			}
+		}
+		if len(s.received) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.received)-1))
+		}
+		w.WriteHeader(308)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newUploadSessionStub(t *testing.T) (*GDriver, *uploadSessionStub, func()) {
+	stub := &uploadSessionStub{}
+	ts := httptest.NewServer(stub)
+	stub.sessionURI = ts.URL + "/session"
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+
+	return &GDriver{
+		client:   ts.Client(),
+		srv:      srv,
+		rootNode: &FileInfo{item: &drive.File{Id: "root"}},
+	}, stub, ts.Close
+}
+
+func TestUploadResumable(t *testing.T) {
+	driver, stub, teardown := newUploadSessionStub(t)
+	defer teardown()
+
+	data := make([]byte, uploadChunkSize+10)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	var sessionURI string
+	driver.uploadSessionFunc = func(path, uri string) {
+		require.Equal(t, "File1", path)
+		sessionURI = uri
+	}
+
+	file, err := driver.uploadResumable("File1", http.MethodPost, "files", &drive.File{Name: "File1"}, &encodedContents{
+		reader: bytes.NewReader(data),
+		size:   -1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1", file.Id)
+	require.NotEmpty(t, sessionURI)
+	require.EqualValues(t, data, stub.received)
+}
+
+func TestUploadKnownSize(t *testing.T) {
+	driver, stub, teardown := newUploadSessionStub(t)
+	defer teardown()
+
+	data := make([]byte, uploadChunkSize+10)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	contents, err := driver.encodeContents("File1", bytes.NewReader(data), false)
+	require.NoError(t, err)
+	require.True(t, contents.needsSession())
+
+	_, err = driver.uploadResumable("File1", http.MethodPost, "files", &drive.File{Name: "File1"}, contents)
+	require.NoError(t, err)
+	require.EqualValues(t, data, stub.received)
+	require.Equal(t, strconv.Itoa(len(data)), stub.uploadLength)
+	require.Equal(t, []string{strconv.Itoa(len(data)), strconv.Itoa(len(data))}, stub.totals)
+}
+
+func TestUploadKnownSmallSize(t *testing.T) {
+	driver, stub, teardown := newUploadSessionStub(t)
+	defer teardown()
+
+	contents, err := driver.encodeContents("File1", bytes.NewBufferString("Hello World"), false)
+	require.NoError(t, err)
+	require.True(t, driver.uploadInSession(contents))
+
+	_, err = driver.uploadResumable("File1", http.MethodPost, "files", &drive.File{Name: "File1"}, contents)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(stub.received))
+	require.Equal(t, "11", stub.uploadLength)
+}
+
+func TestReaderSize(t *testing.T) {
+	require.EqualValues(t, 11, readerSize(bytes.NewBufferString("Hello World")))
+	require.EqualValues(t, 11, readerSize(strings.NewReader("Hello World")))
+	require.EqualValues(t, 5, readerSize(&sizedReader{Reader: strings.NewReader("Hello World"), size: 5}))
+	require.EqualValues(t, -1, readerSize(io.MultiReader(strings.NewReader("Hello World"))))
+
+	r := bytes.NewReader([]byte("Hello World"))
+	_, err := r.Read(make([]byte, 6))
+	require.NoError(t, err)
+	require.EqualValues(t, 5, readerSize(r))
+
+	f, err := ioutil.TempFile("", "gdriver")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	_, err = f.WriteString("Hello World")
+	require.NoError(t, err)
+	_, err = f.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, readerSize(f))
+}
+
+func TestResumeUpload(t *testing.T) {
+	data := make([]byte, uploadChunkSize+10)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	t.Run("interrupted", func(t *testing.T) {
+		driver, stub, teardown := newUploadSessionStub(t)
+		defer teardown()
+
+		// the first bytes have been uploaded before the interruption
+		stub.received = append([]byte(nil), data[:1000]...)
+
+		fi, err := driver.ResumeUpload(stub.sessionURI, bytes.NewReader(data), int64(len(data)), 0)
+		require.NoError(t, err)
+		require.Equal(t, "File1", fi.Path())
+		require.EqualValues(t, len(data), fi.Size())
+		require.EqualValues(t, data, stub.received)
+	})
+
+	t.Run("already complete", func(t *testing.T) {
+		driver, stub, teardown := newUploadSessionStub(t)
+		defer teardown()
+
+		stub.received = append([]byte(nil), data...)
+
+		fi, err := driver.ResumeUpload(stub.sessionURI, bytes.NewReader(data), int64(len(data)), 0)
+		require.NoError(t, err)
+		require.EqualValues(t, len(data), fi.Size())
+	})
+
+	t.Run("status not available", func(t *testing.T) {
+		driver, stub, teardown := newUploadSessionStub(t)
+		defer teardown()
+
+		stub.received = append([]byte(nil), data[:1000]...)
+		stub.failStatus = http.StatusServiceUnavailable
+
+		fi, err := driver.ResumeUpload(stub.sessionURI, bytes.NewReader(data), int64(len(data)), 1000)
+		require.NoError(t, err)
+		require.EqualValues(t, len(data), fi.Size())
+		require.EqualValues(t, data, stub.received)
+	})
+
+	t.Run("invalid offset hint", func(t *testing.T) {
+		driver, stub, teardown := newUploadSessionStub(t)
+		defer teardown()
+
+		_, err := driver.ResumeUpload(stub.sessionURI, bytes.NewReader(data), int64(len(data)), int64(len(data)+1))
+		require.EqualError(t, err, fmt.Sprintf("offset hint %d is out of range", len(data)+1))
+	})
+}
+
+func TestParseUploadRange(t *testing.T) {
+	offset, err := parseUploadRange("")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, offset)
+
+	offset, err = parseUploadRange("bytes=0-524287")
+	require.NoError(t, err)
+	require.EqualValues(t, 524288, offset)
+
+	_, err = parseUploadRange("0-524287")
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package gdriver
+
+import (
+	"errors"
+	"net/http"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+type revertOptions struct {
+	revision     string
+	keepPrevious bool
+	revisionTime bool
+}
+
+// RevertOption can be used to pass optional options to RevertToRevision
+type RevertOption func(options *revertOptions)
+
+// RevertIfRevision only reverts the file if its head revision is rev (see IfRevision),
+// otherwise an ErrRevisionConflict will be returned
+func RevertIfRevision(rev string) RevertOption {
+	return func(options *revertOptions) {
+		options.revision = rev
+	}
+}
+
+// RevertKeepPrevious marks the current revision of the file as keepForever before RevertToRevision replaces it,
+// so drive does not delete it and the revert can be reverted. Drive limits the amount of revisions that can be kept forever.
+func RevertKeepPrevious() RevertOption {
+	return func(options *revertOptions) {
+		options.keepPrevious = true
+	}
+}
+
+// RevertRevisionTime sets the modified time of the file to the modified time of the revision RevertToRevision restores,
+// without this option the modified time is set to now
+func RevertRevisionTime() RevertOption {
+	return func(options *revertOptions) {
+		options.revisionTime = true
+	}
+}
+
+// RevertToRevision replaces the contents of the file path with the contents of the revision revisionID (see GetFileHistory),
+// the contents are streamed from drive without buffering them (see WithUploadSpool for retries).
+// Encrypted or compressed revisions are decoded with the settings of the current file and encoded again.
+// An ErrRevisionNotFound will be returned if the revision does not exist, google native files cannot be reverted.
+//
+// Examples:
+//     RevertToRevision("Folder1/File1", entry.RevisionID, RevertIfRevision(fi.HeadRevisionID()), RevertKeepPrevious())
+func (d *GDriver) RevertToRevision(path string, revisionID string, opts ...RevertOption) (_ *FileInfo, err error) {
+	defer d.audit("RevertToRevision", path, "")(&err)
+	var options revertOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	file, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, FileIsDirectoryError{Path: path}
+	}
+	if file.IsGoogleNative() {
+		return nil, errors.New("google native files cannot be reverted")
+	}
+	if options.revision != "" && options.revision != file.item.HeadRevisionId {
+		return nil, ErrRevisionConflict{Path: path, Expected: options.revision, Actual: file.item.HeadRevisionId}
+	}
+
+	revision, err := d.srv.Revisions.Get(file.item.Id, revisionID).Fields("id", "modifiedTime").Do()
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil, ErrRevisionNotFound{RevisionID: revisionID}
+		}
+		return nil, err
+	}
+
+	if options.keepPrevious && file.item.HeadRevisionId != "" {
+		if _, err = d.srv.Revisions.Update(file.item.Id, file.item.HeadRevisionId, &drive.Revision{KeepForever: true}).Fields("id").Do(); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := d.srv.Revisions.Get(file.item.Id, revisionID).Download()
+	if err != nil {
+		return nil, err
+	}
+	body, err := d.decodeContents(file, response.Body)
+	if err != nil {
+		response.Body.Close() // nolint: errcheck
+		return nil, err
+	}
+	defer body.Close() // nolint: errcheck
+
+	// the revision is checked again right before the upload
+	if err = d.updateFileContents(file, body, options.revision); err != nil {
+		return nil, err
+	}
+
+	var updated *drive.File
+	if options.revisionTime {
+		updated, err = d.srv.Files.Update(file.item.Id, &drive.File{ModifiedTime: revision.ModifiedTime}).Fields(fileInfoFields...).Do()
+	} else {
+		updated, err = d.srv.Files.Get(file.item.Id).Fields(fileInfoFields...).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		item:         updated,
+		parentPath:   file.parentPath,
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
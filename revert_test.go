@@ -0,0 +1,106 @@
+package gdriver
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertToRevision(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	readFile := func(path string) string {
+		_, r, err := driver.GetFile(path)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		return string(data)
+	}
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	time.Sleep(10 * time.Millisecond)
+	newFile(t, driver, "Folder1/File1", "Hello Universe")
+	history, err := driver.GetFileHistory("Folder1/File1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	first, second := history[0], history[1]
+
+	t.Run("revert", func(t *testing.T) {
+		before := time.Now().Add(-time.Second)
+		fi, err := driver.RevertToRevision("Folder1/File1", first.RevisionID)
+		require.NoError(t, err)
+		require.Equal(t, "Folder1/File1", fi.Path())
+		require.NotEqual(t, second.RevisionID, fi.HeadRevisionID())
+		require.True(t, fi.ModifiedTime().After(before))
+		require.Equal(t, "Hello World", readFile("Folder1/File1"))
+
+		history, err := driver.GetFileHistory("Folder1/File1")
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+	})
+
+	t.Run("keep previous and revision time", func(t *testing.T) {
+		head, err := driver.Stat("Folder1/File1")
+		require.NoError(t, err)
+		fi, err := driver.RevertToRevision("Folder1/File1", second.RevisionID, RevertKeepPrevious(), RevertRevisionTime())
+		require.NoError(t, err)
+		require.Equal(t, "Hello Universe", readFile("Folder1/File1"))
+		require.True(t, second.ModifiedTime.Equal(fi.ModifiedTime()))
+
+		previous, err := driver.srv.Revisions.Get(head.item.Id, head.HeadRevisionID()).Fields("keepForever").Do()
+		require.NoError(t, err)
+		require.True(t, previous.KeepForever)
+	})
+
+	t.Run("if revision", func(t *testing.T) {
+		_, err := driver.RevertToRevision("Folder1/File1", first.RevisionID, RevertIfRevision(second.RevisionID))
+		require.IsType(t, ErrRevisionConflict{}, err)
+		require.Equal(t, "Hello Universe", readFile("Folder1/File1"))
+
+		head, err := driver.Stat("Folder1/File1")
+		require.NoError(t, err)
+		_, err = driver.RevertToRevision("Folder1/File1", first.RevisionID, RevertIfRevision(head.HeadRevisionID()))
+		require.NoError(t, err)
+		require.Equal(t, "Hello World", readFile("Folder1/File1"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := driver.RevertToRevision("Folder1/File1", "unknown")
+		require.Equal(t, ErrRevisionNotFound{RevisionID: "unknown"}, err)
+		_, err = driver.RevertToRevision("Folder1", first.RevisionID)
+		require.Equal(t, FileIsDirectoryError{Path: "Folder1"}, err)
+		_, err = driver.RevertToRevision("Folder1/File2", first.RevisionID)
+		require.True(t, IsNotExist(err))
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		require.NoError(t, err)
+		encrypted := *driver
+		require.NoError(t, WithEncryption(key)(&encrypted))
+
+		_, err = encrypted.PutFile("Folder1/File3", strings.NewReader("Hello World"))
+		require.NoError(t, err)
+		_, err = encrypted.PutFile("Folder1/File3", strings.NewReader("Hello Universe"))
+		require.NoError(t, err)
+		history, err := encrypted.GetFileHistory("Folder1/File3")
+		require.NoError(t, err)
+
+		fi, err := encrypted.RevertToRevision("Folder1/File3", history[0].RevisionID)
+		require.NoError(t, err)
+		require.True(t, fi.IsEncrypted())
+		_, r, err := encrypted.GetFile("Folder1/File3")
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "Hello World", string(data))
+	})
+}
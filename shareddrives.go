@@ -0,0 +1,109 @@
+package gdriver
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// CreateSharedDrive creates a shared drive with the name and returns its id.
+// Failed requests are retried, a retry will not create the shared drive twice.
+// The shared drive is created with a temporary name (".gdriver-tmp-" and the request id) and renamed to name afterwards.
+func (d *GDriver) CreateSharedDrive(name string) (id string, err error) {
+	defer d.audit("CreateSharedDrive", name, "")(&err)
+	if name == "" {
+		return "", errors.New("name cannot be empty")
+	}
+	requestID, err := newRequestID()
+	if err != nil {
+		return "", err
+	}
+	// the shared drive is created with a name that contains the request id,
+	// so it can be found if an earlier attempt created it, and renamed afterwards
+	createName := tempFilePrefix + requestID
+
+	var backoff batchBackoff
+	var attempts int
+	err = retry(&backoff, func() error {
+		attempts++
+		if id == "" {
+			sharedDrive, err := d.srv.Teamdrives.Create(requestID, &drive.TeamDrive{Name: createName}).Fields("id").Do()
+			if attempts > 1 && isConflictError(err) {
+				// an earlier attempt already created the shared drive
+				sharedDrive, err = d.findSharedDrive(createName)
+			}
+			if err != nil {
+				return err
+			}
+			id = sharedDrive.Id
+		}
+		_, err := d.srv.Teamdrives.Update(id, &drive.TeamDrive{Name: name}).Fields("id").Do()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// findSharedDrive returns the shared drive that was created with the name createName (see CreateSharedDrive)
+func (d *GDriver) findSharedDrive(createName string) (*drive.TeamDrive, error) {
+	list, err := d.srv.Teamdrives.List().Q(fmt.Sprintf("name='%s'", escapeQueryValue(createName))).Fields("teamDrives(id)").Do()
+	if err != nil {
+		return nil, err
+	}
+	switch len(list.TeamDrives) {
+	case 0:
+		return nil, fmt.Errorf("shared drive `%s' was not created", createName)
+	case 1:
+		return list.TeamDrives[0], nil
+	default:
+		return nil, fmt.Errorf("multiple shared drives found for `%s'", createName)
+	}
+}
+
+// RenameSharedDrive renames the shared drive with the id
+func (d *GDriver) RenameSharedDrive(id, newName string) (err error) {
+	defer d.audit("RenameSharedDrive", id, newName)(&err)
+	if newName == "" {
+		return errors.New("new name cannot be empty")
+	}
+	_, err = d.srv.Teamdrives.Update(id, &drive.TeamDrive{Name: newName}).Fields("id").Do()
+	return err
+}
+
+// DeleteSharedDrive deletes the shared drive with the id,
+// a SharedDriveNotEmptyError will be returned if the shared drive still contains files
+func (d *GDriver) DeleteSharedDrive(id string) (err error) {
+	defer d.audit("DeleteSharedDrive", id, "")(&err)
+	err = d.srv.Teamdrives.Delete(id).Do()
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		for _, item := range apiErr.Errors {
+			if item.Reason == "cannotDeleteNonemptyTeamDrive" || item.Reason == "cannotDeleteNonemptyDrive" {
+				return SharedDriveNotEmptyError{ID: id}
+			}
+		}
+	}
+	return err
+}
+
+// isConflictError returns true if the error is a 409 conflict
+func isConflictError(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	return ok && apiErr.Code == http.StatusConflict
+}
+
+// newRequestID returns a random (version 4) uuid
+func newRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
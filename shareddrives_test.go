@@ -0,0 +1,85 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestSharedDrives(t *testing.T) {
+	var requestIDs []string
+	var createdNames []string
+	var createResponses []int
+	var renamed string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/drive/v3/teamdrives":
+			requestIDs = append(requestIDs, r.URL.Query().Get("requestId"))
+			var teamDrive drive.TeamDrive
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&teamDrive))
+			createdNames = append(createdNames, teamDrive.Name)
+			code := createResponses[0]
+			createResponses = createResponses[1:]
+			if code != http.StatusOK {
+				w.WriteHeader(code)
+				fmt.Fprintf(w, `{"error":{"code":%d,"message":"error"}}`, code)
+				return
+			}
+			fmt.Fprint(w, `{"id":"drive1"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/drive/v3/teamdrives":
+			// only the drive of this request is found, not other drives named Project
+			require.Equal(t, fmt.Sprintf("name='%s'", createdNames[0]), r.URL.Query().Get("q"))
+			fmt.Fprint(w, `{"teamDrives":[{"id":"drive1"}]}`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/drive/v3/teamdrives/drive1":
+			var teamDrive drive.TeamDrive
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&teamDrive))
+			renamed = teamDrive.Name
+			fmt.Fprint(w, `{"id":"drive1"}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/drive/v3/teamdrives/drive1":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":{"code":403,"message":"not empty","errors":[{"reason":"cannotDeleteNonemptyTeamDrive"}]}}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/drive/v3/teamdrives/drive2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{srv: srv}
+
+	createResponses = []int{http.StatusOK}
+	id, err := driver.CreateSharedDrive("Project")
+	require.NoError(t, err)
+	require.Equal(t, "drive1", id)
+	require.Len(t, requestIDs, 1)
+	require.Len(t, requestIDs[0], 36)
+	require.Equal(t, []string{".gdriver-tmp-" + requestIDs[0]}, createdNames)
+	require.Equal(t, "Project", renamed)
+
+	// the first attempt created the drive but failed, the retry conflicts
+	requestIDs, createdNames, renamed = nil, nil, ""
+	createResponses = []int{http.StatusInternalServerError, http.StatusConflict}
+	id, err = driver.CreateSharedDrive("Project")
+	require.NoError(t, err)
+	require.Equal(t, "drive1", id)
+	require.Len(t, requestIDs, 2)
+	require.Equal(t, requestIDs[0], requestIDs[1])
+	require.Equal(t, createdNames[0], createdNames[1])
+	require.Equal(t, "Project", renamed)
+
+	require.NoError(t, driver.RenameSharedDrive("drive1", "Project 2"))
+	require.Equal(t, "Project 2", renamed)
+
+	require.Equal(t, SharedDriveNotEmptyError{ID: "drive1"}, driver.DeleteSharedDrive("drive1"))
+	require.NoError(t, driver.DeleteSharedDrive("drive2"))
+}
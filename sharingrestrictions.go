@@ -0,0 +1,108 @@
+package gdriver
+
+import (
+	"net/http"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// SharingRestrictions controls who can share a file and whether readers can download it
+type SharingRestrictions struct {
+	// WritersCanShare allows writers to change the permissions of a file or directory, if false only the owner can.
+	// Files in shared drives have no owner, their sharing is controlled by the shared drive and this setting is not changed.
+	WritersCanShare bool
+	// CopyRequiresWriterPermission disables downloading, printing and copying a file for readers and commenters.
+	// It only applies to files, drive does not allow it to be set on directories.
+	CopyRequiresWriterPermission bool
+}
+
+// sharingRestrictionsFields requests the fields that are returned by SharingRestrictions
+const sharingRestrictionsFields = "writersCanShare,copyRequiresWriterPermission"
+
+// SharingRestrictions returns the sharing restrictions of this file or directory,
+// all restrictions are false if they were not requested (see StatSharingRestrictions, ListSharingRestrictions and WalkSharingRestrictions)
+func (i *FileInfo) SharingRestrictions() SharingRestrictions {
+	return SharingRestrictions{
+		WritersCanShare:              i.item.WritersCanShare,
+		CopyRequiresWriterPermission: i.item.CopyRequiresWriterPermission,
+	}
+}
+
+// StatSharingRestrictions requests the sharing restrictions of the file, see FileInfo.SharingRestrictions
+func StatSharingRestrictions() StatOption {
+	return func(options *statOptions) {
+		options.sharingRestrictions = true
+	}
+}
+
+// ListSharingRestrictions requests the sharing restrictions of the files, see FileInfo.SharingRestrictions
+func ListSharingRestrictions() ListOption {
+	return func(options *listOptions) {
+		options.sharingRestrictions = true
+	}
+}
+
+// WalkSharingRestrictions requests the sharing restrictions of the files, see FileInfo.SharingRestrictions
+func WalkSharingRestrictions() WalkOption {
+	return func(options *walkOptions) {
+		options.sharingRestrictions = true
+	}
+}
+
+// SetSharingRestrictions sets the sharing restrictions of a file or directory.
+// A SharingRestrictionsError will be returned if the current user is not allowed to change the restrictions
+// or if CopyRequiresWriterPermission is set for a directory.
+//
+// Examples:
+//     SetSharingRestrictions("Contracts/Contract.pdf", SharingRestrictions{WritersCanShare: false, CopyRequiresWriterPermission: true})
+func (d *GDriver) SetSharingRestrictions(path string, r SharingRestrictions) (err error) {
+	defer d.audit("SetSharingRestrictions", path, "")(&err)
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType,teamDriveId,capabilities(canShare,canChangeCopyRequiresWriterPermission))")
+	if err != nil {
+		return err
+	}
+	if file == d.rootNode {
+		return SharingRestrictionsError{Path: path, Reason: "root cannot be restricted"}
+	}
+	if file.IsDir() && r.CopyRequiresWriterPermission {
+		return SharingRestrictionsError{Path: path, Reason: "copyRequiresWriterPermission cannot be set on directories"}
+	}
+	capabilities := file.item.Capabilities
+	if capabilities == nil || !capabilities.CanShare {
+		return SharingRestrictionsError{Path: path, Reason: "insufficient permissions to change the sharing settings"}
+	}
+	if !file.IsDir() && !capabilities.CanChangeCopyRequiresWriterPermission {
+		return SharingRestrictionsError{Path: path, Reason: "insufficient permissions to change copyRequiresWriterPermission"}
+	}
+
+	update := &drive.File{
+		CopyRequiresWriterPermission: r.CopyRequiresWriterPermission,
+		// false values are only sent if they are forced
+		ForceSendFields: []string{"CopyRequiresWriterPermission"},
+	}
+	if file.item.TeamDriveId == "" {
+		update.WritersCanShare = r.WritersCanShare
+		update.ForceSendFields = append(update.ForceSendFields, "WritersCanShare")
+	}
+	_, err = d.srv.Files.Update(file.item.Id, update).SupportsTeamDrives(true).Fields("id").Do()
+	if reason, ok := sharingRestrictionsErrorReason(err); ok {
+		return SharingRestrictionsError{Path: path, Reason: reason}
+	}
+	return err
+}
+
+// sharingRestrictionsErrorReason returns the message of err if drive rejected a change of the sharing restrictions
+func sharingRestrictionsErrorReason(err error) (string, bool) {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok || apiErr.Code != http.StatusForbidden {
+		return "", false
+	}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "insufficientFilePermissions", "fieldNotWritable", "cannotModifyViewersCanCopyContent":
+			return item.Message, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,77 @@
+package gdriver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+func TestSetSharingRestrictions(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+
+	restrictions := func(path string) SharingRestrictions {
+		var result *SharingRestrictions
+		require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+			if f.Path() == path {
+				r := f.SharingRestrictions()
+				result = &r
+			}
+			return nil
+		}, WalkSharingRestrictions()))
+		require.NotNil(t, result)
+		return *result
+	}
+
+	require.Equal(t, SharingRestrictions{WritersCanShare: true}, restrictions("Folder1/File1"))
+
+	require.NoError(t, driver.SetSharingRestrictions("Folder1/File1", SharingRestrictions{CopyRequiresWriterPermission: true}))
+	require.Equal(t, SharingRestrictions{CopyRequiresWriterPermission: true}, restrictions("Folder1/File1"))
+
+	require.NoError(t, driver.SetSharingRestrictions("Folder1/File1", SharingRestrictions{WritersCanShare: true}))
+	require.Equal(t, SharingRestrictions{WritersCanShare: true}, restrictions("Folder1/File1"))
+
+	// directories only support WritersCanShare
+	require.NoError(t, driver.SetSharingRestrictions("Folder1", SharingRestrictions{}))
+	require.Equal(t, SharingRestrictions{}, restrictions("Folder1"))
+
+	// Stat and ListDirectory request the restrictions with an option as well
+	fi, err := driver.Stat("Folder1/File1", StatSharingRestrictions())
+	require.NoError(t, err)
+	require.Equal(t, SharingRestrictions{WritersCanShare: true}, fi.SharingRestrictions())
+	var listed []SharingRestrictions
+	require.NoError(t, driver.ListDirectory("Folder1", func(f *FileInfo) error {
+		listed = append(listed, f.SharingRestrictions())
+		return nil
+	}, ListSharingRestrictions()))
+	require.Equal(t, []SharingRestrictions{{WritersCanShare: true}}, listed)
+
+	err = driver.SetSharingRestrictions("Folder1", SharingRestrictions{CopyRequiresWriterPermission: true})
+	require.IsType(t, SharingRestrictionsError{}, err)
+
+	err = driver.SetSharingRestrictions("", SharingRestrictions{})
+	require.IsType(t, SharingRestrictionsError{}, err)
+	err = driver.SetSharingRestrictions("Folder1/File2", SharingRestrictions{})
+	require.True(t, IsNotExist(err))
+}
+
+func TestSharingRestrictionsErrorReason(t *testing.T) {
+	reason, ok := sharingRestrictionsErrorReason(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "insufficientFilePermissions", Message: "not permitted"}},
+	})
+	require.True(t, ok)
+	require.Equal(t, "not permitted", reason)
+
+	_, ok = sharingRestrictionsErrorReason(&googleapi.Error{
+		Code:   http.StatusForbidden,
+		Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}},
+	})
+	require.False(t, ok)
+	_, ok = sharingRestrictionsErrorReason(nil)
+	require.False(t, ok)
+}
@@ -0,0 +1,53 @@
+package gdriver
+
+import (
+	"errors"
+
+	"google.golang.org/api/googleapi"
+)
+
+// WithSimpleUploadThreshold uploads files with a known size of at most n bytes in one request without a resumable session,
+// even if WithUploadSessionCallback is used (the callback is not called for those files).
+// The size is known if the reader passed to PutFile provides it (see SizedReader) or PutContentLength was used,
+// contents that are encrypted or compressed have no known size.
+// Without this option all contents with a known size and all contents with WithUploadSessionCallback are uploaded in a session.
+func WithSimpleUploadThreshold(n int64) Option {
+	return func(driver *GDriver) error {
+		if n < 0 {
+			return errors.New("simple upload threshold cannot be negative")
+		}
+		driver.simpleUploadThreshold = n
+		return nil
+	}
+}
+
+// PutContentLength announces the amount of bytes the reader passed to PutFile will provide,
+// use it for readers that do not implement SizedReader. n must be exact.
+func PutContentLength(n int64) PutOption {
+	return func(options *putOptions) {
+		options.contentLength = n
+	}
+}
+
+// isSimpleUpload returns true if the contents should be uploaded in one request (see WithSimpleUploadThreshold)
+func (d *GDriver) isSimpleUpload(c *encodedContents) bool {
+	return d.simpleUploadThreshold > 0 && c.size >= 0 && c.size <= d.simpleUploadThreshold
+}
+
+// uploadInSession returns true if the contents should be uploaded with a resumable session
+func (d *GDriver) uploadInSession(c *encodedContents) bool {
+	if d.isSimpleUpload(c) {
+		return false
+	}
+	return d.uploadSessionFunc != nil || c.needsSession()
+}
+
+// mediaOptions returns the media options for uploads without a resumable session
+func (d *GDriver) mediaOptions(c *encodedContents) []googleapi.MediaOption {
+	options := c.mediaOptions()
+	if d.isSimpleUpload(c) {
+		// the library would buffer and upload contents that are larger than a chunk in a session
+		options = append(options, googleapi.ChunkSize(0))
+	}
+	return options
+}
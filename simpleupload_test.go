@@ -0,0 +1,81 @@
+package gdriver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleUpload(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	small := []byte("Hello World")
+	large := make([]byte, uploadChunkSize+10)
+	_, err := rand.Read(large)
+	require.NoError(t, err)
+
+	var sessions []string
+	require.NoError(t, WithUploadSessionCallback(func(path, sessionURI string) {
+		sessions = append(sessions, path)
+	})(driver))
+
+	// upload uploads data to path and returns the amount of requests the upload needed
+	upload := func(path string, data []byte, opts ...PutOption) (*FileInfo, int64) {
+		before, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		// io.MultiReader hides the size of the contents
+		fi, err := driver.PutFile(path, io.MultiReader(bytes.NewReader(data)), opts...)
+		require.NoError(t, err)
+		after, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		return fi, after.APICalls - before.APICalls
+	}
+	// compare checks that both files have the same contents and metadata
+	compare := func(resumable, simple *FileInfo, data []byte) {
+		require.Equal(t, resumable.Size(), simple.Size())
+		require.Equal(t, resumable.item.MimeType, simple.item.MimeType)
+		require.Equal(t, resumable.item.AppProperties, simple.item.AppProperties)
+		for _, fi := range []*FileInfo{resumable, simple} {
+			_, r, err := driver.GetFile(fi.Path())
+			require.NoError(t, err)
+			contents, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, data, contents)
+		}
+	}
+
+	// the session callback forces resumable uploads
+	resumableSmall, resumableSmallCalls := upload("Resumable/File1.txt", small, PutContentLength(int64(len(small))))
+	resumableLarge, resumableLargeCalls := upload("Resumable/File2.bin", large, PutContentLength(int64(len(large))))
+	require.Equal(t, []string{"Resumable/File1.txt", "Resumable/File2.bin"}, sessions)
+
+	require.NoError(t, WithSimpleUploadThreshold(int64(len(large)))(driver))
+	sessions = nil
+	simpleSmall, simpleSmallCalls := upload("Simple/File1.txt", small, PutContentLength(int64(len(small))))
+	simpleLarge, simpleLargeCalls := upload("Simple/File2.bin", large, PutContentLength(int64(len(large))))
+	require.Empty(t, sessions)
+	require.True(t, simpleSmallCalls < resumableSmallCalls)
+	require.True(t, simpleLargeCalls < resumableLargeCalls)
+	compare(resumableSmall, simpleSmall, small)
+	compare(resumableLarge, simpleLarge, large)
+
+	// the size is unknown without PutContentLength
+	_, _ = upload("Unknown/File1.txt", small)
+	require.Equal(t, []string{"Unknown/File1.txt"}, sessions)
+
+	// existing files are updated without a session as well
+	sessions = nil
+	_, _ = upload("Simple/File1.txt", large, PutContentLength(int64(len(large))))
+	require.Empty(t, sessions)
+	updated, err := driver.Stat("Simple/File1.txt")
+	require.NoError(t, err)
+	compare(simpleLarge, updated, large)
+
+	require.Error(t, WithSimpleUploadThreshold(-1)(driver))
+}
@@ -0,0 +1,70 @@
+package gdriver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithEnforceSingleParent controls whether enforceSingleParent=true is sent when files are created or updated,
+// drive will then refuse to give a file multiple parents. It is enabled by default, disable it if you rely on multiple parents.
+func WithEnforceSingleParent(enabled bool) Option {
+	return func(driver *GDriver) error {
+		driver.enforceSingleParent = enabled
+		return nil
+	}
+}
+
+// singleParentTransport adds the enforceSingleParent query parameter to the create and update requests of files
+type singleParentTransport struct {
+	base   http.RoundTripper
+	driver *GDriver
+}
+
+func (t *singleParentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.driver.enforceSingleParent && isFileCreateOrUpdate(req) {
+		// a RoundTripper must not modify the request
+		r := new(http.Request)
+		*r = *req
+		u := *req.URL
+		query := u.Query()
+		query.Set("enforceSingleParent", "true")
+		u.RawQuery = query.Encode()
+		r.URL = &u
+		req = r
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// isFileCreateOrUpdate returns true for the (upload) requests of Files.Create and Files.Update,
+// requests to sub resources (e.g. permissions or copy) are ignored
+func isFileCreateOrUpdate(req *http.Request) bool {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	idx := strings.LastIndex(path, "/files")
+	if idx < 0 {
+		return false
+	}
+	rest := path[idx+len("/files"):]
+	switch req.Method {
+	case http.MethodPost:
+		return rest == ""
+	case http.MethodPatch:
+		return strings.Count(rest, "/") == 1
+	default:
+		return false
+	}
+}
+
+// withSingleParentTransport returns a copy of client that adds the enforceSingleParent query parameter if it is enabled in driver
+func withSingleParentTransport(client *http.Client, driver *GDriver) *http.Client {
+	c := *client
+	c.Transport = &singleParentTransport{
+		base:   client.Transport,
+		driver: driver,
+	}
+	return &c
+}
@@ -0,0 +1,75 @@
+package gdriver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// queryRecordingTransport records the method, path and enforceSingleParent parameter of every request and answers with a file
+type queryRecordingTransport struct {
+	requests []string
+}
+
+func (t *queryRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.Method+" "+req.URL.Path+" "+req.URL.Query().Get("enforceSingleParent"))
+	body := `{"id":"root","mimeType":"` + mimeTypeFolder + `"}`
+	if req.Method != http.MethodGet {
+		body = `{"id":"file1","name":"File1","mimeType":"` + mimeTypeFile + `","parents":["root"]}`
+	}
+	if req.URL.Query().Get("q") != "" {
+		body = `{"files":[]}`
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if req.URL.Query().Get("uploadType") == "resumable" {
+		header.Set("Location", "https://www.googleapis.com/upload/drive/v3/files?upload_id=1")
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestEnforceSingleParent(t *testing.T) {
+	transport := &queryRecordingTransport{}
+	driver, err := New(&http.Client{Transport: transport})
+	require.NoError(t, err)
+	_, err = driver.PutFile("File1", bytes.NewBufferString("Hello World"))
+	require.NoError(t, err)
+	require.Contains(t, transport.requests, "POST /upload/drive/v3/files true")
+
+	transport = &queryRecordingTransport{}
+	driver, err = New(&http.Client{Transport: transport}, WithEnforceSingleParent(false))
+	require.NoError(t, err)
+	_, err = driver.PutFile("File1", bytes.NewBufferString("Hello World"))
+	require.NoError(t, err)
+	require.Contains(t, transport.requests, "POST /upload/drive/v3/files ")
+}
+
+func TestIsFileCreateOrUpdate(t *testing.T) {
+	for _, test := range []struct {
+		method   string
+		url      string
+		expected bool
+	}{
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files", true},
+		{http.MethodPost, "https://www.googleapis.com/upload/drive/v3/files?uploadType=multipart", true},
+		{http.MethodPatch, "https://www.googleapis.com/drive/v3/files/abc", true},
+		{http.MethodPatch, "https://www.googleapis.com/upload/drive/v3/files/abc?uploadType=resumable", true},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files/abc/copy", false},
+		{http.MethodPost, "https://www.googleapis.com/drive/v3/files/abc/permissions", false},
+		{http.MethodPatch, "https://www.googleapis.com/drive/v3/files/abc/permissions/def", false},
+		{http.MethodGet, "https://www.googleapis.com/drive/v3/files/abc", false},
+		{http.MethodPut, "https://www.googleapis.com/upload/drive/v3/files?upload_id=abc", false},
+	} {
+		req, err := http.NewRequest(test.method, test.url, nil)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, isFileCreateOrUpdate(req), "%s %s", test.method, test.url)
+	}
+}
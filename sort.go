@@ -0,0 +1,104 @@
+package gdriver
+
+import (
+	"sort"
+	"strings"
+)
+
+// ListDirectorySorted returns the contents of the directory path sorted by less (see ByName, ByModifiedDesc, BySizeDesc and DirsFirst).
+// The whole listing is buffered before it is sorted, for large directories prefer ListDirectory
+// if the order does not matter or can be applied by drive (orderBy of files.list).
+//
+// Examples:
+//     ListDirectorySorted("Pictures", DirsFirst(ByName))
+func (d *GDriver) ListDirectorySorted(path string, less func(a, b *FileInfo) bool) ([]*FileInfo, error) {
+	var files []*FileInfo
+	// the comparators need all fields, even with WithMinimalFields
+	err := d.listDirectory(path, listFields[0], listFilter{}, func(f *FileInfo) error {
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return less(files[i], files[j])
+	})
+	return files, nil
+}
+
+// ByName orders files by their name
+func ByName(a, b *FileInfo) bool {
+	return a.Name() < b.Name()
+}
+
+// ByModifiedDesc orders files by their modified time, the most recently modified file first
+func ByModifiedDesc(a, b *FileInfo) bool {
+	return a.ModifiedTime().After(b.ModifiedTime())
+}
+
+// BySizeDesc orders files by their size, the largest file first
+func BySizeDesc(a, b *FileInfo) bool {
+	return a.Size() > b.Size()
+}
+
+// DirsFirst orders directories before files, directories and files are ordered by less
+func DirsFirst(less func(a, b *FileInfo) bool) func(a, b *FileInfo) bool {
+	return func(a, b *FileInfo) bool {
+		if a.IsDir() != b.IsDir() {
+			return a.IsDir()
+		}
+		return less(a, b)
+	}
+}
+
+// Compare orders the files by their path, it returns -1 if i comes before other, 1 if it comes after other and 0 if both have the same path
+func (i *FileInfo) Compare(other *FileInfo) int {
+	return strings.Compare(i.Path(), other.Path())
+}
+
+// CompareBySize orders the files by their size, it returns -1 if i is smaller than other, 1 if it is larger and 0 if both have the same size
+func (i *FileInfo) CompareBySize(other *FileInfo) int {
+	switch {
+	case i.Size() < other.Size():
+		return -1
+	case i.Size() > other.Size():
+		return 1
+	}
+	return 0
+}
+
+// CompareByModifiedTime orders the files by their modified time, it returns -1 if i was modified before other,
+// 1 if it was modified after other and 0 if both were modified at the same time
+func (i *FileInfo) CompareByModifiedTime(other *FileInfo) int {
+	a, b := i.ModifiedTime(), other.ModifiedTime()
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// SortByName sorts the files by their path (see Compare), files in the same directory are sorted by their name
+func SortByName(files []*FileInfo) {
+	sortFiles(files, (*FileInfo).Compare)
+}
+
+// SortBySize sorts the files by their size, the smallest file first, files with the same size keep their order
+func SortBySize(files []*FileInfo) {
+	sortFiles(files, (*FileInfo).CompareBySize)
+}
+
+// SortByModifiedTime sorts the files by their modified time, the least recently modified file first,
+// files with the same modified time keep their order
+func SortByModifiedTime(files []*FileInfo) {
+	sortFiles(files, (*FileInfo).CompareByModifiedTime)
+}
+
+func sortFiles(files []*FileInfo, compare func(a, b *FileInfo) int) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return compare(files[i], files[j]) < 0
+	})
+}
@@ -0,0 +1,94 @@
+package gdriver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestListDirectorySorted(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	modified := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, file := range []struct {
+		name string
+		dir  bool
+		size int64
+		age  time.Duration
+	}{
+		{"b.txt", false, 10, time.Hour},
+		{"Folder2", true, 0, 3 * time.Hour},
+		{"c.txt", false, 30, 2 * time.Hour},
+		{"Folder1", true, 0, 0},
+		{"a.txt", false, 20, 4 * time.Hour},
+	} {
+		id := string(rune('1' + i))
+		stub.add("root", id, file.name, file.dir)
+		stub.files[id].Size = file.size
+		stub.files[id].ModifiedTime = modified.Add(-file.age).Format(time.RFC3339)
+	}
+
+	names := func(less func(a, b *FileInfo) bool) []string {
+		files, err := driver.ListDirectorySorted("", less)
+		require.NoError(t, err)
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"Folder1", "Folder2", "a.txt", "b.txt", "c.txt"}, names(ByName))
+	require.Equal(t, []string{"Folder1", "b.txt", "c.txt", "Folder2", "a.txt"}, names(ByModifiedDesc))
+	require.Equal(t, []string{"c.txt", "a.txt", "b.txt"}, names(BySizeDesc)[:3])
+	// directories have no size, their listing order is kept
+	require.Equal(t, []string{"Folder2", "Folder1", "c.txt", "a.txt", "b.txt"}, names(DirsFirst(BySizeDesc)))
+	require.Equal(t, []string{"Folder1", "Folder2", "b.txt", "c.txt", "a.txt"}, names(DirsFirst(ByModifiedDesc)))
+}
+
+func TestSortFiles(t *testing.T) {
+	modified := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	newFileInfo := func(parentPath, name string, size int64, age time.Duration) *FileInfo {
+		return &FileInfo{
+			item: &drive.File{
+				Name:         name,
+				Size:         size,
+				ModifiedTime: modified.Add(-age).Format(time.RFC3339),
+			},
+			parentPath: parentPath,
+		}
+	}
+	files := []*FileInfo{
+		newFileInfo("Folder1", "b.txt", 10, time.Hour),
+		newFileInfo("", "c.txt", 30, 2*time.Hour),
+		newFileInfo("Folder1", "a.txt", 20, 4*time.Hour),
+		newFileInfo("", "Folder1", 0, 0),
+		newFileInfo("", "a.txt", 10, 3*time.Hour),
+	}
+
+	require.Equal(t, -1, files[2].Compare(files[0]))
+	require.Equal(t, 1, files[0].Compare(files[2]))
+	require.Equal(t, 0, files[0].Compare(files[0]))
+	require.Equal(t, 0, files[0].CompareBySize(files[4]))
+	require.Equal(t, 1, files[1].CompareBySize(files[0]))
+	require.Equal(t, -1, files[1].CompareByModifiedTime(files[0]))
+	require.Equal(t, 1, files[3].CompareByModifiedTime(files[0]))
+
+	paths := func(sortFn func([]*FileInfo)) []string {
+		sorted := append([]*FileInfo(nil), files...)
+		sortFn(sorted)
+		var paths []string
+		for _, f := range sorted {
+			paths = append(paths, f.Path())
+		}
+		return paths
+	}
+
+	require.Equal(t, []string{"Folder1", "Folder1/a.txt", "Folder1/b.txt", "a.txt", "c.txt"}, paths(SortByName))
+	// files with the same size keep their order
+	require.Equal(t, []string{"Folder1", "Folder1/b.txt", "a.txt", "Folder1/a.txt", "c.txt"}, paths(SortBySize))
+	require.Equal(t, []string{"Folder1/a.txt", "a.txt", "c.txt", "Folder1/b.txt", "Folder1"}, paths(SortByModifiedTime))
+}
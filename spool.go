@@ -0,0 +1,116 @@
+package gdriver
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// spoolFilePrefix is the name prefix of the local temporary files created by WithUploadSpool
+const spoolFilePrefix = "gdriver-spool-"
+
+// uploadSpool holds the options of WithUploadSpool
+type uploadSpool struct {
+	memoryLimit int64
+	tempDir     string
+}
+
+// WithUploadSpool buffers the contents of uploads so failed uploads can be retried (see PutFile).
+// Up to memoryLimit bytes are kept in memory, larger contents are written to a temporary file in tempDir
+// (if tempDir is empty the default directory for temporary files is used), the file is removed after the upload.
+// Readers that implement io.ReadSeeker (e.g. os.File) are not buffered, they will be rewound to their initial position instead.
+func WithUploadSpool(memoryLimit int64, tempDir string) Option {
+	return func(driver *GDriver) error {
+		if memoryLimit < 0 {
+			return errors.New("memory limit cannot be negative")
+		}
+		driver.uploadSpool = &uploadSpool{
+			memoryLimit: memoryLimit,
+			tempDir:     tempDir,
+		}
+		return nil
+	}
+}
+
+// spool returns a reader for the contents of r that can be rewound, cleanup must be called if the reader is not needed anymore
+func (s *uploadSpool) spool(r io.Reader) (_ io.ReadSeeker, cleanup func(), err error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		return rs, func() {}, nil
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, s.memoryLimit+1)
+	if err == io.EOF {
+		return bytes.NewReader(buf.Bytes()), func() {}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the contents do not fit into memory
+	f, err := ioutil.TempFile(s.tempDir, spoolFilePrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	removeFile := func() {
+		f.Close()           // nolint: errcheck
+		os.Remove(f.Name()) // nolint: errcheck
+	}
+	defer func() {
+		// cleanup is nil if an error was returned
+		if err != nil {
+			removeFile()
+		}
+	}()
+	if _, err = f.Write(buf.Bytes()[:n]); err != nil {
+		return nil, nil, err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		return nil, nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	return f, removeFile, nil
+}
+
+// withUploadRetry calls upload with the contents of r for the file filePath,
+// if WithUploadSpool was used the contents will be spooled and the upload will be retried if it failed with a retryable error.
+// The contents are checked against the maximum upload size (see WithMaxUploadSize).
+func (d *GDriver) withUploadRetry(filePath string, r io.Reader, upload func(r io.Reader) error) (err error) {
+	r, limiter, err := d.limitUpload(filePath, r)
+	if err != nil {
+		return err
+	}
+	if limiter != nil {
+		defer func() {
+			// the drive library wraps errors of the reader
+			if limiter.exceeded {
+				err = limiter.err()
+			}
+		}()
+	}
+
+	if d.uploadSpool == nil {
+		return upload(r)
+	}
+	rs, cleanup, err := d.uploadSpool.spool(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	var backoff batchBackoff
+	return retry(&backoff, func() error {
+		if _, err := rs.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		return upload(rs)
+	})
+}
@@ -0,0 +1,137 @@
+package gdriver
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+)
+
+func TestUploadSpool(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// oneShotReader hides the Seek method of the underlying reader
+	oneShotReader := func(s string) io.Reader {
+		return io.LimitReader(strings.NewReader(s), int64(len(s)))
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		spool := &uploadSpool{memoryLimit: 11, tempDir: tempDir}
+		rs, cleanup, err := spool.spool(oneShotReader("Hello World"))
+		require.NoError(t, err)
+		defer cleanup()
+		require.IsType(t, &bytes.Reader{}, rs)
+		requireReadSeekerContents(t, rs, "Hello World")
+	})
+
+	t.Run("temp file", func(t *testing.T) {
+		spool := &uploadSpool{memoryLimit: 4, tempDir: tempDir}
+		rs, cleanup, err := spool.spool(oneShotReader("Hello World"))
+		require.NoError(t, err)
+		require.IsType(t, &os.File{}, rs)
+		requireReadSeekerContents(t, rs, "Hello World")
+
+		entries, err := ioutil.ReadDir(tempDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.True(t, strings.HasPrefix(entries[0].Name(), spoolFilePrefix))
+
+		// the temp file should be removed
+		cleanup()
+		entries, err = ioutil.ReadDir(tempDir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("read seeker", func(t *testing.T) {
+		spool := &uploadSpool{memoryLimit: 4, tempDir: tempDir}
+		r := strings.NewReader("Hello World")
+		rs, cleanup, err := spool.spool(r)
+		require.NoError(t, err)
+		defer cleanup()
+		require.True(t, rs == io.ReadSeeker(r), "read seeker should not be spooled")
+	})
+}
+
+func TestWithUploadRetry(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// upload fails after reading the first bytes, the second attempt succeeds
+	newUpload := func(contents *[]string) func(r io.Reader) error {
+		return func(r io.Reader) error {
+			if len(*contents) == 0 {
+				buf := make([]byte, 3)
+				_, err := io.ReadFull(r, buf)
+				require.NoError(t, err)
+				*contents = append(*contents, string(buf))
+				return &googleapi.Error{Code: 503}
+			}
+			buf, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			*contents = append(*contents, string(buf))
+			return nil
+		}
+	}
+
+	t.Run("spooled", func(t *testing.T) {
+		driver := &GDriver{uploadSpool: &uploadSpool{memoryLimit: 4, tempDir: tempDir}}
+		var contents []string
+		require.NoError(t, driver.withUploadRetry("File1", io.LimitReader(strings.NewReader("Hello World"), 11), newUpload(&contents)))
+		require.Equal(t, []string{"Hel", "Hello World"}, contents)
+
+		entries, err := ioutil.ReadDir(tempDir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("read seeker", func(t *testing.T) {
+		driver := &GDriver{uploadSpool: &uploadSpool{memoryLimit: 4, tempDir: tempDir}}
+		r := strings.NewReader("Hello World")
+		_, err := r.Seek(6, io.SeekStart)
+		require.NoError(t, err)
+		var contents []string
+		require.NoError(t, driver.withUploadRetry("File1", r, newUpload(&contents)))
+		require.Equal(t, []string{"Wor", "World"}, contents)
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		driver := &GDriver{uploadSpool: &uploadSpool{memoryLimit: 4, tempDir: tempDir}}
+		attempts := 0
+		err := driver.withUploadRetry("File1", strings.NewReader("Hello World"), func(r io.Reader) error {
+			attempts++
+			return &googleapi.Error{Code: 400}
+		})
+		require.Error(t, err)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("without spool", func(t *testing.T) {
+		driver := &GDriver{}
+		attempts := 0
+		err := driver.withUploadRetry("File1", strings.NewReader("Hello World"), func(r io.Reader) error {
+			attempts++
+			return &googleapi.Error{Code: 503}
+		})
+		require.Error(t, err)
+		require.Equal(t, 1, attempts)
+	})
+}
+
+func requireReadSeekerContents(t *testing.T, rs io.ReadSeeker, expected string) {
+	for i := 0; i < 2; i++ {
+		_, err := rs.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		buf, err := ioutil.ReadAll(rs)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(buf))
+	}
+}
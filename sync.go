@@ -0,0 +1,312 @@
+package gdriver
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncState is the state of SyncDownIncremental that has to be passed to the next call,
+// it can be stored by the caller (e.g. as json). The zero value starts with a full download.
+type SyncState struct {
+	// Token is the changes token of the last sync (see GetChangesStartToken)
+	Token string `json:"token"`
+	// Files holds the synchronized files and directories by their id
+	Files map[string]SyncedFile `json:"files"`
+}
+
+// SyncedFile describes a file or directory that was synchronized by SyncDownIncremental
+type SyncedFile struct {
+	// Path is the slash separated path relative to the local directory
+	Path string `json:"path"`
+	// MD5 is the md5 checksum of the contents, it is empty for directories
+	MD5 string `json:"md5,omitempty"`
+	Dir bool   `json:"dir,omitempty"`
+}
+
+// SyncReport describes what SyncDownIncremental changed locally, all paths are slash separated paths relative to the local directory
+type SyncReport struct {
+	// Downloaded holds the files that were downloaded
+	Downloaded []string
+	// Removed holds the files and directories that were deleted
+	Removed []string
+	// Moved holds the new paths of the files and directories that were renamed or moved
+	Moved []string
+}
+
+type syncOptions struct {
+	reporter ProgressReporter
+}
+
+// SyncOption can be used to pass optional options to SyncDownIncremental
+type SyncOption func(options *syncOptions)
+
+// SyncReporter reports the progress of every file that SyncDownIncremental downloads to reporter
+func SyncReporter(reporter ProgressReporter) SyncOption {
+	return func(options *syncOptions) {
+		options.reporter = reporter
+	}
+}
+
+// SyncDownIncremental mirrors the directory remoteDir to localDir.
+// The first call (with an empty state) downloads all files, the following calls only apply the changes
+// that happened since the previous call: changed files are downloaded, renamed or moved files are moved locally,
+// deleted files (or files that were moved out of remoteDir) are removed.
+// The returned state must be passed to the next call, it is only valid for the same remoteDir and localDir.
+// Google native files (e.g. google docs) are skipped, use ExportDirectory to export them.
+// An UnsafeLocalPathError will be returned if a name (like "..") would point outside of localDir, nothing is written for it.
+func (d *GDriver) SyncDownIncremental(remoteDir, localDir string, state SyncState, opts ...SyncOption) (SyncState, *SyncReport, error) {
+	var options syncOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	s := &syncer{
+		driver:   d,
+		remote:   strings.Join(strings.FieldsFunc(remoteDir, isPathSeperator), "/"),
+		localDir: localDir,
+		files:    make(map[string]SyncedFile, len(state.Files)),
+		report:   &SyncReport{},
+	}
+	for id, file := range state.Files {
+		s.files[id] = file
+	}
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return state, nil, err
+	}
+
+	var err error
+	var token string
+	if state.Token == "" {
+		// get the token first, so changes that happen during the download will be applied in the next call
+		if token, err = d.GetChangesStartToken(); err != nil {
+			return state, nil, err
+		}
+		err = s.full(options.reporter)
+	} else {
+		token, err = s.incremental(state.Token, options.reporter)
+	}
+	if err != nil {
+		return state, s.report, err
+	}
+	return SyncState{Token: token, Files: s.files}, s.report, nil
+}
+
+type syncer struct {
+	driver   *GDriver
+	remote   string
+	localDir string
+	files    map[string]SyncedFile
+	report   *SyncReport
+}
+
+// syncChange is a file or directory that changed since the last sync
+type syncChange struct {
+	file *FileInfo
+	// path is the relative path, it is empty if the file was removed
+	path string
+}
+
+// relativePath returns the path of file relative to the remote directory, ok is false if the file is not in the remote directory
+func (s *syncer) relativePath(file *FileInfo) (string, bool) {
+	if s.remote == "" {
+		return file.Path(), true
+	}
+	if !strings.HasPrefix(file.Path(), s.remote+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(file.Path(), s.remote+"/"), true
+}
+
+// full downloads all files of the remote directory
+func (s *syncer) full(reporter ProgressReporter) error {
+	var changes []syncChange
+	err := s.driver.Walk(s.remote, func(f *FileInfo) error {
+		if relativePath, ok := s.relativePath(f); ok {
+			changes = append(changes, syncChange{file: f, path: relativePath})
+		}
+		return nil
+	}, WalkSorted(), walkFields(md5ListFields()))
+	if err != nil {
+		return err
+	}
+	return s.apply(changes, reporter)
+}
+
+// incremental applies the changes since token and returns the token for the next sync
+func (s *syncer) incremental(token string, reporter ProgressReporter) (string, error) {
+	var changes []syncChange
+	token, err := s.driver.GetChangedFilesSinceToken(token, func(f *FileInfo, removed bool) error {
+		if removed {
+			changes = append(changes, syncChange{file: f})
+			return nil
+		}
+		relativePath, ok := s.relativePath(f)
+		if !ok {
+			// the file might have been moved out of the remote directory
+			changes = append(changes, syncChange{file: f})
+			return nil
+		}
+		changes = append(changes, syncChange{file: f, path: relativePath})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, s.apply(changes, reporter)
+}
+
+// apply removes, moves and downloads the changed files,
+// removals are handled first, then directories (parents before their children) and then files
+func (s *syncer) apply(changes []syncChange, reporter ProgressReporter) error {
+	order := func(change syncChange) int {
+		switch {
+		case change.path == "":
+			return 0
+		case change.file.IsDir():
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if oi, oj := order(changes[i]), order(changes[j]); oi != oj {
+			return oi < oj
+		}
+		return strings.Count(changes[i].path, "/") < strings.Count(changes[j].path, "/")
+	})
+
+	var downloads []syncChange
+	for _, change := range changes {
+		id := change.file.item.Id
+		synced, known := s.files[id]
+		if change.path == "" {
+			if known {
+				if err := s.remove(id, synced); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if change.file.IsGoogleNative() && !change.file.IsDir() {
+			continue
+		}
+
+		if known && synced.Path != change.path {
+			if err := s.move(synced.Path, change.path); err != nil {
+				return err
+			}
+			synced = s.files[id]
+		}
+		if change.file.IsDir() {
+			localDir, err := s.local(change.path)
+			if err != nil {
+				return err
+			}
+			if err = os.MkdirAll(localDir, 0755); err != nil {
+				return err
+			}
+			s.files[id] = SyncedFile{Path: change.path, Dir: true}
+			continue
+		}
+		if known && synced.MD5 == change.file.item.Md5Checksum {
+			continue
+		}
+		downloads = append(downloads, change)
+	}
+
+	report := newBatchReport(len(downloads), nil, reporter)
+	for _, change := range downloads {
+		report.started(change.path)
+		n, err := s.download(change)
+		report.finished(change.path, n, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// local returns the local path of a relative path, an UnsafeLocalPathError will be returned if it points outside of localDir
+func (s *syncer) local(relativePath string) (string, error) {
+	return localPath(s.localDir, relativePath)
+}
+
+// remove deletes a file or directory locally and forgets it (and its descendants)
+func (s *syncer) remove(id string, synced SyncedFile) error {
+	localPath, err := s.local(synced.Path)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(localPath); err != nil {
+		return err
+	}
+	delete(s.files, id)
+	if synced.Dir {
+		for childID, child := range s.files {
+			if strings.HasPrefix(child.Path, synced.Path+"/") {
+				delete(s.files, childID)
+			}
+		}
+	}
+	s.report.Removed = append(s.report.Removed, synced.Path)
+	return nil
+}
+
+// move moves a file or directory locally and updates the paths of it (and its descendants)
+func (s *syncer) move(oldPath, newPath string) error {
+	oldLocalPath, err := s.local(oldPath)
+	if err != nil {
+		return err
+	}
+	newLocalPath, err := s.local(newPath)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(newLocalPath), 0755); err != nil {
+		return err
+	}
+	if err = os.Rename(oldLocalPath, newLocalPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for id, file := range s.files {
+		switch {
+		case file.Path == oldPath:
+			file.Path = newPath
+		case strings.HasPrefix(file.Path, oldPath+"/"):
+			file.Path = path.Join(newPath, strings.TrimPrefix(file.Path, oldPath+"/"))
+		default:
+			continue
+		}
+		s.files[id] = file
+	}
+	s.report.Moved = append(s.report.Moved, newPath)
+	return nil
+}
+
+// download writes the contents of a changed file to its local path
+func (s *syncer) download(change syncChange) (int64, error) {
+	localPath, err := s.local(change.path)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return 0, err
+	}
+	body, err := s.driver.downloadDecoded(change.file)
+	if err != nil {
+		return 0, err
+	}
+	n, readErr, writeErr := writeLocalFile(localPath, body)
+	if readErr != nil {
+		return n, readErr
+	}
+	if writeErr != nil {
+		return n, writeErr
+	}
+	s.files[change.file.item.Id] = SyncedFile{Path: change.path, MD5: change.file.item.Md5Checksum}
+	s.report.Downloaded = append(s.report.Downloaded, change.path)
+	return n, nil
+}
@@ -0,0 +1,90 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncDownIncremental(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/File2", "Hello Universe")
+	newFile(t, driver, "Folder1/Folder2/File3", "Hello Mars")
+	newFile(t, driver, "Folder1/File4", "Hello Venus")
+	newFile(t, driver, "Other/File5", "Hello Jupiter")
+
+	localDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(localDir)
+
+	readLocal := func(relativePath string) string {
+		data, err := ioutil.ReadFile(filepath.Join(localDir, filepath.FromSlash(relativePath)))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	state, report, err := driver.SyncDownIncremental("Folder1", localDir, SyncState{})
+	require.NoError(t, err)
+	require.NotEmpty(t, state.Token)
+	require.ElementsMatch(t, []string{"File1", "File2", "File4", "Folder2/File3"}, report.Downloaded)
+	require.Equal(t, "Hello Mars", readLocal("Folder2/File3"))
+
+	// the state must survive a json round trip
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	state = SyncState{}
+	require.NoError(t, json.Unmarshal(data, &state))
+
+	newFile(t, driver, "Folder1/File1", "Hello Saturn")
+	_, err = driver.Rename("Folder1/Folder2", "Folder3")
+	require.NoError(t, err)
+	_, err = driver.Move("Folder1/File2", "Folder1/Folder3/File2")
+	require.NoError(t, err)
+	require.NoError(t, driver.Delete("Folder1/File4"))
+	newFile(t, driver, "Other/File6", "Hello Neptune")
+
+	state, report, err = driver.SyncDownIncremental("Folder1", localDir, state)
+	require.NoError(t, err)
+	require.Equal(t, []string{"File1"}, report.Downloaded)
+	require.Equal(t, []string{"File4"}, report.Removed)
+	require.ElementsMatch(t, []string{"Folder3", "Folder3/File2"}, report.Moved)
+
+	require.Equal(t, "Hello Saturn", readLocal("File1"))
+	require.Equal(t, "Hello Universe", readLocal("Folder3/File2"))
+	require.Equal(t, "Hello Mars", readLocal("Folder3/File3"))
+	for _, removed := range []string{"File2", "File4", "Folder2", "File6"} {
+		_, err = os.Stat(filepath.Join(localDir, removed))
+		require.True(t, os.IsNotExist(err), removed)
+	}
+
+	// moving a file out of the directory removes it
+	_, err = driver.Move("Folder1/File1", "Other/File1")
+	require.NoError(t, err)
+	_, report, err = driver.SyncDownIncremental("Folder1", localDir, state)
+	require.NoError(t, err)
+	require.Equal(t, []string{"File1"}, report.Removed)
+}
+
+func TestSyncDownIncrementalUnsafeNames(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "../File1", "Hello World")
+
+	parentDir, err := ioutil.TempDir("", "gdriver")
+	require.NoError(t, err)
+	defer os.RemoveAll(parentDir)
+	localDir := filepath.Join(parentDir, "Sync")
+
+	_, _, err = driver.SyncDownIncremental("", localDir, SyncState{})
+	require.Equal(t, UnsafeLocalPathError{Path: ".."}, err)
+	_, err = os.Stat(filepath.Join(parentDir, "File1"))
+	require.True(t, os.IsNotExist(err))
+}
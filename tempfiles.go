@@ -0,0 +1,278 @@
+package gdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// tempFilePrefix is the name prefix of temporary files
+const tempFilePrefix = ".gdriver-tmp-"
+
+// CleanTempFiles deletes the temporary files (files with the prefix ".gdriver-tmp-") in the directory path
+// that were created more than olderThan ago and returns how many files were deleted.
+// Temporary files are left behind if a process crashes during an upload.
+func (d *GDriver) CleanTempFiles(path string, olderThan time.Duration) (deleted int, err error) {
+	defer d.audit("CleanTempFiles", path, "")(&err)
+	deadline := d.now().Add(-olderThan)
+	return d.deleteTempFiles(path, tempFilePrefix, func(file *FileInfo) bool {
+		return file.CreationTime().Before(deadline)
+	})
+}
+
+// RunTempFileCleaner calls CleanTempFiles for the directory path immediately and then every interval,
+// onClean (if not nil) is called with the amount of deleted files after every run.
+// Transient errors (e.g. rate limits or network errors) are ignored, the files are cleaned again in the next run.
+// RunTempFileCleaner blocks until ctx is done (and returns ctx.Err()) or another error occurs.
+//
+// Examples:
+//     go driver.RunTempFileCleaner(ctx, "Uploads", time.Hour, 24*time.Hour, nil)
+func (d *GDriver) RunTempFileCleaner(ctx context.Context, path string, interval, olderThan time.Duration, onClean func(deleted int)) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// the ticker might fire at the same time ctx is done
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		deleted, err := d.CleanTempFiles(path, olderThan)
+		if err != nil {
+			if !isTransientError(err) {
+				return err
+			}
+		} else if onClean != nil {
+			onClean(deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RebuildPolicy controls which file RecoverFromCrash keeps if a directory contains multiple files with the same name,
+// e.g. because a PutFile was retried after the first upload created the file
+type RebuildPolicy int
+
+const (
+	// RebuildKeepNewest keeps the file that was created last, it is the upload of the last retry (default)
+	RebuildKeepNewest RebuildPolicy = iota
+	// RebuildKeepOldest keeps the file that was created first
+	RebuildKeepOldest
+)
+
+func (p RebuildPolicy) String() string {
+	switch p {
+	case RebuildKeepNewest:
+		return "keep newest"
+	case RebuildKeepOldest:
+		return "keep oldest"
+	default:
+		return fmt.Sprintf("RebuildPolicy(%d)", int(p))
+	}
+}
+
+// WithRebuildPolicy sets the RebuildPolicy RecoverFromCrash uses for duplicate files, it defaults to RebuildKeepNewest
+func WithRebuildPolicy(policy RebuildPolicy) Option {
+	return func(driver *GDriver) error {
+		switch policy {
+		case RebuildKeepNewest, RebuildKeepOldest:
+		default:
+			return fmt.Errorf("unknown rebuild policy %s", policy)
+		}
+		driver.rebuildPolicy = policy
+		return nil
+	}
+}
+
+// AtomicPutFile uploads r to path like PutFile, but readers never see a partial upload:
+// the contents are uploaded to a temporary file (with the prefix ".gdriver-tmp-") in the same directory,
+// which replaces the existing file after the upload succeeded.
+// Note that the file gets a new id. If the process crashes the temporary file is left behind, see CleanTempFiles and RecoverFromCrash.
+func (d *GDriver) AtomicPutFile(filePath string, r io.Reader) (_ *FileInfo, err error) {
+	defer d.audit("AtomicPutFile", filePath, "")(&err)
+	pathParts := strings.FieldsFunc(filePath, isPathSeperator)
+	amountOfParts := len(pathParts)
+	if amountOfParts <= 0 {
+		return nil, errors.New("path cannot be empty")
+	}
+	if err = d.validateName(pathParts[amountOfParts-1]); err != nil {
+		return nil, err
+	}
+	parentNode, err := d.makeParentDirectory(pathParts)
+	if err != nil {
+		return nil, err
+	}
+
+	requestID, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+	tmpParts := append(pathParts[:amountOfParts-1:amountOfParts-1], tempFilePrefix+requestID)
+	tmp, err := d.createFileInParent(parentNode, path.Join(tmpParts...), tmpParts, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	newFile, err := d.replaceWithTemp(parentNode, filePath, pathParts[amountOfParts-1], tmp)
+	if err != nil {
+		// the temporary file is not needed anymore
+		d.srv.Files.Delete(tmp.item.Id).Do() // nolint: errcheck
+		return nil, err
+	}
+	return &FileInfo{
+		item:         newFile,
+		parentPath:   path.Join(pathParts[:amountOfParts-1]...),
+		pathEscaping: d.pathEscaping,
+	}, nil
+}
+
+// replaceWithTemp renames the temporary file tmp in parentNode to name and deletes the existing file name afterwards,
+// if the process crashes in between both files exist and RecoverFromCrash keeps the newer one (see RebuildKeepNewest)
+func (d *GDriver) replaceWithTemp(parentNode *FileInfo, filePath, name string, tmp *FileInfo) (*drive.File, error) {
+	existing, err := d.getFileByParts(parentNode, []string{name}, "files(id,mimeType)")
+	if err != nil && !IsNotExist(err) {
+		return nil, err
+	}
+	if existing != nil && existing.IsDir() {
+		return nil, FileIsDirectoryError{Path: filePath}
+	}
+	newFile, err := d.srv.Files.Update(tmp.item.Id, &drive.File{
+		Name: d.pathEscaping.unescape(name),
+	}).Fields(fileInfoFields...).Do()
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err = d.srv.Files.Delete(existing.item.Id).Do(); err != nil {
+			return nil, err
+		}
+	}
+	return newFile, nil
+}
+
+// RecoverFromCrash deletes all files in the directory path whose name starts with tmpPrefix (".gdriver-tmp-" if empty)
+// and resolves files with the same name (e.g. created by retried PutFile calls) with the RebuildPolicy (see WithRebuildPolicy),
+// it returns how many files were deleted. Use it after a crash, when no other process uploads to the directory:
+// the temporary files are partial uploads that were never completed, unlike CleanTempFiles their age is not checked.
+// Directories with the same name are not resolved.
+func (d *GDriver) RecoverFromCrash(path string, tmpPrefix string) (recovered int, err error) {
+	defer d.audit("RecoverFromCrash", path, "")(&err)
+	if tmpPrefix == "" {
+		tmpPrefix = tempFilePrefix
+	}
+	recovered, err = d.deleteTempFiles(path, tmpPrefix, func(*FileInfo) bool {
+		return true
+	})
+	if err != nil {
+		return recovered, err
+	}
+	duplicates, err := d.deleteDuplicates(path)
+	return recovered + duplicates, err
+}
+
+// deleteTempFiles deletes the files in the directory path whose name starts with prefix and that match expired
+func (d *GDriver) deleteTempFiles(path, prefix string, expired func(*FileInfo) bool) (deleted int, err error) {
+	dir, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return 0, err
+	}
+	if !dir.IsDir() {
+		return 0, FileIsNotDirectoryError{Path: path}
+	}
+
+	var files []*FileInfo
+	query := fmt.Sprintf("'%s' in parents and name contains '%s' and trashed = false", dir.item.Id, escapeQueryValue(prefix))
+	err = d.listFiles(query, listFields[0], func(f *drive.File) error {
+		file := &FileInfo{item: f, parentPath: dir.Path(), pathEscaping: d.pathEscaping}
+		if !file.IsDir() && strings.HasPrefix(f.Name, prefix) && expired(file) {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return d.deleteFiles(files)
+}
+
+// deleteDuplicates deletes the files in the directory path that have the same name as another file,
+// the file that is kept is selected by the RebuildPolicy
+func (d *GDriver) deleteDuplicates(path string) (int, error) {
+	dir, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return 0, err
+	}
+
+	byName := make(map[string][]*FileInfo)
+	var names []string
+	query := fmt.Sprintf("'%s' in parents and trashed = false", dir.item.Id)
+	err = d.listFiles(query, "files(id,name,mimeType,createdTime)", func(f *drive.File) error {
+		file := &FileInfo{item: f, parentPath: dir.Path(), pathEscaping: d.pathEscaping}
+		if file.IsDir() {
+			return nil
+		}
+		if _, ok := byName[f.Name]; !ok {
+			names = append(names, f.Name)
+		}
+		byName[f.Name] = append(byName[f.Name], file)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var files []*FileInfo
+	for _, name := range names {
+		duplicates := byName[name]
+		if len(duplicates) < 2 {
+			continue
+		}
+		sort.SliceStable(duplicates, func(i, j int) bool {
+			return duplicates[i].CreationTime().Before(duplicates[j].CreationTime())
+		})
+		if d.rebuildPolicy == RebuildKeepOldest {
+			files = append(files, duplicates[1:]...)
+		} else {
+			files = append(files, duplicates[:len(duplicates)-1]...)
+		}
+	}
+	return d.deleteFiles(files)
+}
+
+// deleteFiles deletes the files and returns how many files were deleted, files that do not exist anymore are skipped
+func (d *GDriver) deleteFiles(files []*FileInfo) (deleted int, err error) {
+	for _, file := range files {
+		if err = d.srv.Files.Delete(file.item.Id).Do(); err != nil {
+			if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+				// deleted by someone else in the meantime
+				continue
+			}
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// now returns the current time, it can be replaced in tests
+func (d *GDriver) now() time.Time {
+	if d.clock != nil {
+		return d.clock()
+	}
+	return time.Now()
+}
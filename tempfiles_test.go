@@ -0,0 +1,207 @@
+package gdriver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanTempFiles(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub.add("root", "1", "Folder1", true)
+	stub.add("1", "2", ".gdriver-tmp-1", false)
+	stub.add("1", "3", ".gdriver-tmp-2", false)
+	stub.add("1", "4", "File1", false)
+	stub.add("1", "5", ".gdriver-tmp-3", true)
+	for id, offset := range map[string]time.Duration{"2": 0, "3": 2 * time.Hour, "4": 0, "5": 0} {
+		stub.files[id].CreatedTime = created.Add(offset).Format(time.RFC3339)
+	}
+
+	now := created.Add(90 * time.Minute)
+	driver.clock = func() time.Time {
+		return now
+	}
+
+	deleted, err := driver.CleanTempFiles("Folder1", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	require.Equal(t, []string{"2"}, stub.deleted)
+
+	// advance the time
+	now = created.Add(4 * time.Hour)
+	deleted, err = driver.CleanTempFiles("Folder1", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	require.Equal(t, []string{"2", "3"}, stub.deleted)
+
+	deleted, err = driver.CleanTempFiles("Folder1", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 0, deleted)
+}
+
+func TestRunTempFileCleaner(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	stub.add("root", "1", "Folder1", true)
+	stub.add("1", "2", ".gdriver-tmp-1", false)
+	stub.files["2"].CreatedTime = created.Format(time.RFC3339)
+
+	now := created
+	driver.clock = func() time.Time {
+		return now
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var runs []int
+	err := driver.RunTempFileCleaner(ctx, "Folder1", time.Millisecond, time.Hour, func(deleted int) {
+		runs = append(runs, deleted)
+		switch len(runs) {
+		case 1:
+			// the file is not old enough, advance the time
+			now = created.Add(2 * time.Hour)
+		case 2:
+			cancel()
+		}
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, []int{0, 1}, runs)
+	require.Equal(t, []string{"2"}, stub.deleted)
+
+	err = driver.RunTempFileCleaner(context.Background(), "Folder1", 0, time.Hour, nil)
+	require.EqualError(t, err, "interval must be positive")
+}
+
+func TestRecoverFromCrash(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Folder1", true)
+	stub.add("1", "2", ".gdriver-tmp-1", false)
+	stub.add("1", "3", ".partial-1", false)
+	stub.add("1", "4", "File1", false)
+	stub.add("1", "5", ".partial-2", true)
+	stub.add("1", "6", "File.partial-3", false)
+	for _, file := range stub.files {
+		file.CreatedTime = driver.now().Format(time.RFC3339)
+	}
+
+	recovered, err := driver.RecoverFromCrash("Folder1", ".partial-")
+	require.NoError(t, err)
+	require.Equal(t, 1, recovered)
+	require.Equal(t, []string{"3"}, stub.deleted)
+
+	// the default prefix ignores the age of the files
+	recovered, err = driver.RecoverFromCrash("Folder1", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, recovered)
+	require.Equal(t, []string{"3", "2"}, stub.deleted)
+}
+
+func TestRecoverFromCrashDuplicates(t *testing.T) {
+	newTree := func(t *testing.T) (*GDriver, *listStub, func()) {
+		driver, stub, teardown := newListStub(t)
+		created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+		stub.add("root", "1", "Folder1", true)
+		// a retried upload created File1 three times
+		stub.add("1", "2", "File1", false)
+		stub.add("1", "3", "File1", false)
+		stub.add("1", "4", "File1", false)
+		stub.add("1", "5", "File2", false)
+		stub.add("1", "6", "Folder2", true)
+		stub.add("1", "7", "Folder2", true)
+		for id, offset := range map[string]time.Duration{"2": time.Minute, "3": 0, "4": 2 * time.Minute, "5": 0, "6": 0, "7": time.Minute} {
+			stub.files[id].CreatedTime = created.Add(offset).Format(time.RFC3339)
+		}
+		return driver, stub, teardown
+	}
+
+	t.Run("keep newest", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+
+		recovered, err := driver.RecoverFromCrash("Folder1", "")
+		require.NoError(t, err)
+		require.Equal(t, 2, recovered)
+		require.Equal(t, []string{"3", "2"}, stub.deleted)
+	})
+
+	t.Run("keep oldest", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+		require.NoError(t, WithRebuildPolicy(RebuildKeepOldest)(driver))
+
+		recovered, err := driver.RecoverFromCrash("Folder1", "")
+		require.NoError(t, err)
+		require.Equal(t, 2, recovered)
+		require.Equal(t, []string{"2", "4"}, stub.deleted)
+	})
+
+	require.Error(t, WithRebuildPolicy(RebuildPolicy(2))(&GDriver{}))
+}
+
+func TestAtomicPutFile(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	names := func(path string) []string {
+		var names []string
+		require.NoError(t, driver.ListDirectory(path, func(f *FileInfo) error {
+			names = append(names, f.Name())
+			return nil
+		}))
+		return names
+	}
+	contents := func(path string) string {
+		_, r, err := driver.GetFile(path)
+		require.NoError(t, err)
+		received, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return string(received)
+	}
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	old, err := driver.Stat("Folder1/File1")
+	require.NoError(t, err)
+
+	fi, err := driver.AtomicPutFile("Folder1/File1", bytes.NewBufferString("Hello Universe"))
+	require.NoError(t, err)
+	require.Equal(t, "Folder1/File1", fi.Path())
+	require.NotEqual(t, old.DriveFile().Id, fi.DriveFile().Id)
+	require.Equal(t, "Hello Universe", contents("Folder1/File1"))
+	require.Equal(t, []string{"File1"}, names("Folder1"))
+
+	fi, err = driver.AtomicPutFile("Folder2/File2", bytes.NewBufferString("Hello World"))
+	require.NoError(t, err)
+	require.Equal(t, "Folder2/File2", fi.Path())
+	require.Equal(t, "Hello World", contents("Folder2/File2"))
+
+	// failed uploads keep the existing file and leave no temporary file behind
+	_, err = driver.AtomicPutFile("Folder1/File1", io.MultiReader(strings.NewReader("Hello"), failingReader{}))
+	require.Error(t, err)
+	require.Equal(t, "Hello Universe", contents("Folder1/File1"))
+	require.Equal(t, []string{"File1"}, names("Folder1"))
+
+	_, err = driver.AtomicPutFile("Folder1", bytes.NewBufferString("Hello World"))
+	require.Equal(t, FileIsDirectoryError{Path: "Folder1"}, err)
+	require.ElementsMatch(t, []string{"Folder1", "Folder2"}, names(""))
+}
+
+// failingReader fails every read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
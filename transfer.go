@@ -0,0 +1,235 @@
+package gdriver
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+type transferOptions struct {
+	progress func(transferred, total int64)
+	reporter ProgressReporter
+}
+
+// TransferOption can be used to pass optional options to TransferFile and TransferDirectory
+type TransferOption func(options *transferOptions)
+
+// TransferProgress calls fn while the contents are transferred,
+// total is the size of the source file or -1 if it is unknown
+func TransferProgress(fn func(transferred, total int64)) TransferOption {
+	return func(options *transferOptions) {
+		options.progress = fn
+	}
+}
+
+// TransferReporter reports the progress of every file that TransferDirectory transfers to reporter
+func TransferReporter(reporter ProgressReporter) TransferOption {
+	return func(options *transferOptions) {
+		options.reporter = reporter
+	}
+}
+
+// TransferFile copies the file srcPath of src to dstPath of dst, src and dst can be authenticated with different accounts.
+// The contents are streamed from src to dst without buffering the whole file.
+// The mime type and modified time of the source file are preserved,
+// if dstPath is an existing directory the file will be placed in it using the name of the source file.
+// The transferred contents are verified using the md5 checksum of the source file,
+// a ChecksumMismatchError will be returned (and the copy will be deleted) if they do not match.
+func TransferFile(src *GDriver, srcPath string, dst *GDriver, dstPath string, opts ...TransferOption) (_ *FileInfo, err error) {
+	defer dst.audit("TransferFile", srcPath, dstPath)(&err)
+
+	var options transferOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	srcFile, err := src.getFile(src.rootNode, srcPath, md5ListFields())
+	if err != nil {
+		return nil, err
+	}
+	if srcFile.IsDir() {
+		return nil, FileIsDirectoryError{Path: srcPath}
+	}
+	return transferFile(src, srcFile, dst, dstPath, options)
+}
+
+// TransferDirectory copies the directory srcPath of src with all its descendants to dstPath of dst (see TransferFile)
+func TransferDirectory(src *GDriver, srcPath string, dst *GDriver, dstPath string, opts ...TransferOption) (err error) {
+	defer dst.audit("TransferDirectory", srcPath, dstPath)(&err)
+
+	var options transferOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	srcDir, err := src.getFile(src.rootNode, srcPath, "files(id,mimeType)")
+	if err != nil {
+		return err
+	}
+	if !srcDir.IsDir() {
+		return FileIsNotDirectoryError{Path: srcPath}
+	}
+	if _, err = dst.MakeDirectory(dstPath); err != nil {
+		return err
+	}
+
+	// collect the tree first, so the totals are known
+	var items []*FileInfo
+	var files int
+	err = src.Walk(srcPath, func(f *FileInfo) error {
+		items = append(items, f)
+		if !f.IsDir() {
+			files++
+		}
+		return nil
+	}, WalkSorted(), walkFields(md5ListFields()))
+	if err != nil {
+		return err
+	}
+
+	report := newBatchReport(files, nil, options.reporter)
+	srcDirPath := strings.Join(strings.FieldsFunc(srcPath, isPathSeperator), "/")
+	for _, f := range items {
+		targetPath := path.Join(dstPath, strings.TrimPrefix(strings.TrimPrefix(f.Path(), srcDirPath), "/"))
+		if f.IsDir() {
+			if _, err = dst.MakeDirectory(targetPath); err != nil {
+				return err
+			}
+			continue
+		}
+		report.started(f.Path())
+		fi, err := transferFile(src, f, dst, targetPath, options)
+		report.finished(f.Path(), fileSize(fi), err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transferFile(src *GDriver, srcFile *FileInfo, dst *GDriver, dstPath string, options transferOptions) (*FileInfo, error) {
+	if srcFile.IsGoogleNative() {
+		return nil, fmt.Errorf("`%s' is a google native file and has no contents that could be transferred", srcFile.Path())
+	}
+
+	dstParts := strings.FieldsFunc(dstPath, isPathSeperator)
+	existentFile, err := dst.getFileByParts(dst.rootNode, dstParts, "files(id,mimeType)")
+	if err != nil && !IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if !existentFile.IsDir() {
+			return nil, FileExistError{Path: dstPath}
+		}
+		dstParts = append(dstParts, dst.pathEscaping.escape(srcFile.Name()))
+		if _, err = dst.getFileByParts(dst.rootNode, dstParts, "files(id)"); err == nil {
+			return nil, FileExistError{Path: path.Join(dstParts...)}
+		} else if !IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	body, err := src.downloadDecoded(srcFile)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	reader := &transferReader{
+		Reader:   body,
+		hash:     md5.New(),
+		progress: options.progress,
+		total:    -1,
+	}
+	switch {
+	case srcFile.IsCompressed():
+		reader.total = srcFile.OriginalSize()
+	case !srcFile.IsEncrypted():
+		reader.total = srcFile.Size()
+	}
+
+	dstFile, err := dst.createFile(path.Join(dstParts...), dstParts, reader, &drive.File{
+		MimeType:     srcFile.item.MimeType,
+		ModifiedTime: srcFile.item.ModifiedTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifyTransfer(srcFile, dst, dstFile, reader.hash.Sum(nil)); err != nil {
+		dst.srv.Files.Delete(dstFile.item.Id).Do() // nolint: errcheck
+		return nil, err
+	}
+	return dstFile, nil
+}
+
+// verifyTransfer compares the checksum of the transferred contents with the checksums of the source and destination file
+func verifyTransfer(srcFile *FileInfo, dst *GDriver, dstFile *FileInfo, transferred []byte) error {
+	actual := []byte(hex.EncodeToString(transferred))
+
+	// the checksum of encoded files is the checksum of the encoded contents
+	if !isEncodedFile(srcFile.item) && srcFile.item.Md5Checksum != "" && srcFile.item.Md5Checksum != string(actual) {
+		return ChecksumMismatchError{
+			Path:     srcFile.Path(),
+			FileID:   srcFile.item.Id,
+			Expected: []byte(srcFile.item.Md5Checksum),
+			Actual:   actual,
+			Method:   HashMethodMD5,
+		}
+	}
+
+	if isEncodedFile(dstFile.item) {
+		return nil
+	}
+	file, err := dst.srv.Files.Get(dstFile.item.Id).Fields("md5Checksum").Do()
+	if err != nil {
+		return err
+	}
+	if file.Md5Checksum != "" && file.Md5Checksum != string(actual) {
+		return ChecksumMismatchError{
+			Path:     dstFile.Path(),
+			FileID:   dstFile.item.Id,
+			Expected: actual,
+			Actual:   []byte(file.Md5Checksum),
+			Method:   HashMethodMD5,
+		}
+	}
+	return nil
+}
+
+// isEncodedFile returns true if the contents of the file are stored encoded (e.g. encrypted or compressed)
+func isEncodedFile(file *drive.File) bool {
+	for key := range file.AppProperties {
+		if isContentAppProperty(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// transferReader hashes the contents and reports the progress
+type transferReader struct {
+	io.Reader
+	hash        hash.Hash
+	progress    func(transferred, total int64)
+	transferred int64
+	total       int64
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n]) // nolint: errcheck
+		r.transferred += int64(n)
+		if r.progress != nil {
+			r.progress(r.transferred, r.total)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,81 @@
+package gdriver
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTransferTarget returns a copy of driver that uses path as root directory
+func newTransferTarget(t *testing.T, driver *GDriver, path string) *GDriver {
+	dir, err := driver.MakeDirectory(path)
+	require.NoError(t, err)
+	target := *driver
+	target.rootNode = dir
+	return &target
+}
+
+func TestTransferFile(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1.txt", "Hello World")
+	src, err := driver.Stat("Folder1/File1.txt")
+	require.NoError(t, err)
+
+	dst := newTransferTarget(t, driver, "Target")
+
+	var transferred, total int64
+	fi, err := TransferFile(driver, "Folder1/File1.txt", dst, "Folder2/File2.txt", TransferProgress(func(n, size int64) {
+		transferred = n
+		total = size
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "Folder2/File2.txt", fi.Path())
+	require.EqualValues(t, 11, transferred)
+	require.EqualValues(t, 11, total)
+
+	fi, r, err := dst.GetFile("Folder2/File2.txt")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", string(data))
+	require.Equal(t, src.item.MimeType, fi.item.MimeType)
+	require.Equal(t, src.ModifiedTime(), fi.ModifiedTime())
+
+	// transfer into an existing directory keeps the name
+	fi, err = TransferFile(driver, "Folder1/File1.txt", dst, "Folder2")
+	require.NoError(t, err)
+	require.Equal(t, "Folder2/File1.txt", fi.Path())
+
+	_, err = TransferFile(driver, "Folder1/File1.txt", dst, "Folder2/File2.txt")
+	require.Equal(t, FileExistError{Path: "Folder2/File2.txt"}, err)
+}
+
+func TestTransferDirectory(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	newFile(t, driver, "Folder1/File1", "Hello World")
+	newFile(t, driver, "Folder1/Folder2/File2", "Hello Universe")
+
+	dst := newTransferTarget(t, driver, "Target")
+	require.NoError(t, TransferDirectory(driver, "Folder1", dst, "Copy"))
+
+	_, r, err := dst.GetFile("Copy/Folder2/File2")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "Hello Universe", string(data))
+
+	files, dirs, err := dst.GetFilesCount("Copy")
+	require.NoError(t, err)
+	require.Equal(t, 2, files)
+	require.Equal(t, 1, dirs)
+}
+
+func TestMD5ListFields(t *testing.T) {
+	require.Equal(t, strings.TrimSuffix(string(listFields[0]), ")")+",md5Checksum)", string(md5ListFields()))
+}
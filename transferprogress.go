@@ -0,0 +1,47 @@
+package gdriver
+
+import "errors"
+
+// defaultCopyBufferSize is the size of the buffer that is used to copy the contents of files
+const defaultCopyBufferSize = 1024 * 1024
+
+// WithCopyBufferSize sets the size of the buffer that is used when the contents of a file are copied with io.Copy,
+// it defaults to 1 MiB
+func WithCopyBufferSize(size int) Option {
+	return func(driver *GDriver) error {
+		if size <= 0 {
+			return errors.New("copy buffer size must be greater than 0")
+		}
+		driver.copyBufferSize = size
+		return nil
+	}
+}
+
+func (d *GDriver) getCopyBufferSize() int {
+	if d.copyBufferSize <= 0 {
+		return defaultCopyBufferSize
+	}
+	return d.copyBufferSize
+}
+
+// TransferProgressFunc will be called with the amount of bytes that were transferred of the file at path,
+// total is the size of the file or -1 if it is unknown
+type TransferProgressFunc func(path string, transferred, total int64)
+
+// WithTransferProgress reports the progress of files that are copied with io.Copy to fn
+func WithTransferProgress(fn TransferProgressFunc) Option {
+	return func(driver *GDriver) error {
+		driver.transferProgressFunc = fn
+		return nil
+	}
+}
+
+// transferProgress returns the function that reports the progress of the file at path
+func (d *GDriver) transferProgress(path string, total int64) func(transferred int64) {
+	if d.transferProgressFunc == nil {
+		return func(int64) {}
+	}
+	return func(transferred int64) {
+		d.transferProgressFunc(path, transferred, total)
+	}
+}
@@ -0,0 +1,96 @@
+package gdriver
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+// newTestReadFile returns a readFile that reads contents instead of downloading the file
+func newTestReadFile(driver *GDriver, contents []byte) *readFile {
+	f := &readFile{
+		Driver: driver,
+		FileInfo: &FileInfo{
+			item: &drive.File{
+				Name: "File1",
+				Size: int64(len(contents)),
+			},
+			parentPath: "Folder1",
+		},
+		reader: ioutil.NopCloser(bytes.NewReader(contents)),
+	}
+	f.once.Do(func() {})
+	return f
+}
+
+// writeRecorder records the size of every write and fails after limit bytes (if limit is not 0)
+type writeRecorder struct {
+	bytes.Buffer
+	writes []int
+	limit  int
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	if w.limit > 0 && w.Len()+len(p) > w.limit {
+		n, _ := w.Buffer.Write(p[:w.limit-w.Len()])
+		w.writes = append(w.writes, n)
+		return n, errors.New("disk full")
+	}
+	w.writes = append(w.writes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestReadFileWriteTo(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789"), 10)
+
+	t.Run("buffer size and progress", func(t *testing.T) {
+		type progress struct {
+			path               string
+			transferred, total int64
+		}
+		var reported []progress
+		driver := &GDriver{}
+		require.NoError(t, WithCopyBufferSize(40)(driver))
+		require.NoError(t, WithTransferProgress(func(path string, transferred, total int64) {
+			reported = append(reported, progress{path, transferred, total})
+		})(driver))
+
+		var w writeRecorder
+		n, err := newTestReadFile(driver, contents).WriteTo(&w)
+		require.NoError(t, err)
+		require.EqualValues(t, len(contents), n)
+		require.Equal(t, contents, w.Bytes())
+		require.Equal(t, []int{40, 40, 20}, w.writes)
+		require.Equal(t, []progress{
+			{"Folder1/File1", 40, 100},
+			{"Folder1/File1", 80, 100},
+			{"Folder1/File1", 100, 100},
+		}, reported)
+	})
+
+	t.Run("default buffer size", func(t *testing.T) {
+		var w writeRecorder
+		n, err := newTestReadFile(&GDriver{}, contents).WriteTo(&w)
+		require.NoError(t, err)
+		require.EqualValues(t, len(contents), n)
+		require.Equal(t, []int{100}, w.writes)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		driver := &GDriver{}
+		require.NoError(t, WithCopyBufferSize(40)(driver))
+		w := writeRecorder{limit: 55}
+		n, err := newTestReadFile(driver, contents).WriteTo(&w)
+		require.EqualError(t, err, "disk full")
+		require.EqualValues(t, 55, n)
+		require.Equal(t, contents[:55], w.Bytes())
+	})
+
+	t.Run("invalid buffer size", func(t *testing.T) {
+		require.Error(t, WithCopyBufferSize(0)(&GDriver{}))
+	})
+}
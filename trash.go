@@ -0,0 +1,155 @@
+package gdriver
+
+import (
+	"sort"
+	"strings"
+
+	drive "google.golang.org/api/drive/v3"
+)
+
+// defaultTrashConcurrency is the amount of files TrashFiltered trashes at the same time if not specified otherwise
+const defaultTrashConcurrency = 4
+
+// TrashDecider will be called by TrashFiltered for every visited file and directory,
+// trash controls whether the item will be trashed and descend whether the descendants of a directory will be visited.
+// Directories that are trashed are trashed with all their descendants, so they will not be visited.
+type TrashDecider func(info *FileInfo) (trash bool, descend bool, err error)
+
+type trashOptions struct {
+	concurrency int
+	emptyDirs   bool
+}
+
+// TrashOption can be used to pass optional options to TrashFiltered
+type TrashOption func(options *trashOptions)
+
+// TrashConcurrency sets the amount of files that will be trashed at the same time, defaults to 4
+func TrashConcurrency(n int) TrashOption {
+	return func(options *trashOptions) {
+		options.concurrency = n
+	}
+}
+
+// TrashEmptyDirectories trashes the visited directories that are empty after the filtered files were trashed
+func TrashEmptyDirectories() TrashOption {
+	return func(options *trashOptions) {
+		options.emptyDirs = true
+	}
+}
+
+// trashedDir tracks the children of a directory TrashFiltered descended into
+type trashedDir struct {
+	info     *FileInfo
+	children int
+	trashed  int
+}
+
+// TrashFiltered walks the directory path and trashes the files and directories decide selected.
+// The directory path itself will not be trashed.
+// If decide returns an error the walk stops before anything was trashed and a CallbackError will be returned,
+// errors of the trashed items are reported in the BatchResult.
+//
+// Examples:
+//     TrashFiltered("Downloads", func(f *FileInfo) (bool, bool, error) {
+//         return !f.IsDir() && f.ModifiedTime().Before(time.Now().AddDate(0, 0, -30)), true, nil
+//     }, TrashEmptyDirectories())
+func (d *GDriver) TrashFiltered(path string, decide TrashDecider, opts ...TrashOption) (_ *BatchResult, err error) {
+	defer d.audit("TrashFiltered", path, "")(&err)
+	options := trashOptions{
+		concurrency: defaultTrashConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.concurrency <= 0 {
+		options.concurrency = defaultTrashConcurrency
+	}
+
+	rootPath := strings.Join(strings.FieldsFunc(path, isPathSeperator), "/")
+	dirs := map[string]*trashedDir{rootPath: {}}
+	var items []*FileInfo
+	err = d.Walk(path, func(f *FileInfo) error {
+		trash, descend, err := decide(f)
+		if err != nil {
+			return err
+		}
+		if parent, ok := dirs[f.ParentPath()]; ok {
+			parent.children++
+		}
+		if trash {
+			items = append(items, f)
+			if f.IsDir() {
+				return SkipDir
+			}
+			return nil
+		}
+		if f.IsDir() {
+			if !descend {
+				return SkipDir
+			}
+			dirs[f.Path()] = &trashedDir{info: f}
+		}
+		return nil
+	}, walkFields(listFields[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{
+		Results: make([]PutResult, len(items)),
+	}
+	var backoff batchBackoff
+	runBatch(len(items), options.concurrency, func(index int) {
+		res := &result.Results[index]
+		res.Path = items[index].Path()
+		res.FileInfo = items[index]
+		res.Err = retry(&backoff, func() error {
+			return d.trashFile(items[index])
+		})
+	})
+	if !options.emptyDirs {
+		return result, nil
+	}
+
+	for _, res := range result.Results {
+		if parent, ok := dirs[res.FileInfo.ParentPath()]; ok && res.Err == nil {
+			parent.trashed++
+		}
+	}
+	// trash the deepest directories first, so their parents can become empty
+	var emptyCandidates []*trashedDir
+	for dirPath, dir := range dirs {
+		if dirPath != rootPath {
+			emptyCandidates = append(emptyCandidates, dir)
+		}
+	}
+	sort.Slice(emptyCandidates, func(i, j int) bool {
+		di, dj := strings.Count(emptyCandidates[i].info.Path(), "/"), strings.Count(emptyCandidates[j].info.Path(), "/")
+		if di != dj {
+			return di > dj
+		}
+		return emptyCandidates[i].info.Path() < emptyCandidates[j].info.Path()
+	})
+	for _, dir := range emptyCandidates {
+		if dir.children != dir.trashed {
+			continue
+		}
+		res := PutResult{Path: dir.info.Path(), FileInfo: dir.info}
+		res.Err = retry(&backoff, func() error {
+			return d.trashFile(dir.info)
+		})
+		if parent, ok := dirs[dir.info.ParentPath()]; ok && res.Err == nil {
+			parent.trashed++
+		}
+		result.Results = append(result.Results, res)
+	}
+	return result, nil
+}
+
+// trashFile moves the file or directory to the trash
+func (d *GDriver) trashFile(file *FileInfo) error {
+	_, err := d.srv.Files.Update(file.item.Id, &drive.File{
+		Trashed: true,
+	}).Fields("id").Do()
+	return err
+}
@@ -0,0 +1,95 @@
+package gdriver
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashFiltered(t *testing.T) {
+	newTree := func(t *testing.T) (*GDriver, *listStub, func()) {
+		driver, stub, teardown := newListStub(t)
+		stub.add("root", "old1", "old1.txt", false)
+		stub.add("root", "new1", "new1.txt", false)
+		stub.add("root", "dir1", "Folder1", true)
+		stub.add("dir1", "old2", "old2.txt", false)
+		stub.add("dir1", "dir2", "Folder2", true)
+		stub.add("dir2", "old3", "old3.txt", false)
+		stub.add("root", "dir3", "Folder3", true)
+		stub.add("dir3", "new2", "new2.txt", false)
+		stub.add("root", "keep", "Keep", true)
+		stub.add("keep", "old4", "old4.txt", false)
+		return driver, stub, teardown
+	}
+	// trash the old files, but do not descend into Keep
+	decide := func(f *FileInfo) (bool, bool, error) {
+		if f.IsDir() {
+			return false, f.Name() != "Keep", nil
+		}
+		return strings.HasPrefix(f.Name(), "old"), false, nil
+	}
+	paths := func(result *BatchResult) []string {
+		var paths []string
+		for _, res := range result.Results {
+			require.NoError(t, res.Err)
+			paths = append(paths, res.Path)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	t.Run("files", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+
+		result, err := driver.TrashFiltered("", decide)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Folder1/Folder2/old3.txt", "Folder1/old2.txt", "old1.txt"}, paths(result))
+		sort.Strings(stub.trashed)
+		require.Equal(t, []string{"old1", "old2", "old3"}, stub.trashed)
+	})
+
+	t.Run("empty directories", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+
+		result, err := driver.TrashFiltered("", decide, TrashEmptyDirectories(), TrashConcurrency(2))
+		require.NoError(t, err)
+		// Folder3 still contains new2.txt and Keep was not visited
+		require.Equal(t, []string{"Folder1", "Folder1/Folder2", "Folder1/Folder2/old3.txt", "Folder1/old2.txt", "old1.txt"}, paths(result))
+		// Folder2 has to be trashed before Folder1
+		require.Equal(t, []string{"dir2", "dir1"}, stub.trashed[3:])
+	})
+
+	t.Run("trash directory", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+
+		var visited []string
+		result, err := driver.TrashFiltered("", func(f *FileInfo) (bool, bool, error) {
+			visited = append(visited, f.Path())
+			return f.Name() == "Folder1", true, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Folder1"}, paths(result))
+		require.Equal(t, []string{"dir1"}, stub.trashed)
+		// the descendants of a trashed directory are not visited
+		for _, p := range visited {
+			require.False(t, strings.HasPrefix(p, "Folder1/"), "visited %s", p)
+		}
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		driver, stub, teardown := newTree(t)
+		defer teardown()
+
+		_, err := driver.TrashFiltered("", func(f *FileInfo) (bool, bool, error) {
+			return false, false, errors.New("stop")
+		})
+		require.EqualError(t, err, "callback throwed an error: stop")
+		require.Empty(t, stub.trashed)
+	})
+}
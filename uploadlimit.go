@@ -0,0 +1,81 @@
+package gdriver
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// WithMaxUploadSize rejects uploads of more than n bytes with an ErrUploadTooLarge,
+// the limit applies to the contents before they are compressed or encrypted.
+// If the size of the contents is known (see PutFile) they are rejected before anything is sent,
+// otherwise the upload is aborted as soon as the limit is crossed, drive discards the contents that were sent so far.
+// The limit applies to PutFile, files opened for writing, PutFiles and TransferFile/TransferDirectory (limit of the destination).
+func WithMaxUploadSize(n int64) Option {
+	return func(driver *GDriver) error {
+		if n <= 0 {
+			return errors.New("maximum upload size must be positive")
+		}
+		driver.maxUploadSize = n
+		return nil
+	}
+}
+
+// limitUpload checks the size of r against the maximum upload size,
+// if the size is unknown r is wrapped into an uploadLimiter that fails once the limit is crossed
+func (d *GDriver) limitUpload(filePath string, r io.Reader) (io.Reader, *uploadLimiter, error) {
+	if d.maxUploadSize <= 0 {
+		return r, nil, nil
+	}
+	size := readerSize(r)
+	if size < 0 {
+		size = seekerSize(r)
+	}
+	if size > d.maxUploadSize {
+		return nil, nil, ErrUploadTooLarge{Path: filePath, Limit: d.maxUploadSize, Size: size}
+	}
+	if size >= 0 {
+		return r, nil, nil
+	}
+	limiter := &uploadLimiter{Reader: r, path: filePath, limit: d.maxUploadSize}
+	return limiter, limiter, nil
+}
+
+// uploadLimiter fails with an ErrUploadTooLarge if more than limit bytes are read
+type uploadLimiter struct {
+	io.Reader
+	path     string
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (l *uploadLimiter) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.err()
+	}
+	n, err := l.Reader.Read(p)
+	l.n += int64(n)
+	if l.n > l.limit {
+		l.exceeded = true
+		return 0, l.err()
+	}
+	return n, err
+}
+
+func (l *uploadLimiter) err() error {
+	return ErrUploadTooLarge{Path: l.path, Limit: l.limit, Size: -1}
+}
+
+// cancelUploadSession discards the contents that were uploaded to the resumable session
+func (d *GDriver) cancelUploadSession(sessionURI string) {
+	req, err := http.NewRequest(http.MethodDelete, sessionURI, nil)
+	if err != nil {
+		return
+	}
+	response, err := d.client.Do(req)
+	if err != nil {
+		return
+	}
+	response.Body.Close() // nolint: errcheck
+}
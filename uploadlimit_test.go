@@ -0,0 +1,115 @@
+package gdriver
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxUploadSize(t *testing.T) {
+	driver, teardown := setup(t)
+	defer teardown()
+
+	require.Error(t, WithMaxUploadSize(0)(driver))
+	require.NoError(t, WithMaxUploadSize(16)(driver))
+
+	// io.MultiReader hides the size of the contents
+	unsized := func(s string) io.Reader {
+		return io.MultiReader(strings.NewReader(s))
+	}
+	requireContents := func(path, expected string) {
+		_, r, err := driver.GetFile(path)
+		require.NoError(t, err)
+		contents, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, expected, string(contents))
+	}
+
+	t.Run("known size", func(t *testing.T) {
+		before, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		_, err = driver.PutFile("File1", strings.NewReader("Hello World, Hello Universe"))
+		require.Equal(t, ErrUploadTooLarge{Path: "File1", Limit: 16, Size: 27}, err)
+		after, err := driver.GetDriveMetrics()
+		require.NoError(t, err)
+		require.Equal(t, before.BytesUploaded, after.BytesUploaded)
+		require.True(t, IsNotExist(getError(driver.Stat("File1"))))
+
+		_, err = driver.PutFile("File1", unsized("Hello World"), PutContentLength(27))
+		require.Equal(t, ErrUploadTooLarge{Path: "File1", Limit: 16, Size: 27}, err)
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		_, err := driver.PutFile("File2", unsized("Hello World"))
+		require.NoError(t, err)
+		requireContents("File2", "Hello World")
+
+		_, err = driver.PutFile("File3", unsized("Hello World, Hello Universe"))
+		require.Equal(t, ErrUploadTooLarge{Path: "File3", Limit: 16, Size: -1}, err)
+		require.True(t, IsNotExist(getError(driver.Stat("File3"))))
+
+		// the existing contents are kept
+		_, err = driver.PutFile("File2", unsized("Hello World, Hello Universe"))
+		require.Equal(t, ErrUploadTooLarge{Path: "File2", Limit: 16, Size: -1}, err)
+		requireContents("File2", "Hello World")
+	})
+
+	t.Run("resumable session", func(t *testing.T) {
+		var sessions int
+		require.NoError(t, WithUploadSessionCallback(func(path, sessionURI string) {
+			sessions++
+		})(driver))
+		defer func() {
+			driver.uploadSessionFunc = nil
+		}()
+
+		_, err := driver.PutFile("File4", unsized("Hello World, Hello Universe"))
+		require.Equal(t, ErrUploadTooLarge{Path: "File4", Limit: 16, Size: -1}, err)
+		require.Equal(t, 1, sessions)
+		require.True(t, IsNotExist(getError(driver.Stat("File4"))))
+	})
+
+	t.Run("spool", func(t *testing.T) {
+		require.NoError(t, WithUploadSpool(4, "")(driver))
+		defer func() {
+			driver.uploadSpool = nil
+		}()
+
+		_, err := driver.PutFile("File5", unsized("Hello World, Hello Universe"))
+		require.Equal(t, ErrUploadTooLarge{Path: "File5", Limit: 16, Size: -1}, err)
+		require.True(t, IsNotExist(getError(driver.Stat("File5"))))
+	})
+
+	t.Run("write", func(t *testing.T) {
+		f, err := driver.Open("File6", O_WRONLY|O_CREATE)
+		require.NoError(t, err)
+		// the upload runs in the background, writes fail once it was aborted
+		for i := 0; i < 4 && err == nil; i++ {
+			_, err = f.Write([]byte("Hello World"))
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		require.Equal(t, ErrUploadTooLarge{Path: "File6", Limit: 16, Size: -1}, err)
+		require.True(t, IsNotExist(getError(driver.Stat("File6"))))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		reader := func(s string) ReaderProvider {
+			return func() (io.Reader, error) {
+				return strings.NewReader(s), nil
+			}
+		}
+		result, err := driver.PutFiles([]PutItem{
+			{Path: "File7", Reader: reader("Hello World")},
+			{Path: "File8", Reader: reader("Hello World, Hello Universe")},
+		}, 2, nil)
+		require.NoError(t, err)
+		require.NoError(t, result.Results[0].Err)
+		require.Equal(t, ErrUploadTooLarge{Path: "File8", Limit: 16, Size: 27}, result.Results[1].Err)
+	})
+}
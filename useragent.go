@@ -0,0 +1,55 @@
+package gdriver
+
+import "net/http"
+
+// Version is the version of gdriver that is used in the default User-Agent,
+// it can be set during the build:
+//     go build -ldflags "-X github.com/Eun/gdriver.Version=1.2.3"
+var Version = "dev"
+
+// WithUserAgent sets the User-Agent header that will be sent with every request, defaults to gdriver/<Version>.
+// Note that New fetches the root directory with the default User-Agent before the options are applied.
+func WithUserAgent(ua string) Option {
+	return func(driver *GDriver) error {
+		driver.userAgent = ua
+		return nil
+	}
+}
+
+// userAgentTransport sets the User-Agent header of the driver on every request
+type userAgentTransport struct {
+	base   http.RoundTripper
+	driver *GDriver
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ua := t.driver.userAgent
+	if ua == "" {
+		ua = "gdriver/" + Version
+	}
+
+	// a RoundTripper must not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for key, values := range req.Header {
+		r.Header[key] = values
+	}
+	r.Header.Set("User-Agent", ua)
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// withUserAgentTransport returns a copy of client that sets the User-Agent of driver
+func withUserAgentTransport(client *http.Client, driver *GDriver) *http.Client {
+	c := *client
+	c.Transport = &userAgentTransport{
+		base:   client.Transport,
+		driver: driver,
+	}
+	return &c
+}
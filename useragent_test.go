@@ -0,0 +1,40 @@
+package gdriver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTransport records the User-Agent of every request and answers with a root directory
+type recordingTransport struct {
+	userAgents []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.userAgents = append(t.userAgents, req.Header.Get("User-Agent"))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"id":"root","mimeType":"` + mimeTypeFolder + `"}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestUserAgent(t *testing.T) {
+	transport := &recordingTransport{}
+	driver, err := New(&http.Client{Transport: transport})
+	require.NoError(t, err)
+	require.Equal(t, []string{"gdriver/" + Version}, transport.userAgents)
+
+	transport = &recordingTransport{}
+	driver, err = New(&http.Client{Transport: transport}, WithUserAgent("my-app/1.0"))
+	require.NoError(t, err)
+	_, err = driver.srv.Files.Get("root").Do()
+	require.NoError(t, err)
+	// the root directory is fetched before the options are applied
+	require.Equal(t, []string{"gdriver/" + Version, "my-app/1.0"}, transport.userAgents)
+}
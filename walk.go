@@ -0,0 +1,386 @@
+package gdriver
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	drive "google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// defaultTraversalConcurrency is the amount of directories that will be listed in parallel if not specified otherwise
+const defaultTraversalConcurrency = 4
+
+// SkipDir can be returned by a WalkFunc to skip the descendants of the directory
+var SkipDir = errors.New("skip this directory")
+
+// WalkFunc will be called by Walk for every file and directory,
+// return SkipDir to skip the descendants of a directory
+type WalkFunc func(info *FileInfo) error
+
+// WithTraversalConcurrency sets the amount of directories that will be listed in parallel during recursive operations like Walk
+func WithTraversalConcurrency(n int) Option {
+	return func(driver *GDriver) error {
+		if n <= 0 {
+			return errors.New("traversal concurrency must be greater than zero")
+		}
+		driver.traversalConcurrency = n
+		return nil
+	}
+}
+
+type walkOptions struct {
+	optionalFields
+	sorted      bool
+	concurrency int
+	filter      listFilter
+	// fields are the fields that will be requested for the files
+	fields googleapi.Field
+}
+
+// WalkOption can be used to pass optional options to Walk
+type WalkOption func(options *walkOptions)
+
+// WalkSorted visits the files in a deterministic order:
+// depth first, and the entries of every directory sorted by their name.
+// Without this option files are visited in the order they were listed, which is faster.
+func WalkSorted() WalkOption {
+	return func(options *walkOptions) {
+		options.sorted = true
+	}
+}
+
+// WalkConcurrency overrides the amount of directories that will be listed in parallel (see WithTraversalConcurrency)
+func WalkConcurrency(n int) WalkOption {
+	return func(options *walkOptions) {
+		options.concurrency = n
+	}
+}
+
+// WalkCapabilities requests the capabilities of the files, see FileInfo.Capabilities
+func WalkCapabilities() WalkOption {
+	return func(options *walkOptions) {
+		options.capabilities = true
+	}
+}
+
+// WalkFolderColor requests the colors of the directories, see FileInfo.FolderColor
+func WalkFolderColor() WalkOption {
+	return func(options *walkOptions) {
+		options.folderColor = true
+	}
+}
+
+// walkFields requests fields for the files instead of the default fields
+func walkFields(fields googleapi.Field) WalkOption {
+	return func(options *walkOptions) {
+		options.fields = fields
+	}
+}
+
+// Walk calls fn for every descendant of the directory path.
+// Directories are listed in parallel (see WithTraversalConcurrency), but fn is always called from the goroutine that called Walk.
+// If fn returns an error (other than SkipDir or SkipAll) the walk stops and a CallbackError will be returned,
+// SkipAll stops the walk without an error.
+func (d *GDriver) Walk(path string, fn WalkFunc, opts ...WalkOption) error {
+	options := walkOptions{
+		concurrency: d.traversalConcurrency,
+		fields:      d.defaultListFields(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.concurrency <= 0 {
+		options.concurrency = defaultTraversalConcurrency
+	}
+	options.fields = options.apply(options.fields)
+	if err := options.filter.validate(); err != nil {
+		return err
+	}
+	var filter string
+	if clause := options.filter.clause(); clause != "" {
+		// directories are always listed to descend into them
+		filter = fmt.Sprintf("(mimeType = '%s' or (%s))", mimeTypeFolder, clause)
+	}
+	if filter != "" || options.filter.sizeFiltered() {
+		options.fields = options.filter.fields(options.fields)
+		walkFn := fn
+		fn = func(info *FileInfo) error {
+			// directories that do not match are not visited, but the walk descends into them
+			if !options.filter.includes(info) {
+				return nil
+			}
+			return walkFn(info)
+		}
+	}
+
+	file, err := d.getFile(d.rootNode, path, listFields...)
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return FileIsNotDirectoryError{Path: path}
+	}
+
+	t := newTraversal(d, options.concurrency, options.fields, filter)
+	defer t.stop()
+
+	if options.sorted {
+		err = t.walkSorted(t.list(file), fn)
+	} else {
+		err = t.walkUnordered(file, fn)
+	}
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// FileFilter can be used to select the files that WalkWithFilter visits
+type FileFilter interface {
+	// Include returns true if the file or directory should be passed to the WalkFunc
+	Include(info *FileInfo) bool
+	// DescendInto returns true if the descendants of the directory should be visited
+	DescendInto(info *FileInfo) bool
+}
+
+// WalkWithFilter walks like Walk, but only calls fn for files and directories that are included by filter
+// and only descends into directories that filter allows.
+func (d *GDriver) WalkWithFilter(path string, filter FileFilter, fn WalkFunc, opts ...WalkOption) error {
+	return d.Walk(path, func(info *FileInfo) error {
+		if filter.Include(info) {
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+		if info.IsDir() && !filter.DescendInto(info) {
+			return SkipDir
+		}
+		return nil
+	}, opts...)
+}
+
+// WalkProgressFunc will be called by WalkWithEstimate after every visited file or directory
+type WalkProgressFunc func(visited, estimated int64)
+
+// WalkWithEstimate walks like Walk and calls progress after every file or directory that was passed to fn.
+// Before the walk starts the children of the directory are counted, this count is used as a rough (lower bound) estimate
+// of the total. The estimate is raised to the amount of visited files if the walk exceeds it.
+func (d *GDriver) WalkWithEstimate(path string, fn WalkFunc, progress WalkProgressFunc, opts ...WalkOption) error {
+	file, err := d.getFile(d.rootNode, path, "files(id,mimeType)")
+	if err != nil {
+		return err
+	}
+	if !file.IsDir() {
+		return FileIsNotDirectoryError{Path: path}
+	}
+
+	var estimated int64
+	query := fmt.Sprintf("'%s' in parents and trashed = false", file.item.Id)
+	if err = d.listFiles(query, "files(id)", func(*drive.File) error {
+		estimated++
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var visited int64
+	return d.Walk(path, func(info *FileInfo) error {
+		err := fn(info)
+		visited++
+		if visited > estimated {
+			estimated = visited
+		}
+		progress(visited, estimated)
+		return err
+	}, opts...)
+}
+
+type nameFilter string
+
+func (f nameFilter) Include(info *FileInfo) bool {
+	matched, _ := path.Match(string(f), info.Name())
+	return matched
+}
+
+func (nameFilter) DescendInto(*FileInfo) bool {
+	return true
+}
+
+// NameFilter includes all files and directories whose name matches pattern (see path.Match for the syntax)
+//
+// Examples:
+//     NameFilter("*.jpg")
+func NameFilter(pattern string) FileFilter {
+	return nameFilter(pattern)
+}
+
+type mimeTypeFilter string
+
+func (f mimeTypeFilter) Include(info *FileInfo) bool {
+	return info.item.MimeType == string(f)
+}
+
+func (mimeTypeFilter) DescendInto(*FileInfo) bool {
+	return true
+}
+
+// MimeTypeFilter includes all files with the mime type
+func MimeTypeFilter(mimeType string) FileFilter {
+	return mimeTypeFilter(mimeType)
+}
+
+// listing is the result of listing a directory
+type listing struct {
+	dir      *FileInfo
+	children []*FileInfo
+	err      error
+}
+
+// traversal lists directories with a bounded amount of workers
+type traversal struct {
+	driver    *GDriver
+	fields    googleapi.Field
+	filter    string
+	semaphore chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newTraversal(d *GDriver, concurrency int, fields googleapi.Field, filter string) *traversal {
+	return &traversal{
+		driver:    d,
+		fields:    fields,
+		filter:    filter,
+		semaphore: make(chan struct{}, concurrency),
+		done:      make(chan struct{}),
+	}
+}
+
+// stop aborts all pending listings
+func (t *traversal) stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// list starts listing the directory and returns a channel that will receive the result
+func (t *traversal) list(dir *FileInfo) <-chan listing {
+	result := make(chan listing, 1)
+	t.listTo(dir, result)
+	return result
+}
+
+// listTo starts listing the directory and sends the result to the channel
+func (t *traversal) listTo(dir *FileInfo, result chan<- listing) {
+	go func() {
+		select {
+		case t.semaphore <- struct{}{}:
+		case <-t.done:
+			return
+		}
+		children, err := t.driver.listChildrenWithFields(dir, t.fields, t.filter)
+		<-t.semaphore
+
+		select {
+		case result <- listing{dir: dir, children: children, err: err}:
+		case <-t.done:
+		}
+	}()
+}
+
+func (t *traversal) walkUnordered(root *FileInfo, fn WalkFunc) error {
+	results := make(chan listing)
+	t.listTo(root, results)
+	for pending := 1; pending > 0; pending-- {
+		result := <-results
+		if result.err != nil {
+			return result.err
+		}
+		for _, child := range result.children {
+			descend, err := visit(child, fn)
+			if err != nil {
+				return err
+			}
+			if descend {
+				pending++
+				t.listTo(child, results)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *traversal) walkSorted(dirListing <-chan listing, fn WalkFunc) error {
+	result := <-dirListing
+	if result.err != nil {
+		return result.err
+	}
+	sort.Slice(result.children, func(i, j int) bool {
+		return result.children[i].Name() < result.children[j].Name()
+	})
+
+	// prefetch the listings of all sub directories
+	subListings := make(map[*FileInfo]<-chan listing)
+	for _, child := range result.children {
+		if child.IsDir() {
+			subListings[child] = t.list(child)
+		}
+	}
+
+	for _, child := range result.children {
+		descend, err := visit(child, fn)
+		if err != nil {
+			return err
+		}
+		if descend {
+			if err = t.walkSorted(subListings[child], fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visit calls fn for the file and returns true if the walk should descend into it
+func visit(file *FileInfo, fn WalkFunc) (bool, error) {
+	if err := fn(file); err != nil {
+		if err == SkipDir {
+			return false, nil
+		}
+		return false, wrapCallbackError(err)
+	}
+	return file.IsDir(), nil
+}
+
+// listChildren lists all children of the directory
+func (d *GDriver) listChildren(dir *FileInfo) ([]*FileInfo, error) {
+	return d.listChildrenWithFields(dir, listFields[0], "")
+}
+
+// listChildrenWithFields lists all children of the directory and requests fields for them,
+// filter is an additional query clause the children must match (if not empty)
+func (d *GDriver) listChildrenWithFields(dir *FileInfo, fields googleapi.Field, filter string) ([]*FileInfo, error) {
+	var children []*FileInfo
+	parentPath := dir.Path()
+	query := fmt.Sprintf("'%s' in parents and trashed = false", dir.item.Id)
+	if filter != "" {
+		query += " and " + filter
+	}
+	err := d.listFiles(query, fields, func(f *drive.File) error {
+		children = append(children, &FileInfo{
+			item:         f,
+			parentPath:   parentPath,
+			pathEscaping: d.pathEscaping,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list `%s': %v", strings.TrimPrefix(parentPath, "/"), err)
+	}
+	return children, nil
+}
@@ -0,0 +1,685 @@
+package gdriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+// listStub serves files.list requests for a static tree
+type listStub struct {
+	mu       sync.Mutex
+	children map[string][]*drive.File
+	files    map[string]*drive.File
+	// latency will be added to every list request
+	latency time.Duration
+	// pageSize is the maximum amount of files per page
+	pageSize int
+
+	active    int32
+	maxActive int32
+	// listRequests counts the list requests
+	listRequests int32
+	// getRequests counts the get requests
+	getRequests int32
+	// queries holds the results of queries that do not query the children of a directory
+	queries map[string][]*drive.File
+	// fields holds the fields of the last list request
+	fields string
+	// deleted holds the ids of the deleted files
+	deleted []string
+	// trashed holds the ids of the files that were trashed
+	trashed []string
+}
+
+var listStubParentQuery = regexp.MustCompile(`'([^']+)' in parents`)
+
+func (s *listStub) add(parentID, id, name string, dir bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file := &drive.File{Id: id, Name: name, MimeType: mimeTypeFile, Parents: []string{parentID}}
+	if dir {
+		file.MimeType = mimeTypeFolder
+	}
+	s.files[id] = file
+	s.children[parentID] = append(s.children[parentID], file)
+}
+
+func (s *listStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	active := atomic.AddInt32(&s.active, 1)
+	defer atomic.AddInt32(&s.active, -1)
+	for {
+		max := atomic.LoadInt32(&s.maxActive)
+		if active <= max || atomic.CompareAndSwapInt32(&s.maxActive, max, active) {
+			break
+		}
+	}
+	time.Sleep(s.latency)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == http.MethodDelete {
+		id := r.URL.Path[len("/drive/v3/files/"):]
+		s.deleted = append(s.deleted, id)
+		delete(s.files, id)
+		for parentID, children := range s.children {
+			for i, child := range children {
+				if child.Id == id {
+					s.children[parentID] = append(children[:i:i], children[i+1:]...)
+					break
+				}
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method == http.MethodPatch {
+		id := r.URL.Path[len("/drive/v3/files/"):]
+		var update drive.File
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if update.Trashed {
+			s.trashed = append(s.trashed, id)
+		}
+		json.NewEncoder(w).Encode(&drive.File{Id: id}) // nolint: errcheck
+		return
+	}
+
+	if r.URL.Path != "/drive/v3/files" {
+		atomic.AddInt32(&s.getRequests, 1)
+		if file, ok := s.files[r.URL.Path[len("/drive/v3/files/"):]]; ok {
+			json.NewEncoder(w).Encode(file) // nolint: errcheck
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	atomic.AddInt32(&s.listRequests, 1)
+	s.fields = r.URL.Query().Get("fields")
+	var list drive.FileList
+	if match := listStubParentQuery.FindStringSubmatch(r.URL.Query().Get("q")); match != nil {
+		children := s.children[match[1]]
+		offset, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		end := offset + s.pageSize
+		if end < len(children) {
+			list.NextPageToken = strconv.Itoa(end)
+		} else {
+			end = len(children)
+		}
+		list.Files = children[offset:end]
+	} else if files, ok := s.queries[r.URL.Query().Get("q")]; ok {
+		list.Files = files
+	} else {
+		http.Error(w, `{"error":{"code":400,"message":"Invalid Value"}}`, http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(list) // nolint: errcheck
+}
+
+func newListStub(t testing.TB) (*GDriver, *listStub, func()) {
+	stub := &listStub{
+		children: make(map[string][]*drive.File),
+		files:    make(map[string]*drive.File),
+		queries:  make(map[string][]*drive.File),
+		pageSize: 3,
+	}
+	ts := httptest.NewServer(stub)
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+
+	return &GDriver{
+		client:   ts.Client(),
+		srv:      srv,
+		rootNode: &FileInfo{item: &drive.File{Id: "root", MimeType: mimeTypeFolder}},
+	}, stub, ts.Close
+}
+
+// addTree adds dirs directories with filesPerDir files each to the root of the stub
+func (s *listStub) addTree(dirs, filesPerDir int) {
+	for i := 0; i < dirs; i++ {
+		dirID := fmt.Sprintf("dir%d", i)
+		s.add("root", dirID, fmt.Sprintf("Folder%03d", i), true)
+		for j := 0; j < filesPerDir; j++ {
+			s.add(dirID, fmt.Sprintf("%s-file%d", dirID, j), fmt.Sprintf("File%d", j), false)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Folder2", true)
+	stub.add("root", "2", "Folder1", true)
+	stub.add("root", "3", "File1", false)
+	stub.add("2", "4", "File3", false)
+	stub.add("2", "5", "File2", false)
+	stub.add("2", "6", "Folder3", true)
+	stub.add("6", "7", "File4", false)
+	stub.add("1", "8", "File5", false)
+	for i := 0; i < 5; i++ {
+		stub.add("1", fmt.Sprintf("1-%d", i), fmt.Sprintf("File6-%d", i), false)
+	}
+
+	expected := []string{
+		"File1",
+		"Folder1",
+		"Folder1/File2",
+		"Folder1/File3",
+		"Folder1/Folder3",
+		"Folder1/Folder3/File4",
+		"Folder2",
+		"Folder2/File5",
+		"Folder2/File6-0",
+		"Folder2/File6-1",
+		"Folder2/File6-2",
+		"Folder2/File6-3",
+		"Folder2/File6-4",
+	}
+
+	t.Run("sorted", func(t *testing.T) {
+		var paths []string
+		require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkSorted()))
+		require.Equal(t, expected, paths)
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		var paths []string
+		require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkConcurrency(2)))
+		sort.Strings(paths)
+		require.Equal(t, expected, paths)
+	})
+
+	t.Run("skip directory", func(t *testing.T) {
+		var paths []string
+		require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			if f.Name() == "Folder2" {
+				return SkipDir
+			}
+			return nil
+		}, WalkSorted()))
+		require.Equal(t, expected[:7], paths)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		err := driver.Walk("", func(f *FileInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+}
+
+func TestWalkWithEstimate(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.addTree(4, 5)
+
+	var paths []string
+	var calls, lastVisited, lastEstimated int64
+	require.NoError(t, driver.WalkWithEstimate("", func(f *FileInfo) error {
+		paths = append(paths, f.Path())
+		return nil
+	}, func(visited, estimated int64) {
+		calls++
+		require.Equal(t, calls, visited)
+		require.True(t, estimated >= 4)
+		require.True(t, estimated >= visited)
+		lastVisited, lastEstimated = visited, estimated
+	}))
+	require.Len(t, paths, 24)
+	require.EqualValues(t, 24, lastVisited)
+	require.EqualValues(t, 24, lastEstimated)
+}
+
+// skipFolderFilter includes all files and does not descend into directories with the name
+type skipFolderFilter string
+
+func (f skipFolderFilter) Include(info *FileInfo) bool {
+	return true
+}
+
+func (f skipFolderFilter) DescendInto(info *FileInfo) bool {
+	return info.Name() != string(f)
+}
+
+func TestWalkWithFilter(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Folder1", true)
+	stub.add("root", "2", "Image1.jpg", false)
+	stub.add("1", "3", "Image2.jpg", false)
+	stub.add("1", "4", "Text1.txt", false)
+	stub.add("1", "5", "Folder2", true)
+	stub.add("5", "6", "Image3.jpg", false)
+
+	walk := func(filter FileFilter) []string {
+		var paths []string
+		require.NoError(t, driver.WalkWithFilter("", filter, func(f *FileInfo) error {
+			paths = append(paths, f.Path())
+			return nil
+		}, WalkSorted()))
+		return paths
+	}
+
+	require.Equal(t, []string{"Folder1/Folder2/Image3.jpg", "Folder1/Image2.jpg", "Image1.jpg"}, walk(NameFilter("*.jpg")))
+	require.Equal(t, []string{"Folder1", "Folder1/Folder2"}, walk(MimeTypeFilter(mimeTypeFolder)))
+	require.Equal(t, []string{"Folder1", "Folder1/Folder2", "Folder1/Image2.jpg", "Folder1/Text1.txt", "Image1.jpg"}, walk(skipFolderFilter("Folder2")))
+}
+
+func TestWalkCapabilities(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	// a shared folder that contains a file of the user and a file of someone else
+	stub.add("root", "1", "Shared", true)
+	stub.add("1", "2", "Own.txt", false)
+	stub.add("1", "3", "Other.txt", false)
+	stub.files["2"].Capabilities = &drive.FileCapabilities{CanEdit: true, CanShare: true, CanDelete: true, CanTrash: true, CanDownload: true, CanRename: true}
+	stub.files["3"].Capabilities = &drive.FileCapabilities{CanDownload: true}
+
+	capabilities := make(map[string]Capabilities)
+	require.NoError(t, driver.Walk("Shared", func(f *FileInfo) error {
+		capabilities[f.Path()] = f.Capabilities()
+		return nil
+	}, WalkCapabilities()))
+	require.Contains(t, stub.fields, capabilitiesField)
+	require.Equal(t, map[string]Capabilities{
+		"Shared/Own.txt":   {CanEdit: true, CanShare: true, CanDelete: true, CanTrash: true, CanDownload: true, CanRename: true},
+		"Shared/Other.txt": {CanDownload: true},
+	}, capabilities)
+
+	require.NoError(t, driver.Walk("Shared", func(f *FileInfo) error {
+		return nil
+	}))
+	require.NotContains(t, stub.fields, "capabilities")
+}
+
+func TestStatAndListCapabilities(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "1", "Shared", true)
+	stub.add("1", "2", "Other.txt", false)
+	stub.files["1"].Capabilities = &drive.FileCapabilities{CanEdit: true, CanRename: true}
+	stub.files["2"].Capabilities = &drive.FileCapabilities{CanDownload: true}
+
+	fi, err := driver.Stat("Shared", StatCapabilities())
+	require.NoError(t, err)
+	require.Contains(t, stub.fields, capabilitiesField)
+	require.Equal(t, Capabilities{CanEdit: true, CanRename: true}, fi.Capabilities())
+
+	var capabilities Capabilities
+	require.NoError(t, driver.ListDirectory("Shared", func(f *FileInfo) error {
+		capabilities = f.Capabilities()
+		return nil
+	}, ListCapabilities()))
+	require.Contains(t, stub.fields, capabilitiesField)
+	require.Equal(t, Capabilities{CanDownload: true}, capabilities)
+
+	require.NoError(t, driver.ListDirectory("Shared", func(f *FileInfo) error {
+		return nil
+	}))
+	require.NotContains(t, stub.fields, "capabilities")
+}
+
+func TestSkipAll(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.addTree(10, 2)
+
+	t.Run("ListDirectory", func(t *testing.T) {
+		atomic.StoreInt32(&stub.listRequests, 0)
+		var files int
+		require.NoError(t, driver.ListDirectory("", func(f *FileInfo) error {
+			files++
+			return SkipAll
+		}))
+		require.Equal(t, 1, files)
+		// no further pages must be fetched
+		require.EqualValues(t, 1, atomic.LoadInt32(&stub.listRequests))
+
+		err := driver.ListDirectory("", func(f *FileInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+
+	t.Run("ListTrashDirect", func(t *testing.T) {
+		atomic.StoreInt32(&stub.listRequests, 0)
+		var files int
+		require.NoError(t, driver.ListTrashDirect("", func(f *FileInfo) error {
+			files++
+			return SkipAll
+		}))
+		require.Equal(t, 1, files)
+		require.EqualValues(t, 1, atomic.LoadInt32(&stub.listRequests))
+
+		err := driver.ListTrashDirect("", func(f *FileInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		for _, opts := range [][]WalkOption{nil, {WalkSorted()}} {
+			var files int
+			require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+				if !f.IsDir() {
+					files++
+					return SkipAll
+				}
+				return nil
+			}, opts...))
+			require.Equal(t, 1, files)
+		}
+	})
+
+	t.Run("ListTrash", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+		newFile(t, driver, "File2", "Hello World")
+		require.NoError(t, driver.Trash("File1"))
+		require.NoError(t, driver.Trash("File2"))
+
+		var files int
+		require.NoError(t, driver.ListTrash("", func(f *FileInfo) error {
+			files++
+			return SkipAll
+		}))
+		require.Equal(t, 1, files)
+
+		err := driver.ListTrash("", func(f *FileInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+
+	t.Run("ListOrphanedFiles", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "Folder1/File1", "Hello World")
+		newFile(t, driver, "Folder1/File2", "Hello World")
+		folder, err := driver.Stat("Folder1")
+		require.NoError(t, err)
+		// detach the files from Folder1, so they are not reachable anymore
+		for _, name := range []string{"File1", "File2"} {
+			fi, err := driver.Stat("Folder1/" + name)
+			require.NoError(t, err)
+			_, err = driver.srv.Files.Update(fi.item.Id, &drive.File{}).RemoveParents(folder.item.Id).Do()
+			require.NoError(t, err)
+			defer driver.srv.Files.Delete(fi.item.Id).Do() // nolint: errcheck
+		}
+
+		var files int
+		require.NoError(t, driver.ListOrphanedFiles(func(f *FileInfo) error {
+			files++
+			return SkipAll
+		}))
+		require.Equal(t, 1, files)
+
+		err = driver.ListOrphanedFiles(func(f *FileInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+
+	t.Run("ListComments", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		newFile(t, driver, "File1", "Hello World")
+		fi, err := driver.Stat("File1")
+		require.NoError(t, err)
+		for _, content := range []string{"Comment 1", "Comment 2"} {
+			_, err = driver.srv.Comments.Create(fi.item.Id, &drive.Comment{Content: content}).Fields("id").Do()
+			require.NoError(t, err)
+		}
+
+		var comments int
+		require.NoError(t, driver.ListComments("File1", func(c *CommentInfo) error {
+			comments++
+			return SkipAll
+		}))
+		require.Equal(t, 1, comments)
+
+		err = driver.ListComments("File1", func(c *CommentInfo) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+
+	t.Run("GetChangedFilesSinceToken", func(t *testing.T) {
+		driver, teardown := setup(t)
+		defer teardown()
+
+		token, err := driver.GetChangesStartToken()
+		require.NoError(t, err)
+		newFile(t, driver, "File1", "Hello World")
+		newFile(t, driver, "File2", "Hello World")
+
+		var changes int
+		nextToken, err := driver.GetChangedFilesSinceToken(token, func(f *FileInfo, removed bool) error {
+			changes++
+			return SkipAll
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, changes)
+		// the changes of the page are reported again with the returned token
+		require.Equal(t, token, nextToken)
+
+		_, err = driver.GetChangedFilesSinceToken(token, func(f *FileInfo, removed bool) error {
+			return errors.New("stop")
+		})
+		require.Equal(t, CallbackError{NestedError: errors.New("stop")}, err)
+	})
+}
+
+func TestWalkConcurrency(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.latency = 10 * time.Millisecond
+	stub.addTree(20, 1)
+	require.NoError(t, WithTraversalConcurrency(3)(driver))
+
+	var files int
+	require.NoError(t, driver.Walk("", func(f *FileInfo) error {
+		if !f.IsDir() {
+			files++
+		}
+		return nil
+	}))
+	require.Equal(t, 20, files)
+	require.True(t, stub.maxActive > 1, "expected parallel list requests")
+	require.True(t, stub.maxActive <= 3, "expected at most 3 parallel list requests, got %d", stub.maxActive)
+
+	require.Error(t, WithTraversalConcurrency(0)(driver))
+}
+
+func benchmarkWalk(b *testing.B, opts ...WalkOption) {
+	driver, stub, teardown := newListStub(b)
+	defer teardown()
+
+	stub.latency = time.Millisecond
+	stub.pageSize = 100
+	stub.addTree(300, 3)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, driver.Walk("", func(f *FileInfo) error {
+			return nil
+		}, opts...))
+	}
+}
+
+func BenchmarkWalkSerial(b *testing.B) {
+	benchmarkWalk(b, WalkConcurrency(1))
+}
+
+func BenchmarkWalkConcurrent(b *testing.B) {
+	benchmarkWalk(b, WalkConcurrency(8))
+}
+
+func BenchmarkWalkConcurrentSorted(b *testing.B) {
+	benchmarkWalk(b, WalkConcurrency(8), WalkSorted())
+}
+
+func TestChunkedListDirectory(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	for i := 0; i < 7; i++ {
+		stub.add("root", strconv.Itoa(i), fmt.Sprintf("File%d", i), false)
+	}
+	// the chunks must not depend on the pages
+	stub.pageSize = 5
+
+	chunks, errs := driver.ChunkedListDirectory("", 3)
+	var sizes []int
+	var names []string
+	for chunk := range chunks {
+		sizes = append(sizes, len(chunk))
+		for _, f := range chunk {
+			names = append(names, f.Name())
+		}
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, []int{3, 3, 1}, sizes)
+	require.Equal(t, []string{"File0", "File1", "File2", "File3", "File4", "File5", "File6"}, names)
+
+	_, errs = driver.ChunkedListDirectory("", 0)
+	require.Error(t, <-errs)
+}
+
+func TestReadDirN(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	for i := 0; i < 7; i++ {
+		stub.add("root", strconv.Itoa(i), fmt.Sprintf("File%d", i), false)
+	}
+	// the reads must not depend on the pages
+	stub.pageSize = 3
+
+	names := func(files []*FileInfo) []string {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		return names
+	}
+
+	_, err := driver.ReadDirN("", 2, nil)
+	require.Error(t, err)
+
+	atomic.StoreInt32(&stub.listRequests, 0)
+	var cursor DirCursor
+	var reads [][]string
+	for {
+		files, err := driver.ReadDirN("", 2, &cursor)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		reads = append(reads, names(files))
+	}
+	require.Equal(t, [][]string{{"File0", "File1"}, {"File2", "File3"}, {"File4", "File5"}, {"File6"}}, reads)
+	// the rest of a page is buffered in the cursor, every page is only fetched once
+	require.Equal(t, int32(3), atomic.LoadInt32(&stub.listRequests))
+	// exhausted cursors stay exhausted
+	_, err = driver.ReadDirN("", 2, &cursor)
+	require.Equal(t, io.EOF, err)
+
+	cursor = DirCursor{}
+	files, err := driver.ReadDirN("", 4, &cursor)
+	require.NoError(t, err)
+	require.Equal(t, []string{"File0", "File1", "File2", "File3"}, names(files))
+	files, err = driver.ReadDirN("", 0, &cursor)
+	require.NoError(t, err)
+	require.Equal(t, []string{"File4", "File5", "File6"}, names(files))
+	files, err = driver.ReadDirN("", 0, &cursor)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	_, err = driver.ReadDirN("", 1, &cursor)
+	require.Equal(t, io.EOF, err)
+
+	// an empty directory
+	driver, stub, teardown = newListStub(t)
+	defer teardown()
+	stub.add("root", "1", "Folder1", true)
+	cursor = DirCursor{}
+	_, err = driver.ReadDirN("Folder1", 1, &cursor)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestGetFileCountByMimeType(t *testing.T) {
+	driver, stub, teardown := newListStub(t)
+	defer teardown()
+
+	stub.add("root", "file1", "File1.txt", false)
+	stub.add("root", "file2", "File2.txt", false)
+	stub.add("root", "dir1", "Folder1", true)
+	stub.add("dir1", "image1", "Image1.png", false)
+	stub.files["file1"].MimeType = "text/plain"
+	stub.files["file2"].MimeType = "text/plain"
+	stub.files["image1"].MimeType = "image/png"
+
+	counts, err := driver.GetFileCountByMimeType("")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		"text/plain":   2,
+		mimeTypeFolder: 1,
+		"image/png":    1,
+	}, counts)
+	require.Equal(t, "files(id,mimeType),nextPageToken", stub.fields)
+}
+
+func BenchmarkGetFileCountByMimeType(b *testing.B) {
+	driver, stub, teardown := newListStub(b)
+	defer teardown()
+
+	stub.latency = time.Millisecond
+	stub.pageSize = 100
+	stub.addTree(300, 3)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counts, err := driver.GetFileCountByMimeType("")
+		require.NoError(b, err)
+		require.Equal(b, 900, counts[mimeTypeFile])
+		require.Equal(b, 300, counts[mimeTypeFolder])
+	}
+}
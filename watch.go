@@ -0,0 +1,86 @@
+package gdriver
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// WatchPath calls Stat for path every interval and calls onChange if the file was changed, created or deleted since the last call,
+// for deleted files onChange is called with nil.
+// Changes are detected by comparing the id, the md5 checksum and the modified time.
+// Transient errors (e.g. rate limits or network errors) are retried with an increasing delay.
+// WatchPath blocks until ctx is done (and returns ctx.Err()), another error occurs,
+// or onChange returns an error (a CallbackError will be returned, SkipAll stops without an error).
+//
+// Examples:
+//     WatchPath(ctx, "config.yaml", time.Minute, func(fi *FileInfo) error { return reload(fi) })
+func (d *GDriver) WatchPath(ctx context.Context, path string, interval time.Duration, onChange func(*FileInfo) error) error {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	last, err := d.watchStat(path)
+	if err != nil {
+		return err
+	}
+
+	delay := interval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		current, err := d.watchStat(path)
+		if err != nil {
+			if !isTransientError(err) {
+				return err
+			}
+			if delay *= 2; delay > batchMaxBackoff {
+				delay = batchMaxBackoff
+			}
+			timer.Reset(delay)
+			continue
+		}
+		delay = interval
+
+		if watchChanged(last, current) {
+			if err = onChange(current); err != nil {
+				return callbackError(err)
+			}
+		}
+		last = current
+		timer.Reset(delay)
+	}
+}
+
+// watchStat returns the FileInfo with md5 checksum of path, or nil if it does not exist
+func (d *GDriver) watchStat(path string) (*FileInfo, error) {
+	file, err := d.getFile(d.rootNode, path, md5ListFields())
+	if IsNotExist(err) {
+		return nil, nil
+	}
+	return file, err
+}
+
+// watchChanged returns true if the file changed between two observations of WatchPath
+func watchChanged(last, current *FileInfo) bool {
+	if last == nil || current == nil {
+		return last != current
+	}
+	return last.item.Id != current.item.Id ||
+		last.item.Md5Checksum != current.item.Md5Checksum ||
+		last.item.ModifiedTime != current.item.ModifiedTime
+}
+
+// isTransientError returns true if the error is temporary, e.g. a rate limit or a network error
+func isTransientError(err error) bool {
+	if isRetryableError(err) {
+		return true
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
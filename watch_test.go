@@ -0,0 +1,94 @@
+package gdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	drive "google.golang.org/api/drive/v3"
+)
+
+func TestWatchPath(t *testing.T) {
+	file := func(id, md5, modifiedTime string) string {
+		return fmt.Sprintf(`{"files":[{"id":"%s","name":"config.yaml","mimeType":"%s","md5Checksum":"%s","modifiedTime":"%s"}]}`,
+			id, mimeTypeFile, md5, modifiedTime)
+	}
+	var mu sync.Mutex
+	responses := []string{
+		file("1", "a", "2019-01-01T00:00:00Z"),
+		file("1", "a", "2019-01-01T00:00:00Z"),
+		"",
+		file("1", "b", "2019-01-02T00:00:00Z"),
+		`{"files":[]}`,
+		`{"files":[]}`,
+		file("2", "c", "2019-01-03T00:00:00Z"),
+	}
+	var fields []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fields = append(fields, r.URL.Query().Get("fields"))
+		response := responses[0]
+		if len(responses) > 1 {
+			responses = responses[1:]
+		}
+		mu.Unlock()
+		if response == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"code":503,"message":"unavailable"}}`)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	defer ts.Close()
+
+	srv, err := drive.New(ts.Client())
+	require.NoError(t, err)
+	srv.BasePath = ts.URL + "/drive/v3/"
+	driver := &GDriver{
+		srv:      srv,
+		rootNode: &FileInfo{item: &drive.File{Id: "root", MimeType: mimeTypeFolder}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []string
+	err = driver.WatchPath(ctx, "config.yaml", time.Millisecond, func(fi *FileInfo) error {
+		if fi == nil {
+			events = append(events, "deleted")
+			return nil
+		}
+		events = append(events, fi.DriveFile().Id+" "+fi.DriveFile().Md5Checksum)
+		if len(events) == 3 {
+			cancel()
+		}
+		return nil
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, []string{"1 b", "deleted", "2 c"}, events)
+	mu.Lock()
+	for _, f := range fields {
+		require.Contains(t, f, string(md5ListFields()))
+	}
+	mu.Unlock()
+
+	// nothing changes anymore
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, driver.WatchPath(ctx, "config.yaml", time.Millisecond, func(fi *FileInfo) error {
+		return errors.New("unexpected change")
+	}))
+
+	mu.Lock()
+	responses = []string{file("2", "c", "2019-01-03T00:00:00Z"), file("2", "d", "2019-01-04T00:00:00Z")}
+	mu.Unlock()
+	err = driver.WatchPath(context.Background(), "config.yaml", time.Millisecond, func(fi *FileInfo) error {
+		return errors.New("some error")
+	})
+	require.Equal(t, CallbackError{NestedError: errors.New("some error")}, err)
+}